@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/service"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util"
+)
+
+var getAllSubscriptions = &Endpoint{
+	Path:    "/admin/subscriptions",
+	Method:  http.MethodGet,
+	Execute: handleGetAllSubscriptions,
+}
+
+var exportAdminSubscriptions = &Endpoint{
+	Path:    "/admin/subscriptions/export",
+	Method:  http.MethodGet,
+	Execute: handleExportSubscriptions,
+}
+
+var importAdminSubscriptions = &Endpoint{
+	Path:    "/admin/subscriptions/import",
+	Method:  http.MethodPost,
+	Execute: handleImportSubscriptions,
+}
+
+// handleGetAllSubscriptions returns every subscription of the server with its team, channel and owner,
+// only the subscriptions to the space of the `space` query parameter when it is set.
+func handleGetAllSubscriptions(w http.ResponseWriter, r *http.Request, _ *Plugin) {
+	if !checkSystemAdminHTTP(w, r) {
+		return
+	}
+
+	overviews, err := service.GetSubscriptionOverviews(r.URL.Query().Get("space"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	b, _ := json.Marshal(overviews)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+// handleExportSubscriptions returns the export of the subscriptions of every channel of the server as a JSON file.
+func handleExportSubscriptions(w http.ResponseWriter, r *http.Request, _ *Plugin) {
+	if !checkSystemAdminHTTP(w, r) {
+		return
+	}
+
+	data, err := service.ExportSubscriptions("")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"confluence-subscriptions-%s.json\"", time.Now().UTC().Format("2006-01-02")))
+	_, _ = w.Write(data)
+}
+
+// handleImportSubscriptions imports the subscriptions of an export of the server into the channels of the same
+// team and channel names as the ones they were exported from, and returns the outcome of each of them.
+func handleImportSubscriptions(w http.ResponseWriter, r *http.Request, p *Plugin) {
+	if !checkSystemAdminHTTP(w, r) {
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxImportSize+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxImportSize {
+		http.Error(w, "the subscription export is too big", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	userID := r.Header.Get(config.HeaderMattermostUserID)
+	results, err := service.ImportSubscriptions(data, "", userID, p.importSubscriptionResolver(userID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	b, _ := json.Marshal(results)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+// checkSystemAdminHTTP writes an error response and returns false if the user of the request is not a system admin.
+func checkSystemAdminHTTP(w http.ResponseWriter, r *http.Request) bool {
+	userID := r.Header.Get(config.HeaderMattermostUserID)
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	if !util.IsSystemAdmin(userID) {
+		http.Error(w, "Only system administrators can manage the subscriptions of every channel", http.StatusForbidden)
+		return false
+	}
+	return true
+}
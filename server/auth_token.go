@@ -4,17 +4,12 @@
 package main
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-	"encoding/base64"
 	"encoding/json"
-	"io"
 
-	"github.com/pkg/errors"
 	"golang.org/x/oauth2"
 
 	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util"
 )
 
 type AuthToken struct {
@@ -33,24 +28,24 @@ func (p *Plugin) NewEncodedAuthToken(token *oauth2.Token) (encodedToken string,
 		return "", err
 	}
 
-	encrypted, err := encrypt(jsonBytes, []byte(encryptionSecret))
+	encrypted, err := util.Encrypt(jsonBytes, []byte(encryptionSecret))
 	if err != nil {
 		return "", err
 	}
 
-	return encode(encrypted), nil
+	return util.Encode(encrypted), nil
 }
 
 func (p *Plugin) ParseAuthToken(encoded string) (token *oauth2.Token, returnErr error) {
 	t := AuthToken{}
 	encryptionSecret := config.GetConfig().EncryptionKey
 
-	decoded, err := decode(encoded)
+	decoded, err := util.Decode(encoded)
 	if err != nil {
 		return nil, err
 	}
 
-	jsonBytes, err := decrypt(decoded, []byte(encryptionSecret))
+	jsonBytes, err := util.Decrypt(decoded, []byte(encryptionSecret))
 	if err != nil {
 		return nil, err
 	}
@@ -61,71 +56,3 @@ func (p *Plugin) ParseAuthToken(encoded string) (token *oauth2.Token, returnErr
 
 	return t.Token, nil
 }
-
-func encode(encrypted []byte) string {
-	encoded := make([]byte, base64.URLEncoding.EncodedLen(len(encrypted)))
-	base64.URLEncoding.Encode(encoded, encrypted)
-	return string(encoded)
-}
-
-func encrypt(plain, secret []byte) ([]byte, error) {
-	if len(secret) == 0 {
-		return plain, nil
-	}
-
-	block, err := aes.NewCipher(secret)
-	if err != nil {
-		return nil, err
-	}
-
-	aesgcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, err
-	}
-
-	nonce := make([]byte, aesgcm.NonceSize())
-	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
-		return nil, err
-	}
-
-	sealed := aesgcm.Seal(nil, nonce, plain, nil)
-	return append(nonce, sealed...), nil
-}
-
-func decode(encoded string) ([]byte, error) {
-	decoded := make([]byte, base64.URLEncoding.DecodedLen(len(encoded)))
-	n, err := base64.URLEncoding.Decode(decoded, []byte(encoded))
-	if err != nil {
-		return nil, err
-	}
-	return decoded[:n], nil
-}
-
-func decrypt(encrypted, secret []byte) ([]byte, error) {
-	if len(secret) == 0 {
-		return encrypted, nil
-	}
-
-	block, err := aes.NewCipher(secret)
-	if err != nil {
-		return nil, err
-	}
-
-	aesgcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, err
-	}
-
-	nonceSize := aesgcm.NonceSize()
-	if len(encrypted) < nonceSize {
-		return nil, errors.New("token too short")
-	}
-
-	nonce, encrypted := encrypted[:nonceSize], encrypted[nonceSize:]
-	plain, err := aesgcm.Open(nil, nonce, encrypted, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	return plain, nil
-}
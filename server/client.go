@@ -15,4 +15,7 @@ type RESTService interface {
 	GetSpaceData(string) (*SpaceResponse, error)
 	GetPageData(int) (*PageResponse, error)
 	GetSpaceKeyFromSpaceID(int64) (string, error)
+	GetPageIDByTitle(spaceKey, title string) (string, error)
+	ContentMatchesCQL(cql, contentID string) (bool, error)
+	ValidateCQL(cql string) error
 }
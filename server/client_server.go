@@ -3,11 +3,13 @@ package main
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
 
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
 	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
 	"github.com/mattermost/mattermost-plugin-confluence/server/service"
 	"github.com/mattermost/mattermost-plugin-confluence/server/util"
@@ -17,14 +19,20 @@ import (
 const (
 	PathCurrentUser = "/rest/api/user/current"
 	PathContentData = "/rest/api/content/"
+	PathSearch      = "/rest/api/content/search"
 	PathSpaceData   = "/rest/api/space/"
 	PathAdminData   = "/rest/api/audit"
+
+	pageExpand    = "body.view,container,space,history,version,metadata.labels,ancestors"
+	versionExpand = "body.view"
+	commentExpand = "body.view,container,space,history,version,ancestors,container.metadata.labels,container.ancestors"
 )
 
 const (
 	Comment = "comment"
 	Space   = "space"
 	Page    = "page"
+	Blog    = "blog"
 )
 
 const pageSize = 10
@@ -54,10 +62,40 @@ type SpaceResponse struct {
 }
 
 type CommentContainer struct {
+	ID        string     `json:"id"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	Links     Links      `json:"_links"`
+	Metadata  Metadata   `json:"metadata"`
+	Ancestors []Ancestor `json:"ancestors"`
+}
+
+type Ancestor struct {
 	ID    string `json:"id"`
-	Type  string `json:"type"`
 	Title string `json:"title"`
-	Links Links  `json:"_links"`
+}
+
+type Label struct {
+	Prefix string `json:"prefix"`
+	Name   string `json:"name"`
+}
+
+type Labels struct {
+	Results []Label `json:"results"`
+}
+
+type Metadata struct {
+	Labels Labels `json:"labels"`
+}
+
+// LabelNames returns the names of the labels, as shown in Confluence.
+// It is never nil, as the labels are always expanded, so content without labels is told apart from events without them.
+func (m Metadata) LabelNames() []string {
+	names := []string{}
+	for _, label := range m.Labels.Results {
+		names = append(names, label.Name)
+	}
+	return names
 }
 
 type Links struct {
@@ -74,6 +112,13 @@ type Body struct {
 
 type CreatedBy struct {
 	Username string `json:"username"`
+	UserKey  string `json:"userKey"`
+}
+
+type Version struct {
+	Number    int       `json:"number"`
+	MinorEdit bool      `json:"minorEdit"`
+	By        CreatedBy `json:"by"`
 }
 
 type History struct {
@@ -88,22 +133,33 @@ type CommentResponse struct {
 	Body      Body             `json:"body"`
 	Links     Links            `json:"_links"`
 	History   History          `json:"history"`
+	Version   Version          `json:"version"`
+	// Ancestors are the comments the comment replies to, the closest last.
+	Ancestors []Ancestor `json:"ancestors"`
 }
 
 type PageResponse struct {
-	ID      string        `json:"id"`
-	Title   string        `json:"title"`
-	Space   SpaceResponse `json:"space"`
-	Body    Body          `json:"body"`
-	Links   Links         `json:"_links"`
-	History History       `json:"history"`
+	ID        string        `json:"id"`
+	Title     string        `json:"title"`
+	Space     SpaceResponse `json:"space"`
+	Body      Body          `json:"body"`
+	Links     Links         `json:"_links"`
+	History   History       `json:"history"`
+	Version   Version       `json:"version"`
+	Metadata  Metadata      `json:"metadata"`
+	Ancestors []Ancestor    `json:"ancestors"`
 }
 
 type ConfluenceServerEvent struct {
 	Comment *CommentResponse
 	Page    *PageResponse
+	Blog    *PageResponse
 	Space   *SpaceResponse
 	BaseURL string
+	// UserKey is the key of the user who triggered the event, as sent in the webhook payload.
+	UserKey string
+	// ContentDiff is what changed in the page or blog post since its previous version, for updates when it could be fetched.
+	ContentDiff *util.LineDiff
 }
 
 func newServerClient(url string, httpClient *http.Client) Client {
@@ -144,6 +200,19 @@ func (csc *confluenceServerClient) GetEventData(webhookPayload *serializer.Confl
 		if err != nil {
 			return nil, errors.Errorf("error getting page data for the event. PageID %d. Error: %v", webhookPayload.Page.ID, err)
 		}
+		if webhookPayload.Event == serializer.PageUpdatedEvent {
+			confluenceServerEvent.ContentDiff = getContentDiff(confluenceServerEvent.Page, csc.GetPageVersionBody)
+		}
+	}
+
+	if strings.Contains(webhookPayload.Event, Blog) {
+		confluenceServerEvent.Blog, err = csc.GetPageData(int(webhookPayload.Blog.ID))
+		if err != nil {
+			return nil, errors.Errorf("error getting blog post data for the event. BlogID %d. Error: %v", webhookPayload.Blog.ID, err)
+		}
+		if webhookPayload.Event == serializer.BlogUpdatedEvent {
+			confluenceServerEvent.ContentDiff = getContentDiff(confluenceServerEvent.Blog, csc.GetPageVersionBody)
+		}
 	}
 
 	if strings.Contains(webhookPayload.Event, Space) {
@@ -158,7 +227,7 @@ func (csc *confluenceServerClient) GetEventData(webhookPayload *serializer.Confl
 
 func (csc *confluenceServerClient) GetCommentData(webhookPayload *serializer.ConfluenceServerWebhookPayload) (*CommentResponse, error) {
 	commentResponse := &CommentResponse{}
-	if _, _, err := service.CallJSONWithURL(csc.URL, fmt.Sprintf("%s%s?expand=body.view,container,space,history", PathContentData, strconv.FormatInt(webhookPayload.Comment.ID, 10)), http.MethodGet, nil, commentResponse, csc.HTTPClient); err != nil {
+	if _, _, err := service.CallJSONWithURL(csc.URL, fmt.Sprintf("%s%s?expand=%s", PathContentData, strconv.FormatInt(webhookPayload.Comment.ID, 10), commentExpand), http.MethodGet, nil, commentResponse, csc.HTTPClient); err != nil {
 		return nil, err
 	}
 
@@ -169,7 +238,7 @@ func (csc *confluenceServerClient) GetCommentData(webhookPayload *serializer.Con
 
 func (csc *confluenceServerClient) GetPageData(pageID int) (*PageResponse, error) {
 	pageResponse := &PageResponse{}
-	if _, _, err := service.CallJSONWithURL(csc.URL, fmt.Sprintf("%s%s?status=any&expand=body.view,container,space,history", PathContentData, strconv.Itoa(pageID)), http.MethodGet, nil, pageResponse, csc.HTTPClient); err != nil {
+	if _, _, err := service.CallJSONWithURL(csc.URL, fmt.Sprintf("%s%s?status=any&expand=%s", PathContentData, strconv.Itoa(pageID), pageExpand), http.MethodGet, nil, pageResponse, csc.HTTPClient); err != nil {
 		return nil, err
 	}
 
@@ -178,6 +247,31 @@ func (csc *confluenceServerClient) GetPageData(pageID int) (*PageResponse, error
 	return pageResponse, nil
 }
 
+// GetPageVersionBody returns the text of the body of a version of the page or blog post.
+func (csc *confluenceServerClient) GetPageVersionBody(pageID string, version int) (string, error) {
+	pageResponse := &PageResponse{}
+	if _, _, err := service.CallJSONWithURL(csc.URL, fmt.Sprintf("%s%s?status=historical&version=%d&expand=%s", PathContentData, pageID, version, versionExpand), http.MethodGet, nil, pageResponse, csc.HTTPClient); err != nil {
+		return "", err
+	}
+
+	return util.GetBodyForExcerpt(pageResponse.Body.View.Value), nil
+}
+
+// getContentDiff returns what changed in the page or blog post since its previous version, fetched with getVersionBody.
+// The notification is still sent without the changes when the previous version can not be fetched.
+func getContentDiff(page *PageResponse, getVersionBody func(pageID string, version int) (string, error)) *util.LineDiff {
+	if page.Version.Number <= 1 {
+		return nil
+	}
+
+	previous, err := getVersionBody(page.ID, page.Version.Number-1)
+	if err != nil {
+		config.Mattermost.LogWarn("Unable to get the previous version of the content.", "PageID", page.ID, "Version", page.Version.Number-1, "Error", err.Error())
+		return nil
+	}
+	return util.DiffLines(previous, page.Body.View.Value)
+}
+
 func (csc *confluenceServerClient) GetSpaceData(spaceKey string) (*SpaceResponse, error) {
 	spaceResponse := &SpaceResponse{}
 	if _, _, err := service.CallJSONWithURL(csc.URL, fmt.Sprintf("%s%s?status=any", PathSpaceData, spaceKey), http.MethodGet, nil, spaceResponse, csc.HTTPClient); err != nil {
@@ -187,6 +281,48 @@ func (csc *confluenceServerClient) GetSpaceData(spaceKey string) (*SpaceResponse
 	return spaceResponse, nil
 }
 
+type contentSearchResponse struct {
+	Results []struct {
+		ID string `json:"id"`
+	} `json:"results"`
+}
+
+// GetPageIDByTitle returns the ID of the page with the title in the space,
+// or a not found response error when the user can see no such page.
+func (csc *confluenceServerClient) GetPageIDByTitle(spaceKey, title string) (string, error) {
+	path := fmt.Sprintf("%s?type=page&spaceKey=%s&title=%s", strings.TrimSuffix(PathContentData, "/"), url.QueryEscape(spaceKey), url.QueryEscape(title))
+	response := &contentSearchResponse{}
+	if _, _, err := service.CallJSONWithURL(csc.URL, path, http.MethodGet, nil, response, csc.HTTPClient); err != nil {
+		return "", err
+	}
+
+	if len(response.Results) == 0 {
+		return "", &service.ResponseError{StatusCode: http.StatusNotFound, Message: "page not found"}
+	}
+	return response.Results[0].ID, nil
+}
+
+// ContentMatchesCQL reports whether the content with the ID is found by the CQL expression.
+func (csc *confluenceServerClient) ContentMatchesCQL(cql, contentID string) (bool, error) {
+	response := &contentSearchResponse{}
+	if _, _, err := service.CallJSONWithURL(csc.URL, cqlContentSearchPath(cql, contentID), http.MethodGet, nil, response, csc.HTTPClient); err != nil {
+		return false, err
+	}
+	return len(response.Results) > 0, nil
+}
+
+// ValidateCQL runs a search with the CQL expression, which Confluence responds to with a bad request status when it is not valid.
+func (csc *confluenceServerClient) ValidateCQL(cql string) error {
+	path := fmt.Sprintf("%s?cql=%s&limit=1", PathSearch, url.QueryEscape(cql))
+	_, _, err := service.CallJSONWithURL(csc.URL, path, http.MethodGet, nil, &contentSearchResponse{}, csc.HTTPClient)
+	return err
+}
+
+// cqlContentSearchPath returns the path of a search for the content with the ID among the content found by the CQL expression.
+func cqlContentSearchPath(cql, contentID string) string {
+	return fmt.Sprintf("%s?cql=%s&limit=1", PathSearch, url.QueryEscape(fmt.Sprintf("(%s) AND id=%s", cql, contentID)))
+}
+
 type apiResponse struct {
 	Results []struct {
 		ID   int64  `json:"id"`
@@ -2,7 +2,10 @@ package main
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -14,6 +17,7 @@ import (
 	"github.com/mattermost/mattermost-plugin-confluence/server/service"
 	"github.com/mattermost/mattermost-plugin-confluence/server/store"
 	"github.com/mattermost/mattermost-plugin-confluence/server/util"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
 )
 
 type PluginAPI interface {
@@ -32,24 +36,98 @@ const (
 	subscriptionDeleteSuccess = "Subscription **%s** has been deleted."
 	noChannelSubscription     = "No subscriptions found for this channel."
 	commonHelpText            = "###### Mattermost Confluence Plugin - Slash Command Help\n\n" +
-		"* `/confluence connect` - Connect your Mattermost user to Confluence.\n" +
-		"* `/confluence disconnect` - Disconnect your Mattermost user from Confluence.\n" +
+		"* `/confluence connect [instance-url]` - Connect your Mattermost user to Confluence. The instance URL is only needed when several instances are installed.\n" +
+		"* `/confluence disconnect [instance-url]` - Disconnect your Mattermost user from Confluence.\n" +
 		"* `/confluence subscribe` - Subscribe the current channel to notifications from Confluence.\n" +
+		"* `/confluence subscribe <space|page|page-tree> <space-key|page-id|page-url> --name \"<name>\" [--events <event,...>] [--url <confluence-url>]` - " +
+		"Subscribe the current channel without the subscription dialog. Use `--preset \"<preset>\"` for the events and filters of a preset. Other options are `--ignore-minor-edits`, `--include-authors`, `--exclude-authors`, " +
+		"and for spaces `--content-type <pages|blogs|both>`, `--include-labels` and `--exclude-labels`. Events default to every event.\n" +
+		"* `/confluence subscribe cql \"<query>\" --name \"<name>\" [--events <event,...>] [--url <confluence-url>]` - Subscribe the current channel to the pages and blog posts found by a CQL query, on Confluence Server and Data Center 9 or later. Quote values in the query with single quotes.\n" +
+		"* `/confluence subscribe all-spaces --name \"<name>\" [--exclude-spaces <space-key,...>] [--exclude-personal-spaces] [--events <event,...>] [--url <confluence-url>]` - Subscribe the current channel to every space of the Confluence instance, but the excluded ones.\n" +
 		"* `/confluence unsubscribe \"<name>\"` - Unsubscribe the current channel from notifications associated with the given subscription name.\n" +
 		"* `/confluence list` - List all subscriptions for the current channel.\n" +
-		"* `/confluence edit \"<name>\"` - Edit the subscription settings associated with the given subscription name.\n"
+		"* `/confluence edit \"<name>\"` - Edit the subscription settings associated with the given subscription name.\n" +
+		"* `/confluence pause \"<name>\" [duration]` - Stop the notifications of the given subscription, for a duration like `2h` or `3d`, or until it is resumed.\n" +
+		"* `/confluence resume \"<name>\"` - Resume the notifications of the given subscription.\n" +
+		"* `/confluence quiet-hours [\"<name>\"] <HH:MM-HH:MM|off> [time-zone]` - Stop the notifications of the given subscription, or of every subscription of the current channel, during the given hours of each day.\n" +
+		"* `/confluence digest [hourly|daily <HH:MM> [time-zone]|immediate]` - Deliver the notifications of the current channel in an hourly or daily digest, grouped by space and page, or right away again. Shows the current mode without arguments.\n" +
+		"* `/confluence preset list` - List the subscription presets defined by system administrators.\n" +
+		"* `/confluence template list` - List the default notification templates and the ones of the subscriptions of the current channel.\n" +
+		"* `/confluence template <set|reset> <event> [\"<template>\"] --subscription \"<name>\"` - Set or remove the notification template of the given subscription for an event, overriding the default one.\n" +
+		"* `/confluence template preview <event> [\"<template>\"] [--subscription \"<name>\"]` - Render the given template, or the one used for the event, with a sample event.\n" +
+		"* `/confluence subscription <copy|move> <\"<name>\"|--all> ~<channel>` - Copy or move the given subscription, or every subscription of the current channel, to another channel of the team.\n" +
+		"* `/confluence export` - Export the subscriptions of the current channel to a JSON file, sent to you in a direct message.\n" +
+		"* `/confluence import [file-id]` - Import the subscriptions of an exported JSON file into the current channel, by default the last one you uploaded to it.\n"
 
 	sysAdminHelpText = "\n###### For System Administrators:\n" +
 		"Setup Instructions:\n" +
 		"* `/confluence install cloud` - Connect Mattermost to a Confluence Cloud instance.\n" +
-		"* `/confluence install server` - Connect Mattermost to a Confluence Server or Data Center instance.\n"
-
-	invalidCommand          = "Invalid command."
-	installOnlySystemAdmin  = "`/confluence install` can only be run by a system administrator."
-	commandsOnlySystemAdmin = "`/confluence` commands can only be run by a system administrator."
-	disconnectedUser        = "User not connected. Please use `/confluence connect`."
-	errorExecutingCommand   = "Error executing the command, please retry."
-	oauth2ConnectPath       = "%s/oauth2/connect"
+		"* `/confluence install server` - Connect Mattermost to a Confluence Server or Data Center instance. Run it again to add another instance.\n" +
+		"* `/confluence instance list` - List the installed Confluence Server or Data Center instances.\n" +
+		"* `/confluence instance remove <instance-url>` - Remove an installed Confluence Server or Data Center instance without subscriptions, disconnecting its users.\n" +
+		"* `/confluence subscriptions all [--space <space-key>]` - List the subscriptions of every channel, flagging the ones of deleted or archived channels.\n" +
+		"* `/confluence preset add \"<name>\" --events <event,...> [options]` - Define a subscription preset, with the event and filter options of `/confluence subscribe`.\n" +
+		"* `/confluence preset edit \"<name>\" --events <event,...> [options] [--apply]` - Replace the settings of a preset, and with `--apply` of the subscriptions created from it.\n" +
+		"* `/confluence preset remove \"<name>\"` - Remove a subscription preset.\n" +
+		"* `/confluence template set <event> \"<template>\"` - Set the default notification template of an event, a Go template like `{{.Author}} updated [{{.Title}}]({{.URL}})`.\n" +
+		"* `/confluence template reset <event>` - Remove the default notification template of an event, so the built-in notification is sent.\n"
+
+	invalidCommand           = "Invalid command."
+	installOnlySystemAdmin   = "`/confluence install` can only be run by a system administrator."
+	commandsOnlySystemAdmin  = "`/confluence` commands can only be run by a system administrator."
+	disconnectedUser         = "User not connected. Please use `/confluence connect`."
+	errorExecutingCommand    = "Error executing the command, please retry."
+	multipleInstances        = "Several Confluence instances are installed: %s. Please specify one with `/confluence %s <instance-url>`."
+	specifyInstanceURL       = "Please specify the URL of the Confluence instance."
+	noInstances              = "No Confluence instances are installed. Please run `/confluence install server`."
+	instanceRemoveSuccess    = "Confluence instance **%s** has been removed, and %d users were disconnected from it."
+	instanceNotInstalled     = "Confluence instance **%s** is not installed."
+	instanceHasSubscriptions = "Confluence instance **%s** still has %d subscriptions. Please delete them before removing the instance. `/confluence subscriptions all` lists them."
+
+	subscriptionPauseSuccess      = "Subscription **%s** has been paused. Use `/confluence resume \"%s\"` to resume it."
+	subscriptionPauseUntilSuccess = "Subscription **%s** has been paused until %s."
+	subscriptionResumeSuccess     = "Subscription **%s** has been resumed. %d events were suppressed while it was silenced."
+	specifyQuietHours             = "Please specify the quiet hours, like `/confluence quiet-hours \"<name>\" 22:00-07:00 Europe/Paris`, or `off` to remove them."
+	quietHoursSetSuccess          = "Quiet hours of %s have been set to %s."
+	quietHoursRemoveSuccess       = "Quiet hours of %s have been removed."
+	channelQuietHours             = "Quiet hours of this channel: %s."
+	quietHoursOff                 = "off"
+
+	subscribeUsage = "Please specify what to subscribe to and a subscription name, like `/confluence subscribe space <space-key> --name \"<name>\"` " +
+		"or `/confluence subscribe page <page-id|page-url> --name \"<name>\"`. Run `/confluence help` for the options."
+	subscribeSuccess     = "Subscription **%s** has been saved."
+	noSubscriptions      = "There are no Confluence subscriptions on this server."
+	noSpaceSubscriptions = "There are no Confluence subscriptions to space **%s** on this server."
+	specifyConfluenceURL = "Please specify the URL of the Confluence instance with `--url`."
+	unsupportedEvent     = "event **%s** is not supported, the supported events are %s"
+)
+
+const (
+	subscribeSpace     = "space"
+	subscribePage      = "page"
+	subscribePageTree  = "page-tree"
+	subscribeCQL       = "cql"
+	subscribeAllSpaces = "all-spaces"
+
+	flagName             = "name"
+	flagEvents           = "events"
+	flagURL              = "url"
+	flagIgnoreMinorEdits = "ignore-minor-edits"
+	flagExcludeAuthors   = "exclude-authors"
+	flagIncludeAuthors   = "include-authors"
+	flagContentType      = "content-type"
+	flagIncludeLabels    = "include-labels"
+	flagExcludeLabels    = "exclude-labels"
+	flagSpace            = "space"
+
+	flagExcludeSpaces         = "exclude-spaces"
+	flagExcludePersonalSpaces = "exclude-personal-spaces"
+)
+
+var (
+	subscribeFlags          = []string{flagName, flagEvents, flagURL, flagIgnoreMinorEdits, flagExcludeAuthors, flagIncludeAuthors, flagPreset}
+	subscribeSpaceFlags     = []string{flagContentType, flagIncludeLabels, flagExcludeLabels}
+	subscribeAllSpacesFlags = []string{flagExcludeSpaces, flagExcludePersonalSpaces}
 )
 
 const (
@@ -65,13 +143,33 @@ To finish the configuration, add a new app in your Confluence Cloud instance fol
 
 var ConfluenceCommandHandler = Handler{
 	handlers: map[string]HandlerFunc{
-		"list":           listChannelSubscription,
-		"unsubscribe":    deleteSubscription,
-		"install/cloud":  showInstallCloudHelp,
-		"install/server": showInstallServerHelp,
-		"connect":        executeConnect,
-		"disconnect":     executeDisconnect,
-		"help":           confluenceHelpCommand,
+		"list":              listChannelSubscription,
+		"unsubscribe":       deleteSubscription,
+		"install/cloud":     showInstallCloudHelp,
+		"install/server":    showInstallServerHelp,
+		"instance/list":     listInstances,
+		"subscriptions/all": listAllSubscriptions,
+		"instance/remove":   removeInstance,
+		"connect":           executeConnect,
+		"disconnect":        executeDisconnect,
+		"subscribe":         executeSubscribe,
+		"pause":             pauseSubscription,
+		"resume":            resumeSubscription,
+		"quiet-hours":       setQuietHours,
+		"digest":            setDigest,
+		"export":            executeExport,
+		"import":            executeImport,
+		"preset/add":        addPreset,
+		"preset/edit":       editPreset,
+		"preset/list":       listPresets,
+		"preset/remove":     removePreset,
+		"template/list":     listTemplates,
+		"template/set":      setTemplate,
+		"template/reset":    resetTemplate,
+		"template/preview":  previewTemplate,
+		"subscription/copy": copySubscription,
+		"subscription/move": moveSubscription,
+		"help":              confluenceHelpCommand,
 	},
 	defaultHandler: executeConfluenceDefault,
 }
@@ -87,7 +185,7 @@ func GetCommand(pAPI PluginAPI) (*model.Command, error) {
 		DisplayName:          "Confluence",
 		Description:          "Integration with Confluence.",
 		AutoComplete:         true,
-		AutoCompleteDesc:     "Available commands: subscribe, list, unsubscribe, edit, install, help.",
+		AutoCompleteDesc:     "Available commands: subscribe, list, unsubscribe, edit, pause, resume, quiet-hours, digest, preset, template, subscription, export, import, install, instance, help.",
 		AutoCompleteHint:     "[command]",
 		AutocompleteData:     getAutoCompleteData(),
 		AutocompleteIconData: iconData,
@@ -95,7 +193,7 @@ func GetCommand(pAPI PluginAPI) (*model.Command, error) {
 }
 
 func getAutoCompleteData() *model.AutocompleteData {
-	confluence := model.NewAutocompleteData("confluence", "[command]", "Available commands: subscribe, list, unsubscribe, edit, install, help")
+	confluence := model.NewAutocompleteData("confluence", "[command]", "Available commands: subscribe, list, unsubscribe, edit, pause, resume, quiet-hours, digest, preset, template, subscription, export, import, install, instance, help")
 
 	install := model.NewAutocompleteData("install", "", "Connect Mattermost to a Confluence instance")
 	installItems := []model.AutocompleteListItem{{
@@ -108,6 +206,38 @@ func getAutoCompleteData() *model.AutocompleteData {
 	install.AddStaticListArgument("", false, installItems)
 	confluence.AddCommand(install)
 
+	instance := model.NewAutocompleteData("instance", "[command]", "Manage the installed Confluence Server or Data Center instances")
+	instanceList := model.NewAutocompleteData("list", "", "List the installed Confluence instances")
+	instance.AddCommand(instanceList)
+	instanceRemove := model.NewAutocompleteData("remove", "[instance-url]", "Remove an installed Confluence instance")
+	instanceRemove.AddTextArgument("URL of the Confluence instance", "[instance-url]", "")
+	instance.AddCommand(instanceRemove)
+	instance.RoleID = model.SystemAdminRoleId
+	confluence.AddCommand(instance)
+
+	subscriptions := model.NewAutocompleteData("subscriptions", "[command]", "Audit the subscriptions of the server")
+	subscriptionsAll := model.NewAutocompleteData("all", "[--space space-key]", "List the subscriptions of every channel")
+	subscriptionsAll.AddNamedTextArgument(flagSpace, "Only list the subscriptions to this space", "[space-key]", "", false)
+	subscriptions.AddCommand(subscriptionsAll)
+	subscriptions.RoleID = model.SystemAdminRoleId
+	confluence.AddCommand(subscriptions)
+
+	preset := model.NewAutocompleteData("preset", "[command]", "Manage the subscription presets")
+	presetList := model.NewAutocompleteData("list", "", "List the subscription presets")
+	preset.AddCommand(presetList)
+	for _, action := range []string{"add", "edit"} {
+		presetData := model.NewAutocompleteData(action, "[name] --events [event,...]", strings.ToUpper(action[:1])+action[1:]+" a subscription preset, system administrators only")
+		presetData.AddTextArgument("Name of the preset", "[name]", "")
+		presetData.AddNamedTextArgument(flagEvents, "Comma separated events to notify", "[event,...]", "", true)
+		presetData.RoleID = model.SystemAdminRoleId
+		preset.AddCommand(presetData)
+	}
+	presetRemove := model.NewAutocompleteData("remove", "[name]", "Remove a subscription preset, system administrators only")
+	presetRemove.AddTextArgument("Name of the preset", "[name]", "")
+	presetRemove.RoleID = model.SystemAdminRoleId
+	preset.AddCommand(presetRemove)
+	confluence.AddCommand(preset)
+
 	list := model.NewAutocompleteData("list", "", "List all subscriptions for the current channel")
 	confluence.AddCommand(list)
 
@@ -115,20 +245,104 @@ func getAutoCompleteData() *model.AutocompleteData {
 	edit.AddDynamicListArgument("name", "api/v1/autocomplete/GetChannelSubscriptions", false)
 	confluence.AddCommand(edit)
 
-	subscribe := model.NewAutocompleteData("subscribe", "", "Subscribe the current channel to notifications from Confluence")
+	subscribe := model.NewAutocompleteData("subscribe", "[space|page|page-tree|cql|all-spaces]", "Subscribe the current channel to notifications from Confluence, in a dialog or with the given options")
+	subscribeSpaceData := model.NewAutocompleteData(subscribeSpace, "[space-key] --name [name]", "Subscribe the current channel to a space")
+	subscribeSpaceData.AddTextArgument("Key of the space", "[space-key]", "")
+	subscribeSpaceData.AddNamedTextArgument(flagName, "Name of the subscription", "[name]", "", true)
+	subscribeSpaceData.AddNamedTextArgument(flagEvents, "Comma separated events to notify, all by default", "[event,...]", "", false)
+	subscribeSpaceData.AddNamedTextArgument(flagURL, "URL of the Confluence instance", "[confluence-url]", "", false)
+	subscribeSpaceData.AddNamedTextArgument(flagPreset, "Name of a preset to take the events and filters from", "[preset]", "", false)
+	subscribeSpaceData.AddNamedStaticListArgument(flagContentType, "Type of content to notify", false, []model.AutocompleteListItem{
+		{Item: "pages"}, {Item: "blogs"}, {Item: "both"},
+	})
+	subscribe.AddCommand(subscribeSpaceData)
+	for _, pageType := range []string{subscribePage, subscribePageTree} {
+		subscribePageData := model.NewAutocompleteData(pageType, "[page-id|page-url] --name [name]", "Subscribe the current channel to a "+strings.ReplaceAll(pageType, "-", " "))
+		subscribePageData.AddTextArgument("ID or URL of the page", "[page-id|page-url]", "")
+		subscribePageData.AddNamedTextArgument(flagName, "Name of the subscription", "[name]", "", true)
+		subscribePageData.AddNamedTextArgument(flagEvents, "Comma separated events to notify, all by default", "[event,...]", "", false)
+		subscribePageData.AddNamedTextArgument(flagURL, "URL of the Confluence instance", "[confluence-url]", "", false)
+		subscribePageData.AddNamedTextArgument(flagPreset, "Name of a preset to take the events and filters from", "[preset]", "", false)
+		subscribe.AddCommand(subscribePageData)
+	}
+	subscribeCQLData := model.NewAutocompleteData(subscribeCQL, "[\"query\"] --name [name]", "Subscribe the current channel to the content found by a CQL query")
+	subscribeCQLData.AddTextArgument("CQL query, in quotes", "[\"query\"]", "")
+	subscribeCQLData.AddNamedTextArgument(flagName, "Name of the subscription", "[name]", "", true)
+	subscribeCQLData.AddNamedTextArgument(flagEvents, "Comma separated events to notify, all by default", "[event,...]", "", false)
+	subscribeCQLData.AddNamedTextArgument(flagURL, "URL of the Confluence instance", "[confluence-url]", "", false)
+	subscribeCQLData.AddNamedTextArgument(flagPreset, "Name of a preset to take the events and filters from", "[preset]", "", false)
+	subscribe.AddCommand(subscribeCQLData)
+	subscribeAllSpacesData := model.NewAutocompleteData(subscribeAllSpaces, "--name [name]", "Subscribe the current channel to every space of the instance")
+	subscribeAllSpacesData.AddNamedTextArgument(flagName, "Name of the subscription", "[name]", "", true)
+	subscribeAllSpacesData.AddNamedTextArgument(flagExcludeSpaces, "Comma separated keys of the spaces to skip", "[space-key,...]", "", false)
+	subscribeAllSpacesData.AddNamedTextArgument(flagEvents, "Comma separated events to notify, all by default", "[event,...]", "", false)
+	subscribeAllSpacesData.AddNamedTextArgument(flagURL, "URL of the Confluence instance", "[confluence-url]", "", false)
+	subscribeAllSpacesData.AddNamedTextArgument(flagPreset, "Name of a preset to take the events and filters from", "[preset]", "", false)
+	subscribe.AddCommand(subscribeAllSpacesData)
 	confluence.AddCommand(subscribe)
 
 	unsubscribe := model.NewAutocompleteData("unsubscribe", "[name]", "Unsubscribe the current channel from notifications associated with the given subscription name")
 	unsubscribe.AddDynamicListArgument("name", "api/v1/autocomplete/GetChannelSubscriptions", false)
 	confluence.AddCommand(unsubscribe)
 
+	pause := model.NewAutocompleteData("pause", "[name] [duration]", "Stop the notifications of the given subscription, for a duration or until it is resumed")
+	pause.AddDynamicListArgument("name", "api/v1/autocomplete/GetChannelSubscriptions", true)
+	pause.AddTextArgument("Duration of the pause, like 2h or 3d", "[duration]", "")
+	confluence.AddCommand(pause)
+
+	resume := model.NewAutocompleteData("resume", "[name]", "Resume the notifications of the given subscription")
+	resume.AddDynamicListArgument("name", "api/v1/autocomplete/GetChannelSubscriptions", true)
+	confluence.AddCommand(resume)
+
+	quietHours := model.NewAutocompleteData("quiet-hours", "[name] <HH:MM-HH:MM|off> [time-zone]", "Stop the notifications of a subscription, or of the channel, during the given hours of each day")
+	confluence.AddCommand(quietHours)
+
+	digest := model.NewAutocompleteData("digest", "[hourly|daily|immediate] [HH:MM] [time-zone]", "Deliver the notifications of the channel in an hourly or daily digest, or right away")
+	digest.AddStaticListArgument("Delivery mode", false, []model.AutocompleteListItem{
+		{Item: types.DigestHourly, HelpText: "A digest every hour"},
+		{Item: types.DigestDaily, HelpText: "A digest every day at the given time, like 08:30 Europe/Paris"},
+		{Item: digestImmediate, HelpText: "Post the notifications right away"},
+	})
+	confluence.AddCommand(digest)
+
+	templateData := model.NewAutocompleteData("template", "[command]", "Manage the notification templates")
+	templateList := model.NewAutocompleteData("list", "", "List the notification templates")
+	templateData.AddCommand(templateList)
+	for _, action := range []string{"set", "reset", "preview"} {
+		hint := "[event] [\"template\"] --subscription [name]"
+		if action == "reset" {
+			hint = "[event] --subscription [name]"
+		}
+		templateAction := model.NewAutocompleteData(action, hint, strings.ToUpper(action[:1])+action[1:]+" the notification template of an event, the default one without --subscription")
+		templateAction.AddTextArgument("Event of the template, like page_updated", "[event]", "")
+		templateAction.AddNamedTextArgument(flagSubscription, "Name of the subscription of the current channel", "[name]", "", false)
+		templateData.AddCommand(templateAction)
+	}
+	confluence.AddCommand(templateData)
+
+	subscription := model.NewAutocompleteData("subscription", "[copy|move]", "Copy or move subscriptions of the current channel to another channel")
+	for _, action := range []string{transferCopy, transferMove} {
+		transfer := model.NewAutocompleteData(action, "[name] ~[channel]", strings.ToUpper(action[:1])+action[1:]+" a subscription, or every subscription with --all, to another channel")
+		transfer.AddDynamicListArgument("name", "api/v1/autocomplete/GetChannelSubscriptions", false)
+		transfer.AddTextArgument("Target channel", "~[channel]", "")
+		subscription.AddCommand(transfer)
+	}
+	confluence.AddCommand(subscription)
+
+	export := model.NewAutocompleteData("export", "", "Export the subscriptions of the current channel to a JSON file")
+	confluence.AddCommand(export)
+
+	importData := model.NewAutocompleteData("import", "[file-id]", "Import the subscriptions of an exported JSON file into the current channel")
+	importData.AddTextArgument("ID of the uploaded file, the last JSON file you uploaded to the channel by default", "[file-id]", "")
+	confluence.AddCommand(importData)
+
 	help := model.NewAutocompleteData("help", "", "Show confluence slash command help")
 	confluence.AddCommand(help)
 
-	connect := model.NewAutocompleteData("connect", "", "Connect your Mattermost account to your Confluence account")
+	connect := model.NewAutocompleteData("connect", "[instance-url]", "Connect your Mattermost account to your Confluence account")
 	confluence.AddCommand(connect)
 
-	disconnect := model.NewAutocompleteData("disconnect", "", "Disconnect your Mattermost account from your Confluence account")
+	disconnect := model.NewAutocompleteData("disconnect", "[instance-url]", "Disconnect your Mattermost account from your Confluence account")
 	confluence.AddCommand(disconnect)
 
 	return confluence
@@ -174,32 +388,59 @@ func (p *Plugin) responsef(commandArgs *model.CommandArgs, format string, args .
 func executeConnect(p *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
 	isAdmin := util.IsSystemAdmin(context.UserId)
 
-	pluginConfig := config.GetConfig()
-	if pluginConfig.ConfluenceURL == "" || !pluginConfig.IsOAuthConfigured() {
+	instance, err := resolveInstance(strings.Join(args, " "))
+	if err != nil && errors.Cause(err) == errMultipleInstances {
+		instances, lErr := store.LoadInstances()
+		if lErr != nil {
+			return p.responsef(context, errorExecutingCommand)
+		}
+		return p.responsef(context, multipleInstances, formattedInstanceURLs(instances), "connect")
+	}
+	if err != nil && errors.Cause(err) != store.ErrNotFound {
+		return p.responsef(context, "Could not complete the **connection** request. Error: %v", err)
+	}
+	if err != nil || !instance.IsOAuthConfigured() {
 		if isAdmin {
 			return p.responsef(context, "OAuth config not set for confluence plugin. Please run `/confluence install server`")
 		}
 		return p.responsef(context, "OAuth config not set for confluence plugin. Please ask the admin to setup OAuth for the plugin")
 	}
-	confluenceURL := pluginConfig.GetConfluenceBaseURL()
-	confluenceURL = strings.TrimSuffix(confluenceURL, "/")
 
-	conn, err := store.LoadConnection(confluenceURL, context.UserId)
+	conn, err := store.LoadConnection(instance.URL, context.UserId)
 	if err == nil && len(conn.ConfluenceAccountID()) != 0 {
 		return p.responsef(context,
 			"You already have a Confluence account linked to your Mattermost account. Please use `/confluence disconnect` to disconnect.")
 	}
 
-	link := fmt.Sprintf(oauth2ConnectPath, util.GetPluginURL())
+	link := instanceRoute(instance.URL, routeUserConnect)
 	return p.responsef(context, "[Click here to link your Confluence account](%s)", link)
 }
 
 func executeDisconnect(p *Plugin, commArgs *model.CommandArgs, args ...string) *model.CommandResponse {
 	user, err := store.LoadUser(commArgs.UserId)
+	if errors.Cause(err) == store.ErrNotFound {
+		return p.responsef(commArgs, "Your account is not connected to Confluence. Please use `/confluence connect` to connect your account.")
+	}
 	if err != nil {
 		return p.responsef(commArgs, "Could not complete the **disconnection** request. Error: %v", err)
 	}
-	confluenceURL := user.InstanceURL
+
+	var confluenceURL string
+	switch {
+	case len(args) > 0:
+		confluenceURL, err = service.NormalizeConfluenceURL(strings.Join(args, " "))
+		if err != nil {
+			return p.responsef(commArgs, "Could not complete the **disconnection** request. Error: %v", err)
+		}
+	case len(user.ConnectedInstances) == 1:
+		confluenceURL = user.ConnectedInstances[0]
+	case len(user.ConnectedInstances) > 1:
+		urls := make([]string, 0, len(user.ConnectedInstances))
+		for _, url := range user.ConnectedInstances {
+			urls = append(urls, "`"+url+"`")
+		}
+		return p.responsef(commArgs, multipleInstances, strings.Join(urls, ", "), "disconnect")
+	}
 
 	disconnected, err := p.DisconnectUser(confluenceURL, commArgs.UserId)
 	if errors.Cause(err) == store.ErrNotFound {
@@ -238,29 +479,157 @@ func showInstallServerHelp(p *Plugin, context *model.CommandArgs, args ...string
 	}
 }
 
-func deleteSubscription(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
-	pluginConfig := config.GetConfig()
-	if pluginConfig.ServerVersionGreaterthan9 {
-		conn, err := store.LoadConnection(pluginConfig.ConfluenceURL, context.UserId)
-		if err != nil {
-			if strings.Contains(err.Error(), "not found") {
-				postCommandResponse(context, disconnectedUser)
-				return &model.CommandResponse{}
-			}
+func listInstances(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	if !util.IsSystemAdmin(context.UserId) {
+		postCommandResponse(context, commandsOnlySystemAdmin)
+		return &model.CommandResponse{}
+	}
 
-			postCommandResponse(context, errorExecutingCommand)
+	instances, err := store.LoadInstances()
+	if err != nil {
+		postCommandResponse(context, errorExecutingCommand)
+		return &model.CommandResponse{}
+	}
+
+	if len(instances) == 0 {
+		postCommandResponse(context, noInstances)
+		return &model.CommandResponse{}
+	}
+
+	list := "| URL | Version 9 or later | OAuth | Admin API Token | Webhook URL |\n| :----|:--------| :--------| :-----| :-----|"
+	for _, instance := range instances {
+		list += fmt.Sprintf("\n|%s|%s|%s|%s|%s|",
+			instance.URL,
+			formattedYesNo(instance.ServerVersionGreaterthan9),
+			formattedYesNo(instance.IsOAuthConfigured()),
+			formattedYesNo(getInstanceAdminAPIToken(instance) != ""),
+			getRedactedInstanceWebhookURL(instance),
+		)
+	}
+	postCommandResponse(context, list)
+	return &model.CommandResponse{}
+}
+
+func listAllSubscriptions(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	if !util.IsSystemAdmin(context.UserId) {
+		postCommandResponse(context, commandsOnlySystemAdmin)
+		return &model.CommandResponse{}
+	}
+
+	positional, flags, err := util.ParseFlags(args)
+	if err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+	for flag := range flags {
+		if flag != flagSpace {
+			postCommandResponse(context, fmt.Sprintf("flag --%s is not supported", flag))
 			return &model.CommandResponse{}
 		}
+	}
+	if len(positional) > 0 {
+		postCommandResponse(context, invalidCommand)
+		return &model.CommandResponse{}
+	}
 
-		if len(conn.ConfluenceAccountID()) == 0 {
-			postCommandResponse(context, disconnectedUser)
-			return &model.CommandResponse{}
+	overviews, err := service.GetSubscriptionOverviews(flags[flagSpace])
+	if err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+	if len(overviews) == 0 {
+		if flags[flagSpace] != "" {
+			postCommandResponse(context, fmt.Sprintf(noSpaceSubscriptions, flags[flagSpace]))
+		} else {
+			postCommandResponse(context, noSubscriptions)
 		}
-	} else if !util.IsSystemAdmin(context.UserId) {
+		return &model.CommandResponse{}
+	}
+
+	for _, message := range serializer.FormattedSubscriptionOverviewList(overviews) {
+		postCommandResponse(context, message)
+	}
+	return &model.CommandResponse{}
+}
+
+func removeInstance(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	if !util.IsSystemAdmin(context.UserId) {
 		postCommandResponse(context, commandsOnlySystemAdmin)
 		return &model.CommandResponse{}
 	}
 
+	if len(args) == 0 {
+		postCommandResponse(context, specifyInstanceURL)
+		return &model.CommandResponse{}
+	}
+
+	instanceURL, err := service.NormalizeConfluenceURL(strings.Join(args, " "))
+	if err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+
+	if _, err = store.LoadInstance(instanceURL); err != nil {
+		if errors.Cause(err) == store.ErrNotFound {
+			postCommandResponse(context, fmt.Sprintf(instanceNotInstalled, instanceURL))
+			return &model.CommandResponse{}
+		}
+		postCommandResponse(context, errorExecutingCommand)
+		return &model.CommandResponse{}
+	}
+
+	// The subscriptions of the instance belong to channels, so they are left for their owners to delete or move
+	// rather than removed along with it.
+	subscriptions, err := service.GetSubscriptions()
+	if err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+	subscriptionCount := 0
+	for _, channelSubscriptions := range subscriptions.ByChannelID {
+		for _, subscription := range channelSubscriptions {
+			if subscription.GetBaseURL() == instanceURL {
+				subscriptionCount++
+			}
+		}
+	}
+	if subscriptionCount > 0 {
+		postCommandResponse(context, fmt.Sprintf(instanceHasSubscriptions, instanceURL, subscriptionCount))
+		return &model.CommandResponse{}
+	}
+
+	disconnected, err := store.DeleteInstanceConnections(instanceURL)
+	if err != nil {
+		config.Mattermost.LogError("Unable to disconnect the users from the Confluence instance.", "InstanceURL", instanceURL, "Error", err.Error())
+		postCommandResponse(context, errorExecutingCommand)
+		return &model.CommandResponse{}
+	}
+
+	if err = store.DeleteInstance(instanceURL); err != nil {
+		if errors.Cause(err) == store.ErrNotFound {
+			postCommandResponse(context, fmt.Sprintf(instanceNotInstalled, instanceURL))
+			return &model.CommandResponse{}
+		}
+		postCommandResponse(context, errorExecutingCommand)
+		return &model.CommandResponse{}
+	}
+
+	postCommandResponse(context, fmt.Sprintf(instanceRemoveSuccess, instanceURL, disconnected))
+	return &model.CommandResponse{}
+}
+
+func formattedYesNo(value bool) string {
+	if value {
+		return "Yes"
+	}
+	return "No"
+}
+
+func deleteSubscription(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	if !checkSubscriptionPermissionCommand(context) {
+		return &model.CommandResponse{}
+	}
+
 	if len(args) == 0 {
 		postCommandResponse(context, specifyAlias)
 		return &model.CommandResponse{}
@@ -275,25 +644,7 @@ func deleteSubscription(_ *Plugin, context *model.CommandArgs, args ...string) *
 }
 
 func listChannelSubscription(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
-	pluginConfig := config.GetConfig()
-	if pluginConfig.ServerVersionGreaterthan9 {
-		conn, err := store.LoadConnection(pluginConfig.ConfluenceURL, context.UserId)
-		if err != nil {
-			if strings.Contains(err.Error(), "not found") {
-				postCommandResponse(context, disconnectedUser)
-				return &model.CommandResponse{}
-			}
-
-			postCommandResponse(context, errorExecutingCommand)
-			return &model.CommandResponse{}
-		}
-
-		if len(conn.ConfluenceAccountID()) == 0 {
-			postCommandResponse(context, disconnectedUser)
-			return &model.CommandResponse{}
-		}
-	} else if !util.IsSystemAdmin(context.UserId) {
-		postCommandResponse(context, commandsOnlySystemAdmin)
+	if !checkSubscriptionPermissionCommand(context) {
 		return &model.CommandResponse{}
 	}
 
@@ -308,13 +659,324 @@ func listChannelSubscription(_ *Plugin, context *model.CommandArgs, args ...stri
 		return &model.CommandResponse{}
 	}
 	list := serializer.FormattedSubscriptionList(channelSubscriptions)
+	if quietHours, err := store.LoadChannelQuietHours(context.ChannelId); err != nil {
+		config.Mattermost.LogError("Unable to get channel quiet hours.", "ChannelID", context.ChannelId, "Error", err.Error())
+	} else if quietHours != nil {
+		list += "\n\n" + fmt.Sprintf(channelQuietHours, quietHours.String())
+	}
+	if digest, err := store.LoadChannelDigest(context.ChannelId); err != nil {
+		config.Mattermost.LogError("Unable to get the channel digest.", "ChannelID", context.ChannelId, "Error", err.Error())
+	} else if digest != nil {
+		list += "\n\n" + fmt.Sprintf(channelDigest, digest.String())
+	}
 	postCommandResponse(context, list)
 	return &model.CommandResponse{}
 }
 
+func pauseSubscription(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	if !checkSubscriptionPermissionCommand(context) {
+		return &model.CommandResponse{}
+	}
+
+	if len(args) == 0 {
+		postCommandResponse(context, specifyAlias)
+		return &model.CommandResponse{}
+	}
+
+	// The duration is optional, so the last argument is only taken as one when it parses as a duration.
+	alias := strings.Join(args, " ")
+	var duration time.Duration
+	if len(args) > 1 {
+		if d, err := parsePauseDuration(args[len(args)-1]); err == nil {
+			alias = strings.Join(args[:len(args)-1], " ")
+			duration = d
+		}
+	}
+
+	var until int64
+	if duration > 0 {
+		until = time.Now().Add(duration).UnixMilli()
+	}
+	if err := service.PauseSubscription(context.ChannelId, alias, until); err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+
+	if until == 0 {
+		postCommandResponse(context, fmt.Sprintf(subscriptionPauseSuccess, alias, alias))
+		return &model.CommandResponse{}
+	}
+	postCommandResponse(context, fmt.Sprintf(subscriptionPauseUntilSuccess, alias, time.UnixMilli(until).UTC().Format(serializer.PausedUntilFormat)))
+	return &model.CommandResponse{}
+}
+
+// parsePauseDuration parses durations like `90m` or `2h`, and days like `3d`.
+func parsePauseDuration(value string) (time.Duration, error) {
+	var duration time.Duration
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		duration = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, err
+		}
+		duration = d
+	}
+
+	if duration <= 0 {
+		return 0, errors.Errorf("invalid duration %q", value)
+	}
+	return duration, nil
+}
+
+func resumeSubscription(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	if !checkSubscriptionPermissionCommand(context) {
+		return &model.CommandResponse{}
+	}
+
+	if len(args) == 0 {
+		postCommandResponse(context, specifyAlias)
+		return &model.CommandResponse{}
+	}
+
+	alias := strings.Join(args, " ")
+	suppressed, err := service.ResumeSubscription(context.ChannelId, alias)
+	if err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+	postCommandResponse(context, fmt.Sprintf(subscriptionResumeSuccess, alias, suppressed))
+	return &model.CommandResponse{}
+}
+
+func setQuietHours(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	if !checkSubscriptionPermissionCommand(context) {
+		return &model.CommandResponse{}
+	}
+
+	if len(args) == 0 {
+		postCommandResponse(context, specifyQuietHours)
+		return &model.CommandResponse{}
+	}
+
+	// The quiet hours of the channel are set when the first argument is the window, otherwise it is a subscription name.
+	var alias string
+	if !isQuietHoursWindow(args[0]) {
+		if len(args) < 2 {
+			postCommandResponse(context, specifyQuietHours)
+			return &model.CommandResponse{}
+		}
+		alias, args = args[0], args[1:]
+	}
+
+	var quietHours *types.QuietHours
+	if args[0] != quietHoursOff {
+		timeZone := ""
+		if len(args) > 1 {
+			timeZone = args[1]
+		}
+		var err error
+		if quietHours, err = types.ParseQuietHours(args[0], timeZone); err != nil {
+			postCommandResponse(context, err.Error())
+			return &model.CommandResponse{}
+		}
+	}
+
+	if alias == "" {
+		if err := store.StoreChannelQuietHours(context.ChannelId, quietHours); err != nil {
+			postCommandResponse(context, errorExecutingCommand)
+			return &model.CommandResponse{}
+		}
+	} else if err := service.SetSubscriptionQuietHours(context.ChannelId, alias, quietHours); err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+
+	target := "this channel"
+	if alias != "" {
+		target = fmt.Sprintf("subscription **%s**", alias)
+	}
+	if quietHours == nil {
+		postCommandResponse(context, fmt.Sprintf(quietHoursRemoveSuccess, target))
+		return &model.CommandResponse{}
+	}
+	postCommandResponse(context, fmt.Sprintf(quietHoursSetSuccess, target, quietHours.String()))
+	return &model.CommandResponse{}
+}
+
+func isQuietHoursWindow(value string) bool {
+	if value == quietHoursOff {
+		return true
+	}
+	_, err := types.ParseQuietHours(value, "")
+	return err == nil
+}
+
+func executeSubscribe(p *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	if !checkSubscriptionPermissionCommand(context) {
+		return &model.CommandResponse{}
+	}
+
+	positional, flags, err := util.ParseFlags(args, flagIgnoreMinorEdits, flagExcludePersonalSpaces)
+	if err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+	// All spaces subscriptions are the only ones without a target.
+	if len(positional) == 1 && positional[0] == subscribeAllSpaces {
+		positional = append(positional, "")
+	}
+	if len(positional) != 2 || flags[flagName] == "" {
+		postCommandResponse(context, subscribeUsage)
+		return &model.CommandResponse{}
+	}
+
+	subscription, err := buildSubscription(context, positional[0], positional[1], flags)
+	if err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+	if flags[flagPreset] != "" {
+		if subscription, err = applySubscribePreset(subscription, flags); err != nil {
+			postCommandResponse(context, err.Error())
+			return &model.CommandResponse{}
+		}
+	}
+
+	subscription, _, err = p.resolveSubscriptionTarget(context.UserId, subscription)
+	if err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+
+	if _, err = service.SaveSubscription(subscription); err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+	postCommandResponse(context, fmt.Sprintf(subscribeSuccess, subscription.GetAlias()))
+	return &model.CommandResponse{}
+}
+
+// buildSubscription returns the subscription described by the arguments of `/confluence subscribe`.
+func buildSubscription(context *model.CommandArgs, subscriptionType, target string, flags map[string]string) (serializer.Subscription, error) {
+	allowedFlags := subscribeFlags
+	if subscriptionType == subscribeSpace {
+		allowedFlags = append(allowedFlags, subscribeSpaceFlags...)
+	}
+	if subscriptionType == subscribeAllSpaces {
+		if target != "" {
+			return nil, errors.New(subscribeUsage)
+		}
+		allowedFlags = append(allowedFlags, subscribeAllSpacesFlags...)
+	}
+	for flag := range flags {
+		if !slices.Contains(allowedFlags, flag) {
+			return nil, errors.Errorf("flag --%s is not supported for %s subscriptions", flag, subscriptionType)
+		}
+	}
+
+	events := serializer.SupportedEvents()
+	if flags[flagEvents] != "" {
+		events = splitList(flags[flagEvents])
+		for _, event := range events {
+			if !serializer.IsSupportedEvent(event) {
+				return nil, errors.Errorf(unsupportedEvent, event, strings.Join(serializer.SupportedEvents(), ", "))
+			}
+		}
+	}
+
+	base := serializer.BaseSubscription{
+		Alias:            flags[flagName],
+		Events:           events,
+		ChannelID:        context.ChannelId,
+		IgnoreMinorEdits: flags[flagIgnoreMinorEdits] != "",
+		ExcludeAuthors:   splitList(flags[flagExcludeAuthors]),
+		IncludeAuthors:   splitList(flags[flagIncludeAuthors]),
+		AuditInfo:        serializer.NewAuditInfo(context.UserId),
+	}
+
+	if subscriptionType != subscribeSpace && subscriptionType != subscribePage && subscriptionType != subscribePageTree &&
+		subscriptionType != subscribeCQL && subscriptionType != subscribeAllSpaces {
+		return nil, errors.New(subscribeUsage)
+	}
+
+	// Space and page URLs are resolved to a space key or page ID when the subscription is saved.
+	baseURL, err := getSubscribeBaseURL(flags[flagURL], target)
+	if err != nil {
+		return nil, err
+	}
+	base.BaseURL = baseURL
+
+	switch subscriptionType {
+	case subscribeSpace:
+		base.Type = serializer.SubscriptionTypeSpace
+		return serializer.SpaceSubscription{
+			SpaceKey:         target,
+			ContentType:      flags[flagContentType],
+			IncludeLabels:    splitList(flags[flagIncludeLabels]),
+			ExcludeLabels:    splitList(flags[flagExcludeLabels]),
+			BaseSubscription: base,
+		}, nil
+	case subscribePageTree:
+		base.Type = serializer.SubscriptionTypePageTree
+		return serializer.PageTreeSubscription{PageID: target, BaseSubscription: base}, nil
+	case subscribeCQL:
+		base.Type = serializer.SubscriptionTypeCQL
+		return serializer.CQLSubscription{CQL: target, BaseSubscription: base}, nil
+	case subscribeAllSpaces:
+		base.Type = serializer.SubscriptionTypeAllSpaces
+		return serializer.AllSpacesSubscription{
+			ExcludeSpaces:         splitList(flags[flagExcludeSpaces]),
+			ExcludePersonalSpaces: flags[flagExcludePersonalSpaces] != "",
+			BaseSubscription:      base,
+		}, nil
+	default:
+		base.Type = serializer.SubscriptionTypePage
+		return serializer.PageSubscription{PageID: target, BaseSubscription: base}, nil
+	}
+}
+
+// getSubscribeBaseURL returns the Confluence URL given with `--url`, or else the base URL of the space or page URL,
+// or else the URL of the only installed instance.
+func getSubscribeBaseURL(urlFlag, target string) (string, error) {
+	if urlFlag != "" {
+		return service.NormalizeConfluenceURL(urlFlag)
+	}
+	if service.IsConfluenceURL(target) {
+		confluenceURL, err := service.ParseConfluenceURL(target)
+		if err != nil {
+			return "", err
+		}
+		return confluenceURL.BaseURL, nil
+	}
+
+	instance, err := resolveInstance("")
+	if err != nil {
+		if errors.Cause(err) == errMultipleInstances || errors.Cause(err) == store.ErrNotFound {
+			return "", errors.New(specifyConfluenceURL)
+		}
+		return "", err
+	}
+	return instance.URL, nil
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func confluenceHelpCommand(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
-	pluginConfig := config.GetConfig()
-	if !pluginConfig.ServerVersionGreaterthan9 && !util.IsSystemAdmin(context.UserId) {
+	policy := config.GetConfig().GetSubscriptionPermission(isUserConnectionRequired())
+	if policy == config.SubscriptionPermissionSystemAdmin && !util.IsSystemAdmin(context.UserId) {
 		postCommandResponse(context, commandsOnlySystemAdmin)
 		return &model.CommandResponse{}
 	}
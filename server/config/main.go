@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost/server/public/plugin"
 	"github.com/pkg/errors"
@@ -11,6 +12,16 @@ import (
 
 const (
 	HeaderMattermostUserID = "Mattermost-User-Id"
+
+	// The policies deciding who can manage the subscriptions of a channel.
+	// Each policy also lets through the users allowed by the stricter ones.
+	SubscriptionPermissionSystemAdmin   = "system_admin"
+	SubscriptionPermissionTeamAdmin     = "team_admin"
+	SubscriptionPermissionChannelAdmin  = "channel_admin"
+	SubscriptionPermissionChannelMember = "channel_member"
+
+	defaultNotificationThreadExpiry = 24 * time.Hour
+	defaultPageDiffMaxLines         = 10
 )
 
 var (
@@ -20,9 +31,19 @@ var (
 )
 
 type Configuration struct {
-	Secret                      string `json:"secret"`
-	EncryptionKey               string `json:"encryptionKey"` // The encryption key used to encrypt tokens
-	AdminAPIToken               string `json:"adminAPIToken"` // API token from Confluence Data Center
+	Secret        string `json:"secret"`
+	EncryptionKey string `json:"encryptionKey"` // The encryption key used to encrypt tokens
+	AdminAPIToken string `json:"adminAPIToken"` // API token from Confluence Data Center
+
+	SubscriptionPermission string `json:"subscriptionPermission"` // Who can manage the subscriptions of a channel
+
+	ThreadNotifications           bool `json:"threadNotifications"`           // Whether the notifications of a page reply to the thread of its first one
+	NotificationThreadExpiryHours int  `json:"notificationThreadExpiryHours"` // How long after its last notification a thread keeps receiving the ones of its page
+	UpdateDebounceMinutes         int  `json:"updateDebounceMinutes"`         // How long the updates of a page edit its first update notification, disabled when not positive
+	PageDiffMaxLines              int  `json:"pageDiffMaxLines"`              // How many added and removed lines page update notifications show
+
+	// The Confluence instance installed before instances were kept in the KV store.
+	// It is moved to the instance registry on activation, see migrateInstance.
 	ConfluenceOAuthClientID     string
 	ConfluenceOAuthClientSecret string
 	ConfluenceURL               string
@@ -33,6 +54,12 @@ func GetConfig() *Configuration {
 	return config.Load().(*Configuration)
 }
 
+// LookupConfig returns the configuration, and whether one was set yet.
+func LookupConfig() (*Configuration, bool) {
+	c, ok := config.Load().(*Configuration)
+	return c, ok
+}
+
 func SetConfig(c *Configuration) {
 	config.Store(c)
 }
@@ -63,8 +90,45 @@ func (c *Configuration) Sanitize() {
 	c.ConfluenceOAuthClientSecret = strings.TrimSpace(c.ConfluenceOAuthClientSecret)
 }
 
-func (c *Configuration) IsOAuthConfigured() bool {
-	return (c.ConfluenceOAuthClientID != "" && c.ConfluenceOAuthClientSecret != "")
+// GetSubscriptionPermission returns the policy deciding who can manage the subscriptions of a channel.
+// Without a policy set, only system admins can, unless users need a connected account to manage subscriptions,
+// in which case any channel member with a connected account can.
+func (c *Configuration) GetSubscriptionPermission(userConnectionRequired bool) string {
+	switch c.SubscriptionPermission {
+	case SubscriptionPermissionSystemAdmin, SubscriptionPermissionTeamAdmin, SubscriptionPermissionChannelAdmin, SubscriptionPermissionChannelMember:
+		return c.SubscriptionPermission
+	}
+
+	if userConnectionRequired {
+		return SubscriptionPermissionChannelMember
+	}
+	return SubscriptionPermissionSystemAdmin
+}
+
+// GetNotificationThreadExpiry returns how long after its last notification a thread keeps receiving the notifications of its page,
+// a day when no valid expiry is set.
+func (c *Configuration) GetNotificationThreadExpiry() time.Duration {
+	if c.NotificationThreadExpiryHours <= 0 {
+		return defaultNotificationThreadExpiry
+	}
+	return time.Duration(c.NotificationThreadExpiryHours) * time.Hour
+}
+
+// GetUpdateDebounce returns how long after the first update notification of a page the later updates edit it
+// rather than being posted, or zero when updates are not coalesced.
+func (c *Configuration) GetUpdateDebounce() time.Duration {
+	if c.UpdateDebounceMinutes <= 0 {
+		return 0
+	}
+	return time.Duration(c.UpdateDebounceMinutes) * time.Minute
+}
+
+// GetPageDiffMaxLines returns how many added and removed lines page update notifications show, 10 when no valid count is set.
+func (c *Configuration) GetPageDiffMaxLines() int {
+	if c.PageDiffMaxLines <= 0 {
+		return defaultPageDiffMaxLines
+	}
+	return c.PageDiffMaxLines
 }
 
 func (c *Configuration) ToMap() (map[string]interface{}, error) {
@@ -80,7 +144,3 @@ func (c *Configuration) ToMap() (map[string]interface{}, error) {
 
 	return out, nil
 }
-
-func (c *Configuration) GetConfluenceBaseURL() string {
-	return c.ConfluenceURL
-}
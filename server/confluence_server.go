@@ -15,6 +15,7 @@ import (
 	"github.com/mattermost/mattermost-plugin-confluence/server/service"
 	"github.com/mattermost/mattermost-plugin-confluence/server/store"
 	"github.com/mattermost/mattermost-plugin-confluence/server/util"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
 )
 
 var confluenceServerWebhook = &Endpoint{
@@ -26,14 +27,18 @@ var confluenceServerWebhook = &Endpoint{
 func handleConfluenceServerWebhook(w http.ResponseWriter, r *http.Request, p *Plugin) {
 	p.client.Log.Info("Received confluence server event.")
 
-	if status, err := verifyHTTPSecret(config.GetConfig().Secret, r.FormValue("secret")); err != nil {
-		http.Error(w, err.Error(), status)
+	instance, instanceErr := getWebhookInstance(r)
+	if instanceErr != nil {
+		http.Error(w, instanceErr.Error(), http.StatusNotFound)
 		return
 	}
 
-	pluginConfig := config.GetConfig()
+	if status, err := verifyHTTPSecret(instance.WebhookSecret, r.FormValue("secret")); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
 
-	if pluginConfig.ServerVersionGreaterthan9 {
+	if instance.ServerVersionGreaterthan9 {
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -54,10 +59,13 @@ func handleConfluenceServerWebhook(w http.ResponseWriter, r *http.Request, p *Pl
 			return
 		}
 
-		pluginConfig := config.GetConfig()
-		instanceID := pluginConfig.ConfluenceURL
+		instanceID := instance.URL
+		adminAPIToken := getInstanceAdminAPIToken(instance)
 
 		notification := p.getNotification()
+		if adminAPIToken != "" {
+			notification.cqlSearcher = &apiTokenCQLSearcher{p: p, instanceURL: instanceID, adminAPIToken: adminAPIToken}
+		}
 
 		client, _, err := p.GetClientFromUserKey(instanceID, event.UserKey)
 		// If there is an error while retrieving the client from the event user key, it could be due to one of the following reasons:
@@ -66,11 +74,11 @@ func handleConfluenceServerWebhook(w http.ResponseWriter, r *http.Request, p *Pl
 		// If the Admin API token is available, we will attempt to fetch additional data using it to send a detailed notification.
 		// Otherwise, a generic notification will be sent.
 		if err != nil {
-			if pluginConfig.AdminAPIToken != "" {
+			if adminAPIToken != "" {
 				p.client.Log.Info("Error getting client for the user who triggered webhook event. Sending notification using admin API token")
 				if strings.Contains(event.Event, Space) {
 					var spaceKey string
-					spaceKey, err = p.GetSpaceKeyFromSpaceIDWithAPIToken(event.Space.ID, pluginConfig)
+					spaceKey, err = p.GetSpaceKeyFromSpaceIDWithAPIToken(event.Space.ID, instanceID, adminAPIToken)
 					if err != nil {
 						p.client.Log.Error("Error getting space key using space ID with API token", "error", err)
 						http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -80,18 +88,19 @@ func handleConfluenceServerWebhook(w http.ResponseWriter, r *http.Request, p *Pl
 				}
 
 				var eventData *ConfluenceServerEvent
-				eventData, err = p.GetEventDataWithAPIToken(event, pluginConfig)
+				eventData, err = p.GetEventDataWithAPIToken(event, instanceID, adminAPIToken)
 				if err != nil {
 					p.client.Log.Error("Error getting event data with API token", "error", err)
 					http.Error(w, err.Error(), http.StatusInternalServerError)
 					return
 				}
 
-				eventData.BaseURL = pluginConfig.ConfluenceURL
+				eventData.BaseURL = instanceID
+				eventData.UserKey = event.UserKey
 				notification.SendConfluenceNotifications(eventData, event.Event, p.BotUserID)
 			} else {
 				p.client.Log.Info("Error getting client for the user who triggered webhook event. Sending generic notification")
-				notification.SendGenericWHNotification(event, p.BotUserID, pluginConfig.ConfluenceURL)
+				notification.SendGenericWHNotification(event, p.BotUserID, instanceID)
 			}
 
 			w.Header().Set("Content-Type", "application/json")
@@ -115,8 +124,12 @@ func handleConfluenceServerWebhook(w http.ResponseWriter, r *http.Request, p *Pl
 			return
 		}
 
-		eventData.BaseURL = pluginConfig.ConfluenceURL
+		eventData.BaseURL = instanceID
+		eventData.UserKey = event.UserKey
 
+		if notification.cqlSearcher == nil {
+			notification.cqlSearcher = client
+		}
 		notification.SendConfluenceNotifications(eventData, event.Event, p.BotUserID)
 	} else {
 		event := serializer.ConfluenceServerEventFromJSON(r.Body)
@@ -127,6 +140,38 @@ func handleConfluenceServerWebhook(w http.ResponseWriter, r *http.Request, p *Pl
 	ReturnStatusOK(w)
 }
 
+// getWebhookInstance returns the instance a webhook request was sent for.
+// Webhooks sent to the route without an instance belong to the instance configured in the plugin settings,
+// and are verified with the webhook secret of the plugin settings.
+func getWebhookInstance(r *http.Request) (*types.Instance, error) {
+	if instanceID := instanceIDFromRequest(r); instanceID != "" {
+		instance, err := store.LoadInstance(instanceID)
+		if err != nil {
+			return nil, err
+		}
+		if instance.WebhookSecret == "" {
+			instance.WebhookSecret = config.GetConfig().Secret
+		}
+		return instance, nil
+	}
+
+	pluginConfig := config.GetConfig()
+	instance := &types.Instance{
+		URL: pluginConfig.ConfluenceURL,
+	}
+	if pluginConfig.ConfluenceURL != "" {
+		stored, err := store.LoadInstance(pluginConfig.ConfluenceURL)
+		if err != nil && errors.Cause(err) != store.ErrNotFound {
+			return nil, err
+		}
+		if stored != nil {
+			instance = stored
+		}
+	}
+	instance.WebhookSecret = pluginConfig.Secret
+	return instance, nil
+}
+
 func (p *Plugin) GetEventData(webhookPayload *serializer.ConfluenceServerWebhookPayload, client Client) (*ConfluenceServerEvent, error) {
 	eventData, err := client.(*confluenceServerClient).GetEventData(webhookPayload)
 	if err != nil {
@@ -155,15 +200,15 @@ func (p *Plugin) GetClientFromUserKey(instanceID, eventUserKey string) (Client,
 	return client, mmUserID, nil
 }
 
-func (p *Plugin) GetSpaceKeyFromSpaceIDWithAPIToken(spaceID int64, pluginConfig *config.Configuration) (string, error) {
+func (p *Plugin) GetSpaceKeyFromSpaceIDWithAPIToken(spaceID int64, instanceURL, adminAPIToken string) (string, error) {
 	start := 0
 
 	for {
-		path := fmt.Sprintf("%s%s?start=%d&limit=%d", pluginConfig.ConfluenceURL, PathSpaceData, start, pageSize)
+		path := fmt.Sprintf("%s%s?start=%d&limit=%d", instanceURL, PathSpaceData, start, pageSize)
 
 		response := &apiResponse{}
 
-		body, statusCode, err := p.MakeHTTPCallWithAPIToken(path)
+		body, statusCode, err := p.MakeHTTPCallWithAPIToken(path, adminAPIToken)
 		if err != nil || statusCode != http.StatusOK {
 			return "", errors.Wrapf(err, "error getting spaceKey from spaceID")
 		}
@@ -188,14 +233,14 @@ func (p *Plugin) GetSpaceKeyFromSpaceIDWithAPIToken(spaceID int64, pluginConfig
 	return "", fmt.Errorf("confluence GetSpaceKeyFromSpaceIDUsingAPIToken: no space found for the space key")
 }
 
-func (p *Plugin) GetEventDataWithAPIToken(webhookPayload *serializer.ConfluenceServerWebhookPayload, pluginConfig *config.Configuration) (*ConfluenceServerEvent, error) {
+func (p *Plugin) GetEventDataWithAPIToken(webhookPayload *serializer.ConfluenceServerWebhookPayload, instanceURL, adminAPIToken string) (*ConfluenceServerEvent, error) {
 	var confluenceServerEvent ConfluenceServerEvent
 	var err error
 	supportedWHEventFound := false
 
 	if strings.Contains(webhookPayload.Event, Comment) {
 		supportedWHEventFound = true
-		confluenceServerEvent.Comment, err = p.GetCommentDataWithAPIToken(webhookPayload, pluginConfig)
+		confluenceServerEvent.Comment, err = p.GetCommentDataWithAPIToken(webhookPayload, instanceURL, adminAPIToken)
 		if err != nil {
 			return nil, errors.Wrapf(err, "error getting comment data for the event using API token")
 		}
@@ -203,15 +248,33 @@ func (p *Plugin) GetEventDataWithAPIToken(webhookPayload *serializer.ConfluenceS
 
 	if strings.Contains(webhookPayload.Event, Page) {
 		supportedWHEventFound = true
-		confluenceServerEvent.Page, err = p.GetPageDataWithAPIToken(int(webhookPayload.Page.ID), pluginConfig)
+		confluenceServerEvent.Page, err = p.GetPageDataWithAPIToken(int(webhookPayload.Page.ID), instanceURL, adminAPIToken)
 		if err != nil {
 			return nil, errors.Wrapf(err, "error getting page data for the event using API token")
 		}
+		if webhookPayload.Event == serializer.PageUpdatedEvent {
+			confluenceServerEvent.ContentDiff = getContentDiff(confluenceServerEvent.Page, func(pageID string, version int) (string, error) {
+				return p.GetPageVersionBodyWithAPIToken(pageID, version, instanceURL, adminAPIToken)
+			})
+		}
+	}
+
+	if strings.Contains(webhookPayload.Event, Blog) {
+		supportedWHEventFound = true
+		confluenceServerEvent.Blog, err = p.GetPageDataWithAPIToken(int(webhookPayload.Blog.ID), instanceURL, adminAPIToken)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting blog post data for the event using API token")
+		}
+		if webhookPayload.Event == serializer.BlogUpdatedEvent {
+			confluenceServerEvent.ContentDiff = getContentDiff(confluenceServerEvent.Blog, func(blogID string, version int) (string, error) {
+				return p.GetPageVersionBodyWithAPIToken(blogID, version, instanceURL, adminAPIToken)
+			})
+		}
 	}
 
 	if strings.Contains(webhookPayload.Event, Space) {
 		supportedWHEventFound = true
-		confluenceServerEvent.Space, err = p.GetSpaceDataWithAPIToken(webhookPayload.Space.SpaceKey, pluginConfig)
+		confluenceServerEvent.Space, err = p.GetSpaceDataWithAPIToken(webhookPayload.Space.SpaceKey, instanceURL, adminAPIToken)
 		if err != nil {
 			return nil, errors.Wrapf(err, "error getting space data for the event using API token")
 		}
@@ -224,11 +287,11 @@ func (p *Plugin) GetEventDataWithAPIToken(webhookPayload *serializer.ConfluenceS
 	return &confluenceServerEvent, nil
 }
 
-func (p *Plugin) GetCommentDataWithAPIToken(webhookPayload *serializer.ConfluenceServerWebhookPayload, pluginConfig *config.Configuration) (*CommentResponse, error) {
+func (p *Plugin) GetCommentDataWithAPIToken(webhookPayload *serializer.ConfluenceServerWebhookPayload, instanceURL, adminAPIToken string) (*CommentResponse, error) {
 	commentResponse := &CommentResponse{}
-	path := fmt.Sprintf("%s%s", pluginConfig.ConfluenceURL, fmt.Sprintf("%s%s?expand=body.view,container,space,history", PathContentData, strconv.FormatInt(webhookPayload.Comment.ID, 10)))
+	path := fmt.Sprintf("%s%s", instanceURL, fmt.Sprintf("%s%s?expand=%s", PathContentData, strconv.FormatInt(webhookPayload.Comment.ID, 10), commentExpand))
 
-	body, statusCode, err := p.MakeHTTPCallWithAPIToken(path)
+	body, statusCode, err := p.MakeHTTPCallWithAPIToken(path, adminAPIToken)
 	if err != nil || statusCode != http.StatusOK {
 		return nil, err
 	}
@@ -242,11 +305,11 @@ func (p *Plugin) GetCommentDataWithAPIToken(webhookPayload *serializer.Confluenc
 	return commentResponse, nil
 }
 
-func (p *Plugin) GetPageDataWithAPIToken(pageID int, pluginConfig *config.Configuration) (*PageResponse, error) {
+func (p *Plugin) GetPageDataWithAPIToken(pageID int, instanceURL, adminAPIToken string) (*PageResponse, error) {
 	pageResponse := &PageResponse{}
-	path := fmt.Sprintf("%s%s", pluginConfig.ConfluenceURL, fmt.Sprintf("%s%s?status=any&expand=body.view,container,space,history", PathContentData, strconv.Itoa(pageID)))
+	path := fmt.Sprintf("%s%s", instanceURL, fmt.Sprintf("%s%s?status=any&expand=%s", PathContentData, strconv.Itoa(pageID), pageExpand))
 
-	body, statusCode, err := p.MakeHTTPCallWithAPIToken(path)
+	body, statusCode, err := p.MakeHTTPCallWithAPIToken(path, adminAPIToken)
 	if err != nil || statusCode != http.StatusOK {
 		return nil, err
 	}
@@ -260,11 +323,31 @@ func (p *Plugin) GetPageDataWithAPIToken(pageID int, pluginConfig *config.Config
 	return pageResponse, nil
 }
 
-func (p *Plugin) GetSpaceDataWithAPIToken(spaceKey string, pluginConfig *config.Configuration) (*SpaceResponse, error) {
+// GetPageVersionBodyWithAPIToken returns the text of the body of a version of the page or blog post, fetched with the admin API token.
+func (p *Plugin) GetPageVersionBodyWithAPIToken(pageID string, version int, instanceURL, adminAPIToken string) (string, error) {
+	pageResponse := &PageResponse{}
+	path := fmt.Sprintf("%s%s", instanceURL, fmt.Sprintf("%s%s?status=historical&version=%d&expand=%s", PathContentData, pageID, version, versionExpand))
+
+	body, statusCode, err := p.MakeHTTPCallWithAPIToken(path, adminAPIToken)
+	if err != nil {
+		return "", err
+	}
+	if statusCode != http.StatusOK {
+		return "", errors.Errorf("unexpected status code %d", statusCode)
+	}
+
+	if err := json.Unmarshal(body, pageResponse); err != nil {
+		return "", errors.Wrapf(err, "error getting page version data with API token")
+	}
+
+	return util.GetBodyForExcerpt(pageResponse.Body.View.Value), nil
+}
+
+func (p *Plugin) GetSpaceDataWithAPIToken(spaceKey, instanceURL, adminAPIToken string) (*SpaceResponse, error) {
 	spaceResponse := &SpaceResponse{}
-	path := fmt.Sprintf("%s%s", pluginConfig.ConfluenceURL, fmt.Sprintf("%s%s?status=any", PathSpaceData, spaceKey))
+	path := fmt.Sprintf("%s%s", instanceURL, fmt.Sprintf("%s%s?status=any", PathSpaceData, spaceKey))
 
-	body, statusCode, err := p.MakeHTTPCallWithAPIToken(path)
+	body, statusCode, err := p.MakeHTTPCallWithAPIToken(path, adminAPIToken)
 	if err != nil || statusCode != http.StatusOK {
 		return nil, err
 	}
@@ -276,14 +359,37 @@ func (p *Plugin) GetSpaceDataWithAPIToken(spaceKey string, pluginConfig *config.
 	return spaceResponse, nil
 }
 
-func (p *Plugin) MakeHTTPCallWithAPIToken(path string) ([]byte, int, error) {
+// apiTokenCQLSearcher runs the searches of CQL subscriptions with the admin API token of the instance.
+type apiTokenCQLSearcher struct {
+	p             *Plugin
+	instanceURL   string
+	adminAPIToken string
+}
+
+func (s *apiTokenCQLSearcher) ContentMatchesCQL(cql, contentID string) (bool, error) {
+	body, statusCode, err := s.p.MakeHTTPCallWithAPIToken(s.instanceURL+cqlContentSearchPath(cql, contentID), s.adminAPIToken)
+	if err != nil {
+		return false, err
+	}
+	if statusCode != http.StatusOK {
+		return false, errors.Errorf("unexpected response status: %d", statusCode)
+	}
+
+	response := &contentSearchResponse{}
+	if err = json.Unmarshal(body, response); err != nil {
+		return false, errors.Wrap(err, "error getting CQL search results with API token")
+	}
+	return len(response.Results) > 0, nil
+}
+
+func (p *Plugin) MakeHTTPCallWithAPIToken(path, adminAPIToken string) ([]byte, int, error) {
 	httpClient := &http.Client{}
 	req, err := http.NewRequest(http.MethodGet, path, nil)
 	if err != nil {
 		return nil, http.StatusInternalServerError, err
 	}
 
-	err = p.SetAdminAPITokenRequestHeader(req)
+	err = p.SetAdminAPITokenRequestHeader(req, adminAPIToken)
 	if err != nil {
 		return nil, http.StatusInternalServerError, err
 	}
@@ -307,10 +413,8 @@ func (p *Plugin) MakeHTTPCallWithAPIToken(path string) ([]byte, int, error) {
 	return body, resp.StatusCode, err
 }
 
-func (p *Plugin) SetAdminAPITokenRequestHeader(req *http.Request) error {
-	pluginConfig := config.GetConfig()
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", pluginConfig.AdminAPIToken))
+func (p *Plugin) SetAdminAPITokenRequestHeader(req *http.Request, adminAPIToken string) error {
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", adminAPIToken))
 	req.Header.Set("Accept", "application/json")
 
 	return nil
@@ -6,21 +6,33 @@ import (
 
 	"github.com/mattermost/mattermost/server/public/model"
 
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
 	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
 	"github.com/mattermost/mattermost-plugin-confluence/server/util"
 )
 
 const (
-	ConfluencePageCreatedMessage            = "%s published a new page in %s."
-	ConfluencePageCreatedWithoutBodyMessage = "%s published a new page %s in %s."
-	ConfluencePageUpdatedMessage            = "%s updated %s in %s."
-	ConfluencePageTrashedMessage            = "%s trashed %s in %s."
-	ConfluencePageRestoredMessage           = "%s restored %s in %s."
-	ConfluenceCommentCreatedMessage         = "%s commented on %s in %s."
-	ConfluenceEmptyCommentCreatedMessage    = "%s [commented](%s) on %s in %s."
-	ConfluenceCommentUpdatedMessage         = "%s updated a comment on %s in %s."
-	ConfluenceEmptyCommentUpdatedMessage    = "%s updated a [comment](%s) on %s in %s."
-	ConfluenceSpaceUpdatedMessage           = "A space titled [%s](%s) was updated."
+	ConfluencePageCreatedMessage             = "%s published a new page in %s."
+	ConfluencePageCreatedWithoutBodyMessage  = "%s published a new page %s in %s."
+	ConfluencePageUpdatedMessage             = "%s updated %s in %s."
+	ConfluencePageUpdatedWithDiffLinkMessage = "%s [View changes](%s)"
+	ConfluencePageChangesMessage             = "**What’s Changed?** %s\n```diff\n%s```\n[**View changes**](%s) | [**View in Confluence**](%s)"
+	ConfluencePageChangesMoreLines           = "… %s not shown\n"
+	ConfluencePageTrashedMessage             = "%s trashed %s in %s."
+	ConfluencePageRestoredMessage            = "%s restored %s in %s."
+	ConfluenceCommentCreatedMessage          = "%s commented on %s in %s."
+	ConfluenceEmptyCommentCreatedMessage     = "%s [commented](%s) on %s in %s."
+	ConfluenceCommentUpdatedMessage          = "%s updated a comment on %s in %s."
+	ConfluenceEmptyCommentUpdatedMessage     = "%s updated a [comment](%s) on %s in %s."
+	ConfluenceSpaceUpdatedMessage            = "A space titled [%s](%s) was updated."
+	ConfluenceBlogCreatedMessage             = "%s published a new blog post in %s."
+	ConfluenceBlogCreatedWithoutBodyMessage  = "%s published a new blog post %s in %s."
+	ConfluenceBlogUpdatedMessage             = "%s updated the blog post %s in %s."
+	ConfluenceBlogTrashedMessage             = "%s trashed the blog post %s in %s."
+	ConfluenceBlogRemovedMessage             = "%s removed the blog post **%s** in %s."
+
+	// maxPageDiffLineLength bounds the length of each line of the changes of a page or blog post.
+	maxPageDiffLineLength = 200
 )
 
 func (e ConfluenceServerEvent) GetSpaceKey() string {
@@ -47,6 +59,100 @@ func (e ConfluenceServerEvent) GetPageID() string {
 	return e.Page.ID
 }
 
+// GetCommentID returns the id of the comment of comment events.
+func (e ConfluenceServerEvent) GetCommentID() string {
+	if e.Comment == nil {
+		return ""
+	}
+	return e.Comment.ID
+}
+
+// GetParentCommentID returns the id of the comment a comment replies to, the closest of its ancestors.
+func (e ConfluenceServerEvent) GetParentCommentID() string {
+	if e.Comment == nil || len(e.Comment.Ancestors) == 0 {
+		return ""
+	}
+	return e.Comment.Ancestors[len(e.Comment.Ancestors)-1].ID
+}
+
+func (e ConfluenceServerEvent) GetBlogSpaceKey() string {
+	return e.Blog.Space.Key
+}
+
+func (e ConfluenceServerEvent) GetBlogID() string {
+	return e.Blog.ID
+}
+
+// GetContentType returns the Confluence content type of the page or blog post the event is about.
+func (e ConfluenceServerEvent) GetContentType() string {
+	switch {
+	case e.Page != nil:
+		return serializer.ConfluenceContentTypePage
+	case e.Blog != nil:
+		return serializer.ConfluenceContentTypeBlogPost
+	case e.Comment != nil:
+		return e.Comment.Container.Type
+	default:
+		return ""
+	}
+}
+
+// GetLabels returns the labels of the page or blog post the event is about, or of the content a comment was made on.
+func (e ConfluenceServerEvent) GetLabels() []string {
+	if e.Page != nil {
+		return e.Page.Metadata.LabelNames()
+	}
+	if e.Blog != nil {
+		return e.Blog.Metadata.LabelNames()
+	}
+	if e.Comment != nil {
+		return e.Comment.Container.Metadata.LabelNames()
+	}
+	return nil
+}
+
+// GetAncestorIDs returns the ids of the pages above the page the event is about, or the page a comment was made on.
+func (e ConfluenceServerEvent) GetAncestorIDs() []string {
+	var ancestors []Ancestor
+	if e.Page != nil {
+		ancestors = e.Page.Ancestors
+	} else if e.Comment != nil {
+		ancestors = e.Comment.Container.Ancestors
+	}
+
+	var ids []string
+	for _, ancestor := range ancestors {
+		ids = append(ids, ancestor.ID)
+	}
+	return ids
+}
+
+// GetAuthors returns the key and the username of the user who triggered the event.
+func (e ConfluenceServerEvent) GetAuthors() []string {
+	authors := []string{e.UserKey}
+	switch {
+	case e.Page != nil:
+		authors = append(authors, e.Page.Version.By.Username, e.Page.Version.By.UserKey)
+	case e.Blog != nil:
+		authors = append(authors, e.Blog.Version.By.Username, e.Blog.Version.By.UserKey)
+	case e.Comment != nil:
+		authors = append(authors, e.Comment.Version.By.Username, e.Comment.Version.By.UserKey)
+	}
+	return authors
+}
+
+// MinorEdit reports whether the page or blog post was edited without notifying watchers.
+func (e ConfluenceServerEvent) MinorEdit() bool {
+	switch {
+	case e.Page != nil:
+		return e.Page.Version.MinorEdit
+	case e.Blog != nil:
+		return e.Blog.Version.MinorEdit
+	default:
+		return false
+	}
+}
+
 func (e *ConfluenceServerEvent) GetUserDisplayNameForCommentEvents() string {
 	return util.GetUsernameOrAnonymousName(e.Comment.History.CreatedBy.Username)
 }
@@ -55,6 +161,10 @@ func (e *ConfluenceServerEvent) GetUserDisplayNameForPageEvents() string {
 	return util.GetUsernameOrAnonymousName(e.Page.History.CreatedBy.Username)
 }
 
+func (e *ConfluenceServerEvent) GetUserDisplayNameForBlogEvents() string {
+	return util.GetUsernameOrAnonymousName(e.Blog.History.CreatedBy.Username)
+}
+
 func (e *ConfluenceServerEvent) GetSpaceDisplayNameForCommentEvents(baseURL string) string {
 	name := e.Comment.Space.Key
 	if strings.TrimSpace(e.Comment.Space.Name) != "" {
@@ -89,6 +199,25 @@ func (e *ConfluenceServerEvent) GetPageDisplayNameForPageEvents(baseURL string)
 	return name
 }
 
+func (e *ConfluenceServerEvent) GetSpaceDisplayNameForBlogEvents(baseURL string) string {
+	name := e.Blog.Space.Key
+	if strings.TrimSpace(e.Blog.Space.Name) != "" {
+		name = strings.TrimSpace(e.Blog.Space.Name)
+	}
+	if e.Blog.Space.Links.Self != "" {
+		name = fmt.Sprintf("[%s](%s/%s)", name, baseURL, e.Blog.Space.Links.Self)
+	}
+	return name
+}
+
+func (e *ConfluenceServerEvent) GetBlogDisplayNameForBlogEvents(baseURL string, withLink bool) string {
+	name := e.Blog.Title
+	if withLink && e.Blog.Links.Self != "" {
+		name = fmt.Sprintf("[%s](%s/%s)", name, baseURL, e.Blog.Links.Self)
+	}
+	return name
+}
+
 func (e *ConfluenceServerEvent) GetPageDisplayNameForCommentEvents(baseURL string) string {
 	if e.Comment.Container.Title == "" {
 		return ""
@@ -101,6 +230,60 @@ func (e *ConfluenceServerEvent) GetPageDisplayNameForCommentEvents(baseURL strin
 	return name
 }
 
+// GetTemplateData returns the data notification templates are rendered with for the event.
+func (e ConfluenceServerEvent) GetTemplateData(eventType string) serializer.TemplateData {
+	data := serializer.TemplateData{
+		Event:       eventType,
+		ContentType: e.GetContentType(),
+	}
+
+	var content *PageResponse
+	var space SpaceResponse
+	var version Version
+	switch {
+	case e.Page != nil:
+		content, space, version = e.Page, e.Page.Space, e.Page.Version
+	case e.Blog != nil:
+		content, space, version = e.Blog, e.Blog.Space, e.Blog.Version
+	case e.Comment != nil:
+		space, version = e.Comment.Space, e.Comment.Version
+		data.Title = e.Comment.Container.Title
+		data.URL = e.webURL(e.Comment.Container.Links)
+		data.Excerpt = serializer.TemplateExcerpt(e.Comment.Body.View.Value)
+		data.CommentURL = e.webURL(e.Comment.Links)
+	case e.Space != nil:
+		space = *e.Space
+	}
+	if content != nil {
+		data.Title = content.Title
+		data.URL = e.webURL(content.Links)
+		data.Excerpt = serializer.TemplateExcerpt(content.Body.View.Value)
+		data.Version = version.Number
+	}
+
+	// The version is authored by who triggered the event, the creator is the fallback for events without one.
+	author := version.By.Username
+	if author == "" && content != nil {
+		author = content.History.CreatedBy.Username
+	} else if author == "" && e.Comment != nil {
+		author = e.Comment.History.CreatedBy.Username
+	}
+	data.Author = util.GetUsernameOrAnonymousName(author)
+
+	data.SpaceKey = space.Key
+	data.SpaceName = strings.TrimSpace(space.Name)
+	data.SpaceURL = e.webURL(space.Links)
+	return data
+}
+
+// webURL returns the URL of the Confluence page behind the links of a content, or an empty string without one.
+func (e ConfluenceServerEvent) webURL(links Links) string {
+	if links.Self == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s", e.BaseURL, links.Self)
+}
+
 func (e ConfluenceServerEvent) GetNotificationPost(eventType, baseURL, botUserID string) *model.Post {
 	var attachment *model.SlackAttachment
 	post := &model.Post{
@@ -124,15 +307,7 @@ func (e ConfluenceServerEvent) GetNotificationPost(eventType, baseURL, botUserID
 
 	case serializer.PageUpdatedEvent:
 		message := fmt.Sprintf(ConfluencePageUpdatedMessage, e.GetUserDisplayNameForPageEvents(), e.GetPageDisplayNameForPageEvents(baseURL), e.GetSpaceDisplayNameForPageEvents(baseURL))
-		if strings.TrimSpace(e.Page.Body.View.Value) != "" {
-			attachment = &model.SlackAttachment{
-				Fallback: message,
-				Pretext:  message,
-				Text:     fmt.Sprintf("**What’s Changed?**\n> %s\n\n[**View in Confluence**](%s)", strings.TrimSpace(e.Page.Body.View.Value), fmt.Sprintf("%s/%s", baseURL, e.Page.Links.Self)),
-			}
-		} else {
-			post.Message = message
-		}
+		attachment = e.getContentChanges(post, e.Page, message, baseURL)
 
 	case serializer.PageTrashedEvent:
 		post.Message = fmt.Sprintf(ConfluencePageTrashedMessage, e.GetUserDisplayNameForPageEvents(), e.GetPageDisplayNameForPageEvents(baseURL), e.GetSpaceDisplayNameForPageEvents(baseURL))
@@ -140,6 +315,31 @@ func (e ConfluenceServerEvent) GetNotificationPost(eventType, baseURL, botUserID
 	case serializer.PageRestoredEvent:
 		post.Message = fmt.Sprintf(ConfluencePageRestoredMessage, e.GetUserDisplayNameForPageEvents(), e.GetPageDisplayNameForPageEvents(baseURL), e.GetSpaceDisplayNameForPageEvents(baseURL))
 
+	case serializer.BlogCreatedEvent:
+		message := fmt.Sprintf(ConfluenceBlogCreatedMessage, e.GetUserDisplayNameForBlogEvents(), e.GetSpaceDisplayNameForBlogEvents(baseURL))
+		if strings.TrimSpace(e.Blog.Body.View.Value) != "" {
+			attachment = &model.SlackAttachment{
+				Fallback:  message,
+				Pretext:   message,
+				Title:     e.Blog.Title,
+				TitleLink: fmt.Sprintf("%s/%s", baseURL, e.Blog.Links.Self),
+				Text:      fmt.Sprintf("%s\n\n[**View in Confluence**](%s)", strings.TrimSpace(e.Blog.Body.View.Value), fmt.Sprintf("%s/%s", baseURL, e.Blog.Links.Self)),
+			}
+		} else {
+			post.Message = fmt.Sprintf(ConfluenceBlogCreatedWithoutBodyMessage, e.GetUserDisplayNameForBlogEvents(), e.GetBlogDisplayNameForBlogEvents(baseURL, true), e.GetSpaceDisplayNameForBlogEvents(baseURL))
+		}
+
+	case serializer.BlogUpdatedEvent:
+		message := fmt.Sprintf(ConfluenceBlogUpdatedMessage, e.GetUserDisplayNameForBlogEvents(), e.GetBlogDisplayNameForBlogEvents(baseURL, true), e.GetSpaceDisplayNameForBlogEvents(baseURL))
+		attachment = e.getContentChanges(post, e.Blog, message, baseURL)
+
+	case serializer.BlogTrashedEvent:
+		post.Message = fmt.Sprintf(ConfluenceBlogTrashedMessage, e.GetUserDisplayNameForBlogEvents(), e.GetBlogDisplayNameForBlogEvents(baseURL, true), e.GetSpaceDisplayNameForBlogEvents(baseURL))
+
+	case serializer.BlogRemovedEvent:
+		// No link for blog post since the blog post was removed
+		post.Message = fmt.Sprintf(ConfluenceBlogRemovedMessage, e.GetUserDisplayNameForBlogEvents(), e.GetBlogDisplayNameForBlogEvents(baseURL, false), e.GetSpaceDisplayNameForBlogEvents(baseURL))
+
 	case serializer.CommentCreatedEvent:
 		message := fmt.Sprintf(ConfluenceCommentCreatedMessage, e.GetUserDisplayNameForCommentEvents(), e.GetPageDisplayNameForCommentEvents(baseURL), e.GetSpaceDisplayNameForCommentEvents(baseURL))
 		text := ""
@@ -177,3 +377,71 @@ func (e ConfluenceServerEvent) GetNotificationPost(eventType, baseURL, botUserID
 	}
 	return post
 }
+
+// getContentChanges returns the attachment showing what changed in the updated page or blog post, or nil when nothing
+// is known of the changes, in which case the message is set on the post, with a link to the changes if there is a previous version.
+func (e ConfluenceServerEvent) getContentChanges(post *model.Post, content *PageResponse, message, baseURL string) *model.SlackAttachment {
+	if !e.ContentDiff.IsEmpty() {
+		return &model.SlackAttachment{
+			Fallback: message,
+			Pretext:  message,
+			Text:     e.formatContentChanges(content, baseURL, config.GetConfig().GetPageDiffMaxLines()),
+		}
+	}
+
+	if content.Version.Number > 1 {
+		post.Message = fmt.Sprintf(ConfluencePageUpdatedWithDiffLinkMessage, message, getContentDiffURL(content, baseURL))
+	} else {
+		post.Message = message
+	}
+	return nil
+}
+
+// getContentDiffURL returns the link to the Confluence view of the changes of a page or blog post since its previous version.
+func getContentDiffURL(content *PageResponse, baseURL string) string {
+	return fmt.Sprintf("%s/pages/diffpagesbyversion.action?pageId=%s&selectedPageVersions=%d&selectedPageVersions=%d", baseURL, content.ID, content.Version.Number-1, content.Version.Number)
+}
+
+// formatContentChanges returns the summary of the lines added to and removed from the page or blog post, showing at most maxLines of them,
+// with links to the full changes and to the content.
+func (e ConfluenceServerEvent) formatContentChanges(content *PageResponse, baseURL string, maxLines int) string {
+	diff := e.ContentDiff
+	summary := []string{}
+	if added := len(diff.Added); added > 0 {
+		summary = append(summary, pluralLines(added)+" added")
+	}
+	if removed := len(diff.Removed); removed > 0 {
+		summary = append(summary, pluralLines(removed)+" removed")
+	}
+
+	lines := ""
+	shown := 0
+	for _, change := range []struct {
+		sign  string
+		lines []string
+	}{{"+", diff.Added}, {"-", diff.Removed}} {
+		for _, line := range change.lines {
+			if shown == maxLines {
+				break
+			}
+			if runes := []rune(line); len(runes) > maxPageDiffLineLength {
+				line = string(runes[:maxPageDiffLineLength]) + "…"
+			}
+			// A fence within a line would end the code block.
+			lines += change.sign + " " + strings.ReplaceAll(line, "```", "` ` `") + "\n"
+			shown++
+		}
+	}
+	if more := len(diff.Added) + len(diff.Removed) - shown; more > 0 {
+		lines += fmt.Sprintf(ConfluencePageChangesMoreLines, pluralLines(more))
+	}
+
+	return fmt.Sprintf(ConfluencePageChangesMessage, strings.Join(summary, ", "), lines, getContentDiffURL(content, baseURL), fmt.Sprintf("%s/%s", baseURL, content.Links.Self))
+}
+
+func pluralLines(count int) string {
+	if count == 1 {
+		return "1 line"
+	}
+	return fmt.Sprintf("%d lines", count)
+}
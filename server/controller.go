@@ -35,6 +35,10 @@ var Endpoints = map[string]*Endpoint{
 	getEndpointKey(userConnect):                         userConnect,
 	getEndpointKey(userConnectComplete):                 userConnectComplete,
 	getEndpointKey(userConnectionInfo):                  userConnectionInfo,
+	getEndpointKey(getAllSubscriptions):                 getAllSubscriptions,
+	getEndpointKey(exportAdminSubscriptions):            exportAdminSubscriptions,
+	getEndpointKey(importAdminSubscriptions):            importAdminSubscriptions,
+	getEndpointKey(getPresets):                          getPresets,
 }
 
 // Uniquely identifies an endpoint using path and method
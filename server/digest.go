@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/service"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+const (
+	digestDeliveryJobKey = "digest_delivery"
+	// digestDeliveryInterval is how late a digest can be posted after its time.
+	digestDeliveryInterval = 5 * time.Minute
+
+	digestImmediate = "immediate"
+
+	digestUsage          = "Please specify the delivery mode, like `/confluence digest hourly`, `/confluence digest daily 08:30 Europe/Paris` or `/confluence digest immediate`."
+	digestSetSuccess     = "The notifications of this channel are now delivered in a digest, %s."
+	digestRemoveSuccess  = "The notifications of this channel are now posted right away. The ones waiting for the digest are posted within a few minutes."
+	channelDigest        = "The notifications of this channel are delivered in a digest, %s."
+	channelDigestMissing = "The notifications of this channel are posted right away."
+)
+
+// scheduleDigestDelivery starts the periodic delivery of the digests that are due.
+// The job holds a cluster mutex while it runs, so a digest is only posted by one server of a cluster.
+func (p *Plugin) scheduleDigestDelivery() error {
+	job, err := cluster.Schedule(p.API, digestDeliveryJobKey, cluster.MakeWaitForRoundedInterval(digestDeliveryInterval), p.deliverDigests)
+	if err != nil {
+		return err
+	}
+	p.digestDeliveryJob = job
+	return nil
+}
+
+func (p *Plugin) deliverDigests() {
+	if err := service.DeliverDueDigests(p.BotUserID, time.Now()); err != nil {
+		config.Mattermost.LogError("Unable to deliver the digests.", "Error", err.Error())
+	}
+}
+
+// setDigest sets the delivery mode of the notifications of the channel, shown when no mode is given.
+func setDigest(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	if !checkSubscriptionPermissionCommand(context) {
+		return &model.CommandResponse{}
+	}
+
+	if len(args) == 0 {
+		digest, err := store.LoadChannelDigest(context.ChannelId)
+		if err != nil {
+			postCommandResponse(context, errorExecutingCommand)
+			return &model.CommandResponse{}
+		}
+		if digest == nil {
+			postCommandResponse(context, channelDigestMissing)
+			return &model.CommandResponse{}
+		}
+		postCommandResponse(context, fmt.Sprintf(channelDigest, digest.String()))
+		return &model.CommandResponse{}
+	}
+
+	var digest *types.Digest
+	if args[0] != digestImmediate {
+		if args[0] == types.DigestDaily && len(args) < 2 {
+			postCommandResponse(context, digestUsage)
+			return &model.CommandResponse{}
+		}
+		clock, timeZone := "", ""
+		if len(args) > 1 {
+			clock = args[1]
+		}
+		if len(args) > 2 {
+			timeZone = args[2]
+		}
+		var err error
+		if digest, err = types.ParseDigest(args[0], clock, timeZone); err != nil {
+			postCommandResponse(context, err.Error())
+			return &model.CommandResponse{}
+		}
+	}
+
+	if err := store.StoreChannelDigest(context.ChannelId, digest); err != nil {
+		postCommandResponse(context, errorExecutingCommand)
+		return &model.CommandResponse{}
+	}
+	if digest == nil {
+		postCommandResponse(context, digestRemoveSuccess)
+		return &model.CommandResponse{}
+	}
+	postCommandResponse(context, fmt.Sprintf(digestSetSuccess, digest.String()))
+	return &model.CommandResponse{}
+}
@@ -9,7 +9,9 @@ import (
 
 	"github.com/mattermost/mattermost-plugin-confluence/server/config"
 	"github.com/mattermost/mattermost-plugin-confluence/server/service"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
 	"github.com/mattermost/mattermost-plugin-confluence/server/util"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
 
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
@@ -25,22 +27,18 @@ type FlowManager struct {
 	getConfiguration func() *config.Configuration
 	MMSiteURL        string
 	GetRedirectURL   func() string
-	webhookURL       string
 	setupFlow        *flow.Flow
 	completionFlow   *flow.Flow
 	announcementFlow *flow.Flow
 }
 
 func (p *Plugin) NewFlowManager() (*FlowManager, error) {
-	webhookURL := util.GetPluginURL() + util.GetConfluenceServerWebhookURLPath()
-
 	fm := &FlowManager{
 		client:           p.client,
 		plugin:           p,
 		pluginID:         manifest.Id,
 		botUserID:        p.BotUserID,
 		router:           p.Router,
-		webhookURL:       webhookURL,
 		getConfiguration: config.GetConfig,
 		MMSiteURL:        util.GetSiteURL(),
 		GetRedirectURL:   p.GetRedirectURL,
@@ -123,6 +121,8 @@ const (
 
 	keyConfluenceURL     = "ConfluenceURL"
 	keyIsOAuthConfigured = "IsOAuthConfigured"
+	keyWebhookURL        = "WebhookURL"
+	keyConnectURL        = "ConnectURL"
 )
 
 func cancelButton() flow.Button {
@@ -152,19 +152,19 @@ func continueButton(next flow.Name) flow.Button {
 	return continueButtonF(flow.Goto(next))
 }
 
-func (fm *FlowManager) getBaseState() flow.State {
-	config := fm.getConfiguration()
-	isOAuthConfigured := config.ConfluenceOAuthClientID != "" || config.ConfluenceOAuthClientSecret != ""
+// getInstanceState returns the flow state describing the given instance.
+func (fm *FlowManager) getInstanceState(instance *types.Instance) flow.State {
 	return flow.State{
-		keyConfluenceURL:     config.GetConfluenceBaseURL(),
-		keyIsOAuthConfigured: isOAuthConfigured,
+		keyConfluenceURL:     instance.URL,
+		keyIsOAuthConfigured: instance.IsOAuthConfigured(),
+		keyWebhookURL:        getInstanceWebhookURL(instance),
+		keyConnectURL:        instanceRoute(instance.URL, routeUserConnect),
 	}
 }
 
+// StartSetupWizard starts the setup wizard, which adds a new instance or updates the instance with the same URL.
 func (fm *FlowManager) StartSetupWizard(userID string, delegatedFrom string) error {
-	state := fm.getBaseState()
-
-	err := fm.setupFlow.ForUser(userID).Start(state)
+	err := fm.setupFlow.ForUser(userID).Start(flow.State{})
 	if err != nil {
 		return err
 	}
@@ -174,10 +174,13 @@ func (fm *FlowManager) StartSetupWizard(userID string, delegatedFrom string) err
 	return nil
 }
 
-func (fm *FlowManager) StartCompletionWizard(userID string) error {
-	state := fm.getBaseState()
+func (fm *FlowManager) StartCompletionWizard(userID, instanceURL string) error {
+	instance, err := store.LoadInstance(instanceURL)
+	if err != nil {
+		return err
+	}
 
-	if err := fm.completionFlow.ForUser(userID).Start(state); err != nil {
+	if err = fm.completionFlow.ForUser(userID).Start(fm.getInstanceState(instance)); err != nil {
 		return err
 	}
 
@@ -186,6 +189,21 @@ func (fm *FlowManager) StartCompletionWizard(userID string) error {
 	return nil
 }
 
+// updateInstance applies the modification to the instance being set up by the flow.
+func (fm *FlowManager) updateInstance(f *flow.Flow, modify func(instance *types.Instance)) error {
+	instance, err := store.LoadInstance(f.GetState().GetString(keyConfluenceURL))
+	if err != nil {
+		return errors.Wrap(err, "failed to load the Confluence instance")
+	}
+
+	modify(instance)
+
+	if err = store.StoreInstance(instance); err != nil {
+		return errors.Wrap(err, "failed to store the Confluence instance")
+	}
+	return nil
+}
+
 func (fm *FlowManager) stepWelcome() flow.Step {
 	welcomeText := fmt.Sprintf(":wave: Welcome to your Confluence integration! [Learn more](%s)", documentationURL)
 	welcomePretext := "Just a few configuration steps to go!"
@@ -204,9 +222,12 @@ func (fm *FlowManager) stepServerVersionQuestion() flow.Step {
 			Name:  "Yes",
 			Color: flow.ColorPrimary,
 			OnClick: func(f *flow.Flow) (flow.Name, flow.State, error) {
-				pluginConfig := config.GetConfig()
-				pluginConfig.ServerVersionGreaterthan9 = true
-				config.SetConfig(pluginConfig)
+				err := fm.updateInstance(f, func(instance *types.Instance) {
+					instance.ServerVersionGreaterthan9 = true
+				})
+				if err != nil {
+					return "", nil, err
+				}
 
 				return stepCSversionGreaterthan9, nil, nil
 			},
@@ -215,9 +236,12 @@ func (fm *FlowManager) stepServerVersionQuestion() flow.Step {
 			Name:  "No",
 			Color: flow.ColorDefault,
 			OnClick: func(f *flow.Flow) (flow.Name, flow.State, error) {
-				pluginConfig := config.GetConfig()
-				pluginConfig.ServerVersionGreaterthan9 = false
-				config.SetConfig(pluginConfig)
+				err := fm.updateInstance(f, func(instance *types.Instance) {
+					instance.ServerVersionGreaterthan9 = false
+				})
+				if err != nil {
+					return "", nil, err
+				}
 
 				return stepCSversionLessthan9, nil, nil
 			},
@@ -227,10 +251,7 @@ func (fm *FlowManager) stepServerVersionQuestion() flow.Step {
 func (fm *FlowManager) stepCSversionGreaterthan9() flow.Step {
 	return flow.NewStep(stepCSversionGreaterthan9).
 		WithText(
-			fmt.Sprintf(
-				"%s has been successfully added. To finish the configuration, add an Application Link in your Confluence instance following these steps:\n",
-				fm.getConfluenceBaseURL(),
-			) +
+			"{{ .ConfluenceURL }} has been successfully added. To finish the configuration, add an Application Link in your Confluence instance following these steps:\n" +
 				"1. Go to [**Settings > Applications > Application Links**]({{ .ConfluenceURL }}/plugins/servlet/applinks/listApplicationLinks)\n" +
 				"   ![image](https://user-images.githubusercontent.com/90389917/202149868-a3044351-37bc-43c0-9671-aba169706917.png)\n" +
 				"2. Select **Create link**.\n" +
@@ -253,7 +274,7 @@ func (fm *FlowManager) stepWebhookInstructions() flow.Step {
 				"2. Select **Create Webhook**.\n" +
 				"4. On the **Create Webhook** screen, set the following values:\n" +
 				"   - **Name**: `Mattermost Webhook`\n" +
-				fmt.Sprintf("   - **URL**: `%s`\n", fm.webhookURL) +
+				"   - **URL**: `{{ .WebhookURL }}`\n" +
 				"   - Select all the Events in the list\n" +
 				"   Select **Save**.\n",
 		).
@@ -262,16 +283,16 @@ func (fm *FlowManager) stepWebhookInstructions() flow.Step {
 
 func (fm *FlowManager) stepCSversionLessthan9() flow.Step {
 	return flow.NewStep(stepCSversionLessthan9).
-		WithText(fmt.Sprintf(`
-To configure the plugin, create a new app in your [Confluence Server](%s) following these steps:
+		WithText(`
+To configure the plugin, create a new app in your [Confluence Server]({{ .ConfluenceURL }}) following these steps:
 1. Navigate to **Settings > Apps > Manage Apps**. For older versions of Confluence, navigate to **Administration > Applications > Add-ons > Manage add-ons**.
 2. Choose **Settings** at the bottom of the page, enable development mode, and apply the change. Development mode allows you to install apps from outside of the Atlassian Marketplace.
 3. Press **Upload app**.
 4. Choose **From my computer** and upload the Mattermost for Confluence OBR file.
 5. Once the app is installed, press **Configure** to open the configuration page.
-6. In the **Webhook URL** field, enter: %s
+6. In the **Webhook URL** field, enter: {{ .WebhookURL }}
 7. Press **Save** to finish the setup.
-`, fm.getConfluenceBaseURL(), fm.webhookURL)).
+`).
 		WithButton(continueButton(stepDone))
 }
 
@@ -314,7 +335,8 @@ func (fm *FlowManager) submitConfluenceURL(f *flow.Flow, submitted map[string]in
 		return "", nil, nil, errors.New("confluence_url is not a string")
 	}
 
-	if _, err := service.CheckConfluenceURL(fm.MMSiteURL, confluenceURL, false); err != nil {
+	confluenceURL, err := service.CheckConfluenceURL(fm.MMSiteURL, confluenceURL, false)
+	if err != nil {
 		errorList["confluence_url"] = err.Error()
 	}
 
@@ -322,22 +344,23 @@ func (fm *FlowManager) submitConfluenceURL(f *flow.Flow, submitted map[string]in
 		return "", nil, errorList, nil
 	}
 
-	config := fm.getConfiguration()
-	config.ConfluenceURL = confluenceURL
-	config.Sanitize()
-
-	configMap, err := config.ToMap()
+	// Installing an instance again keeps its webhook secret, so the webhooks already set up in Confluence keep working.
+	instance, err := store.LoadInstance(confluenceURL)
 	if err != nil {
-		return "", nil, nil, err
+		if errors.Cause(err) != store.ErrNotFound {
+			return "", nil, nil, err
+		}
+		instance = &types.Instance{
+			URL:           confluenceURL,
+			WebhookSecret: model.NewId(),
+		}
 	}
 
-	if err = fm.client.Configuration.SavePluginConfig(configMap); err != nil {
-		return "", nil, nil, errors.Wrap(err, "failed to save plugin config")
+	if err = store.StoreInstance(instance); err != nil {
+		return "", nil, nil, errors.Wrap(err, "failed to store the Confluence instance")
 	}
 
-	return stepServerVersionQuestion, flow.State{
-		keyConfluenceURL: config.GetConfluenceBaseURL(),
-	}, nil, nil
+	return stepServerVersionQuestion, fm.getInstanceState(instance), nil, nil
 }
 
 func (fm *FlowManager) stepOAuthInput() flow.Step {
@@ -365,6 +388,15 @@ func (fm *FlowManager) stepOAuthInput() flow.Step {
 						SubType:     "text",
 						Placeholder: "Enter Confluence OAuth Secret",
 					},
+					{
+						DisplayName: "Confluence Admin API Token",
+						Name:        "admin_api_token",
+						Type:        "text",
+						SubType:     "password",
+						Placeholder: "Enter Confluence Admin API Token",
+						HelpText:    "Optional. Used to send detailed notifications for events triggered by users who are not connected to Mattermost.",
+						Optional:    true,
+					},
 				},
 			},
 			OnDialogSubmit: fm.submitOAuthConfig,
@@ -409,36 +441,30 @@ func (fm *FlowManager) submitOAuthConfig(f *flow.Flow, submitted map[string]inte
 		return "", nil, errorList, nil
 	}
 
-	config := fm.getConfiguration()
-	config.ConfluenceOAuthClientID = clientID
-	config.ConfluenceOAuthClientSecret = clientSecret
+	adminAPIToken, _ := submitted["admin_api_token"].(string)
 
-	configMap, err := config.ToMap()
+	err := fm.updateInstance(f, func(instance *types.Instance) {
+		instance.OAuthClientID = clientID
+		instance.OAuthClientSecret = clientSecret
+		instance.AdminAPIToken = strings.TrimSpace(adminAPIToken)
+	})
 	if err != nil {
 		return "", nil, nil, err
 	}
 
-	err = fm.client.Configuration.SavePluginConfig(configMap)
-	if err != nil {
-		return "", nil, nil, errors.Wrap(err, "failed to save plugin config")
-	}
-
 	return stepOAuthConnect, nil, nil, nil
 }
 
 func (fm *FlowManager) stepOAuthConnect() flow.Step {
 	connectPretext := "##### :white_check_mark: Connect your Confluence account"
-	connectURL := fmt.Sprintf(oauth2ConnectPath, util.GetPluginURL())
-	connectText := fmt.Sprintf("Go [here](%s) to connect your account.", connectURL)
+	connectText := "Go [here]({{ .ConnectURL }}) to connect your account."
 	return flow.NewStep(stepOAuthConnect).
 		WithText(connectText).
 		WithPretext(connectPretext)
 }
 
 func (fm *FlowManager) StartAnnouncementWizard(userID string) error {
-	state := fm.getBaseState()
-
-	err := fm.announcementFlow.ForUser(userID).Start(state)
+	err := fm.announcementFlow.ForUser(userID).Start(flow.State{})
 	if err != nil {
 		return err
 	}
@@ -535,9 +561,3 @@ func (fm *FlowManager) stepDone() flow.Step {
 		Terminal().
 		WithText(":tada: You successfully installed Confluence.")
 }
-
-func (fm *FlowManager) getConfluenceBaseURL() string {
-	pluginConfig := config.GetConfig()
-
-	return pluginConfig.ConfluenceURL
-}
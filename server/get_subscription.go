@@ -6,6 +6,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
 	"github.com/mattermost/mattermost-plugin-confluence/server/service"
 )
 
@@ -18,6 +19,10 @@ var getChannelSubscription = &Endpoint{
 func handleGetChannelSubscription(w http.ResponseWriter, r *http.Request, _ *Plugin) {
 	params := mux.Vars(r)
 	channelID := params["channelID"]
+	if !checkSubscriptionPermissionHTTP(w, r.Header.Get(config.HeaderMattermostUserID), channelID) {
+		return
+	}
+
 	alias := r.FormValue("alias")
 	subscription, errCode, err := service.GetChannelSubscription(channelID, alias)
 	if err != nil {
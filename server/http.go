@@ -8,6 +8,8 @@ import (
 	"text/template"
 
 	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/util"
 )
 
 const (
@@ -45,9 +47,10 @@ func (p *Plugin) loadTemplates(dir string) (map[string]*template.Template, error
 	return templates, nil
 }
 
-// splitInstancePath extracts the instance ID from a given route and returns the instance URL along with the remaining path
-// if the route does not contain a valid instance ID, the original route is returned.
-func splitInstancePath(route string) (instanceURL string, remainingPath string) {
+// splitInstancePath extracts the instance ID from a given route and returns it along with the route without the instance segments,
+// e.g. "/api/v1/instance/<id>/server/webhook" is split into the instance ID and "/api/v1/server/webhook".
+// If the route does not contain a valid instance ID, an empty instance ID and the original route are returned.
+func splitInstancePath(route string) (instanceID string, remainingPath string) {
 	leadingSlash := ""
 	ss := strings.Split(route, "/")
 
@@ -57,29 +60,25 @@ func splitInstancePath(route string) (instanceURL string, remainingPath string)
 		ss = ss[1:]
 	}
 
-	// If there's not enough parts in the path, return the original route
-	if len(ss) < 2 {
-		return "", route
-	}
-
-	// Remove API version prefix if present (e.g., "api/v1")
-	if ss[0] == "api" && strings.Contains(ss[1], "v") {
-		ss = ss[2:]
+	// Keep the API version prefix if present (e.g., "api/v1")
+	var prefix []string
+	if len(ss) > 2 && ss[0] == "api" && strings.HasPrefix(ss[1], "v") {
+		prefix, ss = []string{ss[0], ss[1]}, ss[2:]
 	}
 
-	// If the first segment is not the expected instance prefix, return the route as is
-	if ss[0] != routePrefixInstance {
-		return route, route
+	// If there's not enough parts in the path or the first segment is not the expected instance prefix, return the route as is
+	if len(ss) < 2 || ss[0] != routePrefixInstance {
+		return "", route
 	}
 
 	// Try to decode the instance ID
-	id, err := decode(ss[1])
-	if err != nil {
+	id, err := util.Decode(ss[1])
+	if err != nil || len(id) == 0 {
 		return "", route
 	}
 
 	// Return the decoded instance ID and the remaining path
-	return string(id), leadingSlash + strings.Join(ss[2:], "/")
+	return string(id), leadingSlash + strings.Join(append(prefix, ss[2:]...), "/")
 }
 
 func (p *Plugin) respondTemplate(w http.ResponseWriter, key string, r *http.Request, status int, contentType string, values interface{}) (int, error) {
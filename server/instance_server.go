@@ -3,20 +3,33 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2"
 
 	"github.com/mattermost/mattermost-plugin-confluence/server/config"
 	"github.com/mattermost/mattermost-plugin-confluence/server/service"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
 	"github.com/mattermost/mattermost-plugin-confluence/server/util"
 	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
 )
 
+const instanceMigrationMutexKey = "instance_migration"
+
+var errMultipleInstances = errors.New("several Confluence instances are installed")
+
+type instanceContextKey struct{}
+
 func (p *Plugin) GetServerOAuth2Config(instanceURL string, isAdmin bool) (*oauth2.Config, error) {
-	config := config.GetConfig()
-	if config == nil {
-		return nil, errors.New("error getting plugin configurations")
+	instance, err := store.LoadInstance(instanceURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting the Confluence instance")
+	}
+	if !instance.IsOAuthConfigured() {
+		return nil, errors.Errorf("OAuth is not configured for the Confluence instance %s", instanceURL)
 	}
 
 	var scopes []string
@@ -31,8 +44,8 @@ func (p *Plugin) GetServerOAuth2Config(instanceURL string, isAdmin bool) (*oauth
 		}
 	}
 	return &oauth2.Config{
-		ClientID:     config.ConfluenceOAuthClientID,
-		ClientSecret: config.ConfluenceOAuthClientSecret,
+		ClientID:     instance.OAuthClientID,
+		ClientSecret: instance.OAuthClientSecret,
 		RedirectURL:  fmt.Sprintf("%s%s", util.GetPluginURL(), routeUserComplete),
 		Scopes:       scopes,
 		Endpoint: oauth2.Endpoint{
@@ -72,3 +85,137 @@ func (p *Plugin) ResolveWebhookInstanceURL(instanceURL string) (string, error) {
 
 	return instanceURL, nil
 }
+
+// withInstanceID returns the request with the instance ID taken from its `instance/<id>` route.
+func withInstanceID(r *http.Request, instanceID string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), instanceContextKey{}, instanceID))
+}
+
+// instanceIDFromRequest returns the instance ID of a request made on an `instance/<id>` route, or an empty string.
+func instanceIDFromRequest(r *http.Request) string {
+	instanceID, _ := r.Context().Value(instanceContextKey{}).(string)
+	return instanceID
+}
+
+// instanceRoute returns the URL of a plugin route scoped to the given instance.
+func instanceRoute(instanceURL, route string) string {
+	return fmt.Sprintf("%s/%s/%s%s", util.GetPluginURL(), routePrefixInstance, util.Encode([]byte(instanceURL)), route)
+}
+
+// redactedSecret replaces the webhook secret in the webhook URLs shown after the instance is installed.
+const redactedSecret = "********"
+
+func getInstanceWebhookURL(instance *types.Instance) string {
+	return instanceRoute(instance.URL, confluenceServerWebhook.Path) + "?secret=" + url.QueryEscape(instance.WebhookSecret)
+}
+
+// getRedactedInstanceWebhookURL returns the webhook URL of the instance without its secret, so it can be listed.
+func getRedactedInstanceWebhookURL(instance *types.Instance) string {
+	return instanceRoute(instance.URL, confluenceServerWebhook.Path) + "?secret=" + redactedSecret
+}
+
+// getInstanceAdminAPIToken returns the admin API token of the instance.
+// The instance configured before instances were kept in the KV store falls back to the token of the plugin settings.
+func getInstanceAdminAPIToken(instance *types.Instance) string {
+	if instance.AdminAPIToken != "" {
+		return instance.AdminAPIToken
+	}
+
+	pluginConfig := config.GetConfig()
+	if instance.URL == pluginConfig.ConfluenceURL {
+		return pluginConfig.AdminAPIToken
+	}
+	return ""
+}
+
+// resolveInstance returns the installed instance with the given URL.
+// If no URL is given, the only installed instance is returned.
+func resolveInstance(instanceURL string) (*types.Instance, error) {
+	if instanceURL != "" {
+		normalizedURL, err := service.NormalizeConfluenceURL(instanceURL)
+		if err != nil {
+			return nil, err
+		}
+		return store.LoadInstance(normalizedURL)
+	}
+
+	instances, err := store.LoadInstances()
+	if err != nil {
+		return nil, err
+	}
+	switch len(instances) {
+	case 0:
+		return nil, errors.Wrap(store.ErrNotFound, "no Confluence instance is installed")
+	case 1:
+		return instances[0], nil
+	default:
+		return nil, errMultipleInstances
+	}
+}
+
+// isUserConnectionRequired reports whether users need a connected Confluence account to manage subscriptions,
+// which is the case as soon as a Confluence Server 9 or later instance is installed.
+func isUserConnectionRequired() bool {
+	instances, err := store.LoadInstances()
+	if err != nil {
+		config.Mattermost.LogError("Unable to load the Confluence instances.", "Error", err.Error())
+		return config.GetConfig().ServerVersionGreaterthan9
+	}
+
+	for _, instance := range instances {
+		if instance.ServerVersionGreaterthan9 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasUserConnection reports whether the user has connected a Confluence account on any installed instance.
+func hasUserConnection(mattermostUserID string) (bool, error) {
+	user, err := store.LoadUser(mattermostUserID)
+	if err != nil {
+		if errors.Cause(err) == store.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, instanceURL := range user.ConnectedInstances {
+		connection, connErr := store.LoadConnection(instanceURL, mattermostUserID)
+		if connErr != nil {
+			if errors.Cause(connErr) == store.ErrNotFound {
+				continue
+			}
+			return false, connErr
+		}
+		if len(connection.ConfluenceAccountID()) != 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func formattedInstanceURLs(instances []*types.Instance) string {
+	urls := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		urls = append(urls, "`"+instance.URL+"`")
+	}
+	return strings.Join(urls, ", ")
+}
+
+// migrateInstance moves the single instance of the plugin settings into the instance registry.
+// The admin API token is not copied, so changes to the plugin setting keep applying to this instance.
+func migrateInstance() error {
+	pluginConfig := config.GetConfig()
+	if pluginConfig.ConfluenceURL == "" {
+		return nil
+	}
+
+	return store.MigrateInstance(&types.Instance{
+		URL:                       pluginConfig.ConfluenceURL,
+		OAuthClientID:             pluginConfig.ConfluenceOAuthClientID,
+		OAuthClientSecret:         pluginConfig.ConfluenceOAuthClientSecret,
+		WebhookSecret:             pluginConfig.Secret,
+		ServerVersionGreaterthan9: pluginConfig.ServerVersionGreaterthan9,
+	})
+}
@@ -24,11 +24,14 @@ var eventActions = map[string]string{
 
 type notification struct {
 	*Plugin
+	// cqlSearcher runs the searches of the CQL subscriptions of the instance the events come from.
+	// Without one, CQL subscriptions are not notified.
+	cqlSearcher service.CQLSearcher
 }
 
 func (p *Plugin) getNotification() *notification {
 	return &notification{
-		p,
+		Plugin: p,
 	}
 }
 
@@ -48,10 +51,17 @@ func (n *notification) SendConfluenceNotifications(event serializer.ConfluenceEv
 		return
 	}
 
-	subscriptionChannelIDs := n.getNotificationChannelIDs(url, spaceKey, pageID, eventType)
-	for _, channelID := range subscriptionChannelIDs {
-		post.ChannelId = channelID
-		if _, err := n.API.CreatePost(post); err != nil {
+	notificationEvent := serializer.NewNotificationEventV2(event, eventType, spaceKey, pageID)
+	channels := n.getNotifiedChannels(notificationEvent)
+	if len(channels) == 0 {
+		return
+	}
+
+	templates := service.LoadDefaultTemplates()
+	data := event.GetTemplateData(eventType)
+	for _, channel := range channels {
+		post.ChannelId = channel.ChannelID
+		if err := service.NotifyChannel(service.ChannelNotificationPost(post, notificationEvent, data, templates, channel.Subscriptions), notificationEvent, data); err != nil {
 			n.API.LogError("Unable to create Post in Mattermost", "Error", err.Error())
 		}
 	}
@@ -100,6 +110,11 @@ func (n *notification) extractSpaceKeyAndPageID(event serializer.ConfluenceEvent
 			spaceKey = e.GetPageSpaceKey()
 			pageID = event.GetPageID()
 		}
+	case strings.Contains(eventType, Blog):
+		if e, ok := event.(*ConfluenceServerEvent); ok {
+			spaceKey = e.GetBlogSpaceKey()
+			pageID = e.GetBlogID()
+		}
 	case strings.Contains(eventType, Space):
 		spaceKey = event.GetSpaceKey()
 		if spaceKey != "" {
@@ -110,22 +125,28 @@ func (n *notification) extractSpaceKeyAndPageID(event serializer.ConfluenceEvent
 	return spaceKey, pageID
 }
 
-func (n *notification) getNotificationChannelIDs(url, spaceKey, pageID, eventType string) []string {
-	urlSpaceKeySubscriptions, err := service.GetSubscriptionsByURLSpaceKey(url, spaceKey)
+func (n *notification) getNotifiedChannels(event serializer.NotificationEvent) []service.NotifiedChannel {
+	urlSpaceKeySubscriptions, err := service.GetSubscriptionsByURLSpaceKey(event.BaseURL, event.SpaceKey)
 	if err != nil {
-		n.API.LogError("Unable to get subscribed channels for spaceKey", "SpaceKey", spaceKey, "Error", err.Error())
+		n.API.LogError("Unable to get subscribed channels for spaceKey", "SpaceKey", event.SpaceKey, "Error", err.Error())
 		return nil
 	}
-	urlPageIDSubscriptions, err := service.GetSubscriptionsByURLPageID(url, pageID)
+	urlPageIDSubscriptions, err := service.GetSubscriptionsByURLPageID(event.BaseURL, event.PageID)
 	if err != nil {
-		n.API.LogError("Unable to get subscribed channels for page", "PageID", pageID, "Error", err.Error())
+		n.API.LogError("Unable to get subscribed channels for page", "PageID", event.PageID, "Error", err.Error())
 		return nil
 	}
 
-	urlPageIDSubscriptionChannelIDs := GetURLSubscriptionChannelIDs(urlPageIDSubscriptions, eventType)
-	urlSpaceKeySubscriptionChannelIDs := GetURLSubscriptionChannelIDs(urlSpaceKeySubscriptions, eventType)
+	urlPageIDSubscriptionChannelIDs := GetURLSubscriptionChannelIDs(urlPageIDSubscriptions, event.EventType)
+	urlSpaceKeySubscriptionChannelIDs := GetURLSubscriptionChannelIDs(urlSpaceKeySubscriptions, event.EventType)
+	pageTreeChannelIDs := service.GetPageTreeChannelIDs(event.BaseURL, append([]string{event.PageID}, event.AncestorIDs...), event.EventType)
+	cqlChannelIDs := service.GetCQLChannelIDs(&event, n.cqlSearcher)
+	allSpacesChannelIDs := service.GetAllSpacesChannelIDs(event.BaseURL, event.EventType)
 
-	return util.Deduplicate(append(urlSpaceKeySubscriptionChannelIDs, urlPageIDSubscriptionChannelIDs...))
+	channelIDs := append(urlSpaceKeySubscriptionChannelIDs, urlPageIDSubscriptionChannelIDs...)
+	channelIDs = append(channelIDs, pageTreeChannelIDs...)
+	channelIDs = append(channelIDs, allSpacesChannelIDs...)
+	return service.FilterNotifiedChannels(util.Deduplicate(append(channelIDs, cqlChannelIDs...)), event)
 }
 
 func GetURLSubscriptionChannelIDs(urlSubscriptions serializer.StringArrayMap, eventType string) []string {
@@ -13,8 +13,11 @@ import (
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
 	"github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
 
 	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/service"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
 	"github.com/mattermost/mattermost-plugin-confluence/server/util"
 )
 
@@ -24,6 +27,8 @@ const (
 	botDescription = "Bot for confluence plugin."
 
 	documentationURL = "https://github.com/mattermost-community/mattermost-plugin-confluence#readme"
+
+	subscriptionMigrationMutexKey = "subscription_migration"
 )
 
 type Plugin struct {
@@ -36,6 +41,9 @@ type Plugin struct {
 
 	flowManager *FlowManager
 
+	subscriptionCleanUpJob *cluster.Job
+	digestDeliveryJob      *cluster.Job
+
 	// templates are loaded on startup
 	templates map[string]*template.Template
 }
@@ -55,6 +63,23 @@ func (p *Plugin) OnActivate() error {
 		return err
 	}
 
+	// The instance of the plugin settings is registered first, as the subscriptions are matched to the instances.
+	if err := p.migrateInstance(); err != nil {
+		return errors.Wrap(err, "failed to migrate the Confluence instance")
+	}
+
+	if err := p.migrateSubscriptions(); err != nil {
+		return errors.Wrap(err, "failed to migrate subscriptions")
+	}
+
+	if err := p.scheduleSubscriptionCleanUp(); err != nil {
+		return errors.Wrap(err, "failed to schedule the subscription clean up")
+	}
+
+	if err := p.scheduleDigestDelivery(); err != nil {
+		return errors.Wrap(err, "failed to schedule the digest delivery")
+	}
+
 	bundlePath, err := p.API.GetBundlePath()
 	if err != nil {
 		return errors.Wrap(err, "couldn't get bundle path")
@@ -84,6 +109,23 @@ func (p *Plugin) OnActivate() error {
 	return nil
 }
 
+func (p *Plugin) OnDeactivate() error {
+	if p.subscriptionCleanUpJob != nil {
+		if err := p.subscriptionCleanUpJob.Close(); err != nil {
+			config.Mattermost.LogWarn("Failed to close the subscription clean up job.", "Error", err.Error())
+		}
+	}
+	if p.digestDeliveryJob != nil {
+		if err := p.digestDeliveryJob.Close(); err != nil {
+			config.Mattermost.LogWarn("Failed to close the digest delivery job.", "Error", err.Error())
+		}
+	}
+	if err := service.FlushSuppressedEvents(); err != nil {
+		config.Mattermost.LogWarn("Failed to store the counts of suppressed events.", "Error", err.Error())
+	}
+	return nil
+}
+
 func (p *Plugin) OnConfigurationChange() error {
 	// If OnActivate has not been run yet.
 	if config.Mattermost == nil {
@@ -106,10 +148,47 @@ func (p *Plugin) OnConfigurationChange() error {
 		return err
 	}
 
+	previous, hasPrevious := config.LookupConfig()
 	config.SetConfig(&configuration)
+
+	// The secrets of the instances are encrypted with the encryption key, so they are encrypted again when it changes.
+	if hasPrevious && previous.EncryptionKey != configuration.EncryptionKey {
+		if err := store.ResealInstances(previous.EncryptionKey, configuration.EncryptionKey); err != nil {
+			config.Mattermost.LogError("Unable to encrypt the secrets of the Confluence instances with the new encryption key.", "Error", err.Error())
+		}
+	}
 	return nil
 }
 
+// migrateSubscriptions runs the subscription storage migrations under a cluster mutex,
+// so only one server of a cluster moves the legacy subscriptions.
+func (p *Plugin) migrateSubscriptions() error {
+	mutex, err := cluster.NewMutex(p.API, subscriptionMigrationMutexKey)
+	if err != nil {
+		return err
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if err = service.MigrateSubscriptions(); err != nil {
+		return err
+	}
+
+	return service.MigrateSubscriptionIndexKeys()
+}
+
+// migrateInstance moves the instance of the plugin settings into the instance registry under a cluster mutex.
+func (p *Plugin) migrateInstance() error {
+	mutex, err := cluster.NewMutex(p.API, instanceMigrationMutexKey)
+	if err != nil {
+		return err
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return migrateInstance()
+}
+
 func (p *Plugin) setUpBotUser() error {
 	botUserID, err := p.client.Bot.EnsureBot(&model.Bot{
 		Username:    botUserName,
@@ -161,6 +240,12 @@ func (p *Plugin) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	if instanceID, route := splitInstancePath(r.URL.Path); instanceID != "" {
+		r = withInstanceID(r, instanceID)
+		r.URL.Path = route
+		r.URL.RawPath = ""
+	}
+
 	p.Router.ServeHTTP(w, r)
 }
 
@@ -1,16 +1,28 @@
 package main
 
 import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"bou.ke/monkey"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
 	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/mattermost/mattermost/server/public/pluginapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/service"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
 )
 
 func baseMock() *plugintest.API {
@@ -35,6 +47,26 @@ func TestExecuteCommand(t *testing.T) {
 			ephemeralMessage: invalidCommand,
 			isAdmin:          true,
 		},
+		"instance list without instances": {
+			commandArgs:      &model.CommandArgs{Command: "/confluence instance list", UserId: "abcdabcdabcdabcd", ChannelId: "testtesttesttest"},
+			ephemeralMessage: noInstances,
+			isAdmin:          true,
+			patchAPICalls: func() {
+				monkey.Patch(store.LoadInstances, func() ([]*types.Instance, error) {
+					return []*types.Instance{}, nil
+				})
+			},
+		},
+		"instance remove without url": {
+			commandArgs:      &model.CommandArgs{Command: "/confluence instance remove", UserId: "abcdabcdabcdabcd", ChannelId: "testtesttesttest"},
+			ephemeralMessage: specifyInstanceURL,
+			isAdmin:          true,
+		},
+		"instance list by a non admin": {
+			commandArgs:      &model.CommandArgs{Command: "/confluence instance list", UserId: "abcdabcdabcdabcd", ChannelId: "testtesttesttest"},
+			ephemeralMessage: commandsOnlySystemAdmin,
+			isAdmin:          false,
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			defer monkey.UnpatchAll()
@@ -60,3 +92,571 @@ func TestExecuteCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitInstancePath(t *testing.T) {
+	instanceID := "https://confluence.example.com"
+	encodedInstanceID := util.Encode([]byte(instanceID))
+
+	for name, val := range map[string]struct {
+		route              string
+		expectedInstanceID string
+		expectedRoute      string
+	}{
+		"instance route": {
+			route:              "/api/v1/instance/" + encodedInstanceID + "/server/webhook",
+			expectedInstanceID: instanceID,
+			expectedRoute:      "/api/v1/server/webhook",
+		},
+		"instance route without the api prefix": {
+			route:              "/instance/" + encodedInstanceID + "/oauth2/connect",
+			expectedInstanceID: instanceID,
+			expectedRoute:      "/oauth2/connect",
+		},
+		"route without an instance": {
+			route:         "/api/v1/server/webhook",
+			expectedRoute: "/api/v1/server/webhook",
+		},
+		"invalid instance id": {
+			route:         "/api/v1/instance/%%%/server/webhook",
+			expectedRoute: "/api/v1/instance/%%%/server/webhook",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			id, route := splitInstancePath(val.route)
+			assert.Equal(t, val.expectedInstanceID, id)
+			assert.Equal(t, val.expectedRoute, route)
+		})
+	}
+}
+
+func TestCheckSubscriptionPermission(t *testing.T) {
+	for name, val := range map[string]struct {
+		policy        string
+		isAdmin       bool
+		channelMember *model.ChannelMember
+		teamMember    *model.TeamMember
+		denied        string
+	}{
+		"default policy lets system admins through": {
+			policy:  "default",
+			isAdmin: true,
+		},
+		"default policy stops other users": {
+			policy: "default",
+			denied: commandsOnlySystemAdmin,
+		},
+		"team admin policy lets team admins through": {
+			policy:        config.SubscriptionPermissionTeamAdmin,
+			channelMember: &model.ChannelMember{},
+			teamMember:    &model.TeamMember{SchemeAdmin: true},
+		},
+		"team admin policy lets team admins who are not channel members through": {
+			policy:     config.SubscriptionPermissionTeamAdmin,
+			teamMember: &model.TeamMember{SchemeAdmin: true},
+		},
+		"team admin policy stops channel admins": {
+			policy:        config.SubscriptionPermissionTeamAdmin,
+			channelMember: &model.ChannelMember{SchemeAdmin: true},
+			teamMember:    &model.TeamMember{},
+			denied:        subscriptionsOnlyTeamAdmin,
+		},
+		"channel admin policy lets channel admins through": {
+			policy:        config.SubscriptionPermissionChannelAdmin,
+			channelMember: &model.ChannelMember{SchemeAdmin: true},
+			teamMember:    &model.TeamMember{},
+		},
+		"channel admin policy lets team admins through": {
+			policy:        config.SubscriptionPermissionChannelAdmin,
+			channelMember: &model.ChannelMember{},
+			teamMember:    &model.TeamMember{SchemeAdmin: true},
+		},
+		"channel admin policy stops channel members": {
+			policy:        config.SubscriptionPermissionChannelAdmin,
+			channelMember: &model.ChannelMember{},
+			teamMember:    &model.TeamMember{},
+			denied:        subscriptionsOnlyChannelAdmin,
+		},
+		"channel admin policy stops non members": {
+			policy:     config.SubscriptionPermissionChannelAdmin,
+			teamMember: &model.TeamMember{},
+			denied:     subscriptionsOnlyChannelMember,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer monkey.UnpatchAll()
+			mockAPI := baseMock()
+			config.SetConfig(&config.Configuration{SubscriptionPermission: val.policy})
+			monkey.Patch(store.LoadInstances, func() ([]*types.Instance, error) {
+				return []*types.Instance{}, nil
+			})
+
+			roles := "system_user"
+			if val.isAdmin {
+				roles += " system_admin"
+			}
+			mockAPI.On("GetUser", "abcdabcdabcdabcd").Return(&model.User{Id: "abcdabcdabcdabcd", Roles: roles}, nil)
+			if val.channelMember != nil {
+				mockAPI.On("GetChannelMember", "testtesttesttest", "abcdabcdabcdabcd").Return(val.channelMember, nil)
+			} else {
+				mockAPI.On("GetChannelMember", "testtesttesttest", "abcdabcdabcdabcd").Return(nil, model.NewAppError("GetChannelMember", "not_found", nil, "", http.StatusNotFound))
+			}
+			mockAPI.On("GetChannel", "testtesttesttest").Return(&model.Channel{Id: "testtesttesttest", TeamId: "teamteamteamteam"}, nil)
+			mockAPI.On("GetTeamMember", "teamteamteamteam", "abcdabcdabcdabcd").Return(val.teamMember, nil)
+
+			denied, err := checkSubscriptionPermission("abcdabcdabcdabcd", "testtesttesttest")
+			assert.Nil(t, err)
+			assert.Equal(t, val.denied, denied)
+		})
+	}
+}
+
+type testTargetClient struct {
+	Client
+	statusCode int
+}
+
+func (c *testTargetClient) GetSpaceData(spaceKey string) (*SpaceResponse, error) {
+	if c.statusCode != http.StatusOK {
+		return nil, &service.ResponseError{StatusCode: c.statusCode, Message: "error"}
+	}
+	return &SpaceResponse{Key: spaceKey, Name: "Test Space"}, nil
+}
+
+func (c *testTargetClient) GetPageData(pageID int) (*PageResponse, error) {
+	if c.statusCode != http.StatusOK {
+		return nil, &service.ResponseError{StatusCode: c.statusCode, Message: "error"}
+	}
+	return &PageResponse{ID: strconv.Itoa(pageID), Title: "Test Page"}, nil
+}
+
+func (c *testTargetClient) GetPageIDByTitle(spaceKey, title string) (string, error) {
+	if c.statusCode != http.StatusOK {
+		return "", &service.ResponseError{StatusCode: c.statusCode, Message: "error"}
+	}
+	return "1234", nil
+}
+
+func TestResolveSubscriptionTarget(t *testing.T) {
+	spaceSubscription := serializer.SpaceSubscription{
+		SpaceKey: "TS",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "space",
+			BaseURL:   "https://test.com",
+			ChannelID: "testtesttesttest",
+			Events:    []string{serializer.PageCreatedEvent},
+			Type:      serializer.SubscriptionTypeSpace,
+		},
+	}
+	pageSubscription := serializer.PageSubscription{
+		PageID: "1234",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "page",
+			BaseURL:   "https://test.com",
+			ChannelID: "testtesttesttest",
+			Events:    []string{serializer.PageUpdatedEvent},
+			Type:      serializer.SubscriptionTypePage,
+		},
+	}
+	resolvedSpace := spaceSubscription
+	resolvedSpace.SpaceName = "Test Space"
+	resolvedPage := pageSubscription
+	resolvedPage.PageTitle = "Test Page"
+	withPage := func(page string) serializer.PageSubscription {
+		subscription := pageSubscription
+		subscription.PageID = page
+		return subscription
+	}
+	spaceURLSubscription := spaceSubscription
+	spaceURLSubscription.SpaceKey = "https://test.com/display/TS"
+
+	for name, val := range map[string]struct {
+		subscription       serializer.Subscription
+		connected          bool
+		confluenceStatus   int
+		expected           serializer.Subscription
+		expectedStatusCode int
+		expectError        bool
+	}{
+		"space is resolved": {
+			subscription:       spaceSubscription,
+			connected:          true,
+			confluenceStatus:   http.StatusOK,
+			expected:           resolvedSpace,
+			expectedStatusCode: http.StatusOK,
+		},
+		"page is resolved": {
+			subscription:       pageSubscription,
+			connected:          true,
+			confluenceStatus:   http.StatusOK,
+			expected:           resolvedPage,
+			expectedStatusCode: http.StatusOK,
+		},
+		"missing space is rejected": {
+			subscription:       spaceSubscription,
+			connected:          true,
+			confluenceStatus:   http.StatusNotFound,
+			expected:           spaceSubscription,
+			expectedStatusCode: http.StatusBadRequest,
+			expectError:        true,
+		},
+		"forbidden page is rejected": {
+			subscription:       pageSubscription,
+			connected:          true,
+			confluenceStatus:   http.StatusForbidden,
+			expected:           pageSubscription,
+			expectedStatusCode: http.StatusBadRequest,
+			expectError:        true,
+		},
+		"confluence failure keeps the subscription": {
+			subscription:       pageSubscription,
+			connected:          true,
+			confluenceStatus:   http.StatusInternalServerError,
+			expected:           pageSubscription,
+			expectedStatusCode: http.StatusOK,
+		},
+		"check is skipped for users without a connection": {
+			subscription:       spaceSubscription,
+			expected:           spaceSubscription,
+			expectedStatusCode: http.StatusOK,
+		},
+		"space name is dropped for users without a connection": {
+			subscription:       resolvedSpace,
+			expected:           spaceSubscription,
+			expectedStatusCode: http.StatusOK,
+		},
+		"page title is dropped when confluence fails": {
+			subscription:       resolvedPage,
+			connected:          true,
+			confluenceStatus:   http.StatusInternalServerError,
+			expected:           pageSubscription,
+			expectedStatusCode: http.StatusOK,
+		},
+		"space url is resolved": {
+			subscription:       spaceURLSubscription,
+			connected:          true,
+			confluenceStatus:   http.StatusOK,
+			expected:           resolvedSpace,
+			expectedStatusCode: http.StatusOK,
+		},
+		"page url is resolved": {
+			subscription:       withPage("https://test.com/spaces/TS/pages/1234/Test+Page"),
+			connected:          true,
+			confluenceStatus:   http.StatusOK,
+			expected:           resolvedPage,
+			expectedStatusCode: http.StatusOK,
+		},
+		"tiny link is resolved without a connection": {
+			subscription:       withPage("https://test.com/x/0gQ"),
+			expected:           pageSubscription,
+			expectedStatusCode: http.StatusOK,
+		},
+		"page title url is found": {
+			subscription:       withPage("https://test.com/display/TS/Test+Page"),
+			connected:          true,
+			confluenceStatus:   http.StatusOK,
+			expected:           resolvedPage,
+			expectedStatusCode: http.StatusOK,
+		},
+		"missing page title is rejected": {
+			subscription:       withPage("https://test.com/display/TS/Test+Page"),
+			connected:          true,
+			confluenceStatus:   http.StatusNotFound,
+			expected:           withPage("https://test.com/display/TS/Test+Page"),
+			expectedStatusCode: http.StatusBadRequest,
+			expectError:        true,
+		},
+		"page title url needs a connection": {
+			subscription:       withPage("https://test.com/display/TS/Test+Page"),
+			expected:           withPage("https://test.com/display/TS/Test+Page"),
+			expectedStatusCode: http.StatusBadRequest,
+			expectError:        true,
+		},
+		"page id must be a number": {
+			subscription:       withPage("Test Page"),
+			expected:           withPage("Test Page"),
+			expectedStatusCode: http.StatusBadRequest,
+			expectError:        true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer monkey.UnpatchAll()
+			mockAPI := baseMock()
+			mockAPI.On("LogWarn", mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return()
+
+			p := &Plugin{}
+			monkey.Patch(store.LoadConnection, func(instanceID, mattermostUserID string) (*types.Connection, error) {
+				if !val.connected {
+					return nil, store.ErrNotFound
+				}
+				return &types.Connection{ConfluenceUser: types.ConfluenceUser{AccountID: "account"}}, nil
+			})
+			monkey.Patch(store.LoadInstance, func(instanceURL string) (*types.Instance, error) {
+				return &types.Instance{URL: instanceURL}, nil
+			})
+			monkey.PatchInstanceMethod(reflect.TypeOf(p), "GetServerClient", func(_ *Plugin, instanceID string, connection *types.Connection) (Client, error) {
+				return &testTargetClient{statusCode: val.confluenceStatus}, nil
+			})
+
+			subscription, statusCode, err := p.resolveSubscriptionTarget("abcdabcdabcdabcd", val.subscription)
+			assert.Equal(t, val.expected, subscription)
+			assert.Equal(t, val.expectedStatusCode, statusCode)
+			assert.Equal(t, val.expectError, err != nil)
+		})
+	}
+}
+
+func TestBuildSubscription(t *testing.T) {
+	context := &model.CommandArgs{UserId: "user-id", ChannelId: "channel-id"}
+	allEvents := serializer.SupportedEvents()
+
+	for name, val := range map[string]struct {
+		subscriptionType string
+		target           string
+		flags            map[string]string
+		expected         serializer.Subscription
+		expectedErr      string
+	}{
+		"space with events": {
+			subscriptionType: "space",
+			target:           "ENG",
+			flags:            map[string]string{"name": "Eng docs", "events": "page_created, comment_created", "url": "https://confluence.example.com/"},
+			expected: serializer.SpaceSubscription{
+				SpaceKey: "ENG",
+				BaseSubscription: serializer.BaseSubscription{
+					Alias:     "Eng docs",
+					BaseURL:   "https://confluence.example.com",
+					Events:    []string{"page_created", "comment_created"},
+					ChannelID: "channel-id",
+					Type:      serializer.SubscriptionTypeSpace,
+				},
+			},
+		},
+		"page url with all events": {
+			subscriptionType: "page",
+			target:           "https://confluence.example.com/wiki/pages/viewpage.action?pageId=12345",
+			flags:            map[string]string{"name": "Runbook", "ignore-minor-edits": "true"},
+			expected: serializer.PageSubscription{
+				PageID: "https://confluence.example.com/wiki/pages/viewpage.action?pageId=12345",
+				BaseSubscription: serializer.BaseSubscription{
+					Alias:            "Runbook",
+					BaseURL:          "https://confluence.example.com/wiki",
+					Events:           allEvents,
+					ChannelID:        "channel-id",
+					Type:             serializer.SubscriptionTypePage,
+					IgnoreMinorEdits: true,
+				},
+			},
+		},
+		"page tree url": {
+			subscriptionType: "page-tree",
+			target:           "https://confluence.example.com/spaces/ENG/pages/12345/Runbook",
+			flags:            map[string]string{"name": "Runbook", "events": "page_updated"},
+			expected: serializer.PageTreeSubscription{
+				PageID: "https://confluence.example.com/spaces/ENG/pages/12345/Runbook",
+				BaseSubscription: serializer.BaseSubscription{
+					Alias:     "Runbook",
+					BaseURL:   "https://confluence.example.com",
+					Events:    []string{"page_updated"},
+					ChannelID: "channel-id",
+					Type:      serializer.SubscriptionTypePageTree,
+				},
+			},
+		},
+		"all spaces with exclusions": {
+			subscriptionType: "all-spaces",
+			flags:            map[string]string{"name": "New pages", "events": "page_created", "url": "https://confluence.example.com", "exclude-spaces": "SANDBOX, TEST", "exclude-personal-spaces": "true"},
+			expected: serializer.AllSpacesSubscription{
+				ExcludeSpaces:         []string{"SANDBOX", "TEST"},
+				ExcludePersonalSpaces: true,
+				BaseSubscription: serializer.BaseSubscription{
+					Alias:     "New pages",
+					BaseURL:   "https://confluence.example.com",
+					Events:    []string{"page_created"},
+					ChannelID: "channel-id",
+					Type:      serializer.SubscriptionTypeAllSpaces,
+				},
+			},
+		},
+		"unsupported event": {
+			subscriptionType: "space",
+			target:           "ENG",
+			flags:            map[string]string{"name": "Eng docs", "events": "page_moved", "url": "https://confluence.example.com"},
+			expectedErr:      "event **page_moved** is not supported",
+		},
+		"space flag on a page": {
+			subscriptionType: "page",
+			target:           "12345",
+			flags:            map[string]string{"name": "Runbook", "content-type": "pages", "url": "https://confluence.example.com"},
+			expectedErr:      "flag --content-type is not supported for page subscriptions",
+		},
+		"url of no space or page": {
+			subscriptionType: "page",
+			target:           "https://confluence.example.com/about",
+			flags:            map[string]string{"name": "Runbook"},
+			expectedErr:      "is not a Confluence space or page URL",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			subscription, err := buildSubscription(context, val.subscriptionType, val.target, val.flags)
+			if val.expectedErr != "" {
+				assert.ErrorContains(t, err, val.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "user-id", subscription.GetAuditInfo().CreatedBy)
+			assert.Equal(t, val.expected, subscription.WithAuditInfo(serializer.AuditInfo{}))
+		})
+	}
+}
+
+func TestGetNotificationPostContentChanges(t *testing.T) {
+	config.SetConfig(&config.Configuration{PageDiffMaxLines: 1})
+	content := &PageResponse{ID: "1234", Title: "Release Notes", Version: Version{Number: 3}, Links: Links{Self: "display/DOC/Release+Notes"}, Body: Body{View: View{Value: "\nThe whole body"}}}
+	for name, val := range map[string]struct {
+		eventType string
+		event     ConfluenceServerEvent
+	}{
+		"page update": {
+			eventType: serializer.PageUpdatedEvent,
+			event:     ConfluenceServerEvent{Page: content},
+		},
+		"blog post update": {
+			eventType: serializer.BlogUpdatedEvent,
+			event:     ConfluenceServerEvent{Blog: content},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			event := val.event
+			post := event.GetNotificationPost(val.eventType, "https://test.com", "botuserid")
+			assert.Empty(t, post.Attachments(), "the body is not shown as the changes")
+			assert.Contains(t, post.Message, "[View changes](https://test.com/pages/diffpagesbyversion.action?pageId=1234&selectedPageVersions=2&selectedPageVersions=3)")
+
+			event.ContentDiff = &util.LineDiff{Added: []string{"New section"}, Removed: []string{"Old section"}}
+			post = event.GetNotificationPost(val.eventType, "https://test.com", "botuserid")
+			attachments := post.Attachments()
+			assert.Len(t, attachments, 1)
+			assert.Contains(t, attachments[0].Text, "1 line added, 1 line removed")
+			assert.Contains(t, attachments[0].Text, "+ New section\n… 1 line not shown\n")
+			assert.NotContains(t, attachments[0].Text, "The whole body")
+		})
+	}
+}
+
+func TestGetTemplateDataExcerpt(t *testing.T) {
+	content := &PageResponse{ID: "1234", Title: "Release Notes", Body: Body{View: View{Value: "\nThe release brings faster search."}}}
+	data := ConfluenceServerEvent{Page: content}.GetTemplateData(serializer.PageUpdatedEvent)
+	assert.Equal(t, "The release brings faster search.", data.Excerpt)
+
+	content.Body.View.Value = strings.Repeat("é", 600)
+	data = ConfluenceServerEvent{Page: content}.GetTemplateData(serializer.PageUpdatedEvent)
+	assert.Equal(t, 500, utf8.RuneCountInString(data.Excerpt), "the whole body is cut to an excerpt")
+	assert.True(t, strings.HasSuffix(data.Excerpt, "…"))
+}
+
+func TestCleanUpSubscriptionsWithoutCreator(t *testing.T) {
+	defer monkey.UnpatchAll()
+	mockAPI := baseMock()
+	mockAPI.On("LogInfo", mock.AnythingOfType("string"), mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	mockAPI.On("GetUsers", mock.MatchedBy(func(options *model.UserGetOptions) bool {
+		return options.Role == model.SystemAdminRoleId
+	})).Return([]*model.User{{Id: "adminadminadmin1"}, {Id: "botbotbotbotbot1", IsBot: true}}, nil)
+	mockAPI.On("GetDirectChannel", "botuserbotuser01", "adminadminadmin1").Return(&model.Channel{Id: "directchannel001"}, nil)
+	var posted []*model.Post
+	mockAPI.On("CreatePost", mock.AnythingOfType("*model.Post")).Run(func(args mock.Arguments) {
+		posted = append(posted, args.Get(0).(*model.Post).Clone())
+	}).Return(&model.Post{}, nil)
+
+	monkey.Patch(service.CleanUpSubscriptions, func() ([]service.SubscriptionCleanUp, error) {
+		return []service.SubscriptionCleanUp{{
+			Subscription: serializer.SpaceSubscription{SpaceKey: "TS", BaseSubscription: serializer.BaseSubscription{Alias: "Docs", ChannelID: "testtesttesttest"}},
+			ChannelName:  "town-square",
+			Removed:      true,
+		}}, nil
+	})
+
+	p := &Plugin{BotUserID: "botuserbotuser01"}
+	p.client = pluginapi.NewClient(mockAPI, nil)
+	p.cleanUpSubscriptions()
+
+	mockAPI.AssertNumberOfCalls(t, "GetDirectChannel", 1)
+	assert.Len(t, posted, 1)
+	assert.Equal(t, "directchannel001", posted[0].ChannelId)
+	assert.Equal(t, subscriptionCleanUpUnownedHeader+"\n* **Docs** was removed, as its channel `town-square` was deleted.", posted[0].Message)
+}
+
+func TestApplySubscriptionPreset(t *testing.T) {
+	defer monkey.UnpatchAll()
+	monkey.Patch(store.LoadPreset, func(name string) (*types.Preset, error) {
+		if name != "docs watch" {
+			return nil, store.ErrNotFound
+		}
+		return &types.Preset{Name: "Docs Watch", Events: []string{serializer.PageCreatedEvent}, IgnoreMinorEdits: true, ContentType: "pages"}, nil
+	})
+
+	subscription := serializer.SpaceSubscription{
+		SpaceKey: "DOCS",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:  "docs",
+			Events: serializer.SupportedEvents(),
+			Preset: "docs watch",
+		},
+	}
+	applied, statusCode, err := applySubscriptionPreset(subscription)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+	space := applied.(serializer.SpaceSubscription)
+	assert.Equal(t, "Docs Watch", space.Preset)
+	assert.Equal(t, []string{serializer.PageCreatedEvent}, space.Events)
+	assert.True(t, space.IgnoreMinorEdits)
+	assert.Equal(t, "pages", space.ContentType)
+
+	subscription.Preset = "missing"
+	_, statusCode, err = applySubscriptionPreset(subscription)
+	assert.EqualError(t, err, "preset **missing** not found")
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+
+	subscription.Preset = ""
+	applied, _, err = applySubscriptionPreset(subscription)
+	assert.NoError(t, err)
+	assert.Equal(t, subscription, applied)
+}
+
+func TestInstanceSecretsEncryptionKeyChange(t *testing.T) {
+	mockAPI := baseMock()
+	kv := map[string][]byte{}
+	mockAPI.On("KVGet", mock.AnythingOfType("string")).Return(func(key string) ([]byte, *model.AppError) {
+		return kv[key], nil
+	})
+	mockAPI.On("KVCompareAndSet", mock.AnythingOfType("string"), mock.Anything, mock.Anything).Return(func(key string, oldValue, newValue []byte) (bool, *model.AppError) {
+		kv[key] = newValue
+		return true, nil
+	})
+	mockAPI.On("LogError", mock.AnythingOfType("string"), mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	mockAPI.On("LogWarn", mock.AnythingOfType("string"), mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	previousKey, newKey := "0123456789abcdef0123456789abcdef", "fedcba9876543210fedcba9876543210"
+	config.SetConfig(&config.Configuration{EncryptionKey: previousKey})
+	assert.NoError(t, store.StoreInstance(&types.Instance{URL: "https://first.test.com", WebhookSecret: "first secret"}))
+	assert.NoError(t, store.StoreInstance(&types.Instance{URL: "https://second.test.com", WebhookSecret: "second secret"}))
+	assert.NotContains(t, string(kv["confluence_instances"]), "first secret")
+
+	// An instance whose secrets can not be decrypted is skipped, and the other ones are still loaded.
+	registry := map[string]*types.Instance{}
+	assert.NoError(t, json.Unmarshal(kv["confluence_instances"], &registry))
+	registry["https://second.test.com"].WebhookSecret = "encrypted:corrupted"
+	kv["confluence_instances"], _ = json.Marshal(registry)
+	instances, err := store.LoadInstances()
+	assert.NoError(t, err)
+	assert.Len(t, instances, 1)
+	assert.Equal(t, "first secret", instances[0].WebhookSecret)
+
+	// The unreadable instance is kept when the registry is written.
+	assert.NoError(t, store.StoreInstance(&types.Instance{URL: "https://third.test.com"}))
+	assert.Contains(t, string(kv["confluence_instances"]), "encrypted:corrupted")
+
+	assert.NoError(t, store.ResealInstances(previousKey, newKey))
+	config.SetConfig(&config.Configuration{EncryptionKey: newKey})
+	instance, err := store.LoadInstance("https://first.test.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "first secret", instance.WebhookSecret)
+}
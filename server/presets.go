@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/service"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+const (
+	flagPreset = "preset"
+	flagApply  = "apply"
+
+	presetUsage         = "Please specify a preset name and its events, like `/confluence preset %s \"<name>\" --events page_created,page_updated`."
+	specifyPresetName   = "Please specify a preset name."
+	presetAddSuccess    = "Preset **%s** has been added. Use it with `/confluence subscribe <space|page|page-tree|cql> <target> --name \"<name>\" --preset \"%s\"`."
+	presetEditSuccess   = "Preset **%s** has been updated."
+	presetApplySuccess  = "Preset **%s** has been updated, and applied to %d subscriptions created from it."
+	presetRemoveSuccess = "Preset **%s** has been removed. The subscriptions created from it keep their settings."
+	presetNotFound      = "preset **%s** not found"
+	presetAlreadyExists = "Preset **%s** already exists, use `/confluence preset edit` to change it."
+	noPresets           = "No subscription presets are defined. System administrators can add one with `/confluence preset add`."
+	presetWithSettings  = "flag --%s can not be combined with --preset, the preset defines the events and filters of the subscription"
+)
+
+// presetFlags are the flags of `/confluence preset add` and `edit` setting the preset, named like the flags of `/confluence subscribe`.
+var presetFlags = []string{flagEvents, flagIgnoreMinorEdits, flagExcludeAuthors, flagIncludeAuthors, flagContentType, flagIncludeLabels, flagExcludeLabels}
+
+var getPresets = &Endpoint{
+	Path:    "/presets",
+	Method:  http.MethodGet,
+	Execute: handleGetPresets,
+}
+
+func addPreset(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	return storePreset(context, "add", args...)
+}
+
+func editPreset(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	return storePreset(context, "edit", args...)
+}
+
+// storePreset adds a preset, or replaces the settings of an existing one for `edit`.
+// With `--apply`, the new settings of an edited preset are also given to the subscriptions created from it.
+func storePreset(context *model.CommandArgs, action string, args ...string) *model.CommandResponse {
+	if !util.IsSystemAdmin(context.UserId) {
+		postCommandResponse(context, commandsOnlySystemAdmin)
+		return &model.CommandResponse{}
+	}
+
+	replace := action == "edit"
+	positional, flags, err := util.ParseFlags(args, flagIgnoreMinorEdits, flagApply)
+	if err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+	for flag := range flags {
+		if !slices.Contains(presetFlags, flag) && (!replace || flag != flagApply) {
+			postCommandResponse(context, fmt.Sprintf("flag --%s is not supported", flag))
+			return &model.CommandResponse{}
+		}
+	}
+	if len(positional) == 0 || flags[flagEvents] == "" {
+		postCommandResponse(context, fmt.Sprintf(presetUsage, action))
+		return &model.CommandResponse{}
+	}
+
+	preset := &types.Preset{
+		Name:             strings.Join(positional, " "),
+		Events:           splitList(flags[flagEvents]),
+		IgnoreMinorEdits: flags[flagIgnoreMinorEdits] != "",
+		ExcludeAuthors:   splitList(flags[flagExcludeAuthors]),
+		IncludeAuthors:   splitList(flags[flagIncludeAuthors]),
+		ContentType:      flags[flagContentType],
+		IncludeLabels:    splitList(flags[flagIncludeLabels]),
+		ExcludeLabels:    splitList(flags[flagExcludeLabels]),
+	}
+	if err = serializer.ValidatePreset(preset); err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+
+	if err = store.StorePreset(preset, replace); err != nil {
+		switch errors.Cause(err) {
+		case store.ErrNotFound:
+			postCommandResponse(context, fmt.Sprintf(presetNotFound, preset.Name))
+		case store.ErrAlreadyExists:
+			postCommandResponse(context, fmt.Sprintf(presetAlreadyExists, preset.Name))
+		default:
+			postCommandResponse(context, errorExecutingCommand)
+		}
+		return &model.CommandResponse{}
+	}
+
+	if !replace {
+		postCommandResponse(context, fmt.Sprintf(presetAddSuccess, preset.Name, preset.Name))
+		return &model.CommandResponse{}
+	}
+	if flags[flagApply] == "" {
+		postCommandResponse(context, fmt.Sprintf(presetEditSuccess, preset.Name))
+		return &model.CommandResponse{}
+	}
+
+	updated, err := service.ApplyPresetToSubscriptions(preset, context.UserId)
+	if err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+	postCommandResponse(context, fmt.Sprintf(presetApplySuccess, preset.Name, updated))
+	return &model.CommandResponse{}
+}
+
+func listPresets(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	presets, err := store.LoadPresets()
+	if err != nil {
+		postCommandResponse(context, errorExecutingCommand)
+		return &model.CommandResponse{}
+	}
+	if len(presets) == 0 {
+		postCommandResponse(context, noPresets)
+		return &model.CommandResponse{}
+	}
+
+	postCommandResponse(context, serializer.FormattedPresetList(presets))
+	return &model.CommandResponse{}
+}
+
+func removePreset(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	if !util.IsSystemAdmin(context.UserId) {
+		postCommandResponse(context, commandsOnlySystemAdmin)
+		return &model.CommandResponse{}
+	}
+	if len(args) == 0 {
+		postCommandResponse(context, specifyPresetName)
+		return &model.CommandResponse{}
+	}
+
+	name := strings.Join(args, " ")
+	if err := store.DeletePreset(name); err != nil {
+		if errors.Cause(err) == store.ErrNotFound {
+			postCommandResponse(context, fmt.Sprintf(presetNotFound, name))
+			return &model.CommandResponse{}
+		}
+		postCommandResponse(context, errorExecutingCommand)
+		return &model.CommandResponse{}
+	}
+	postCommandResponse(context, fmt.Sprintf(presetRemoveSuccess, name))
+	return &model.CommandResponse{}
+}
+
+// applySubscribePreset returns the subscription with the settings of the preset given with `--preset`,
+// which can not be combined with the flags the preset sets.
+func applySubscribePreset(subscription serializer.Subscription, flags map[string]string) (serializer.Subscription, error) {
+	for _, flag := range presetFlags {
+		if _, ok := flags[flag]; ok {
+			return nil, errors.Errorf(presetWithSettings, flag)
+		}
+	}
+
+	preset, err := store.LoadPreset(flags[flagPreset])
+	if err != nil {
+		if errors.Cause(err) == store.ErrNotFound {
+			return nil, errors.Errorf(presetNotFound, flags[flagPreset])
+		}
+		return nil, err
+	}
+	return serializer.ApplyPreset(subscription, preset), nil
+}
+
+// applySubscriptionPreset returns the subscription with the settings of the preset it refers to, if any,
+// so a subscription saved from a preset has its settings whatever the client sent along with it.
+// A missing preset is an error, returned with the status code of the response.
+func applySubscriptionPreset(subscription serializer.Subscription) (serializer.Subscription, int, error) {
+	if subscription.GetPreset() == "" {
+		return subscription, http.StatusOK, nil
+	}
+	preset, err := store.LoadPreset(subscription.GetPreset())
+	if err != nil {
+		if errors.Cause(err) == store.ErrNotFound {
+			return subscription, http.StatusBadRequest, errors.Errorf(presetNotFound, subscription.GetPreset())
+		}
+		return subscription, http.StatusInternalServerError, err
+	}
+	return serializer.ApplyPreset(subscription, preset), http.StatusOK, nil
+}
+
+// handleGetPresets returns the subscription presets, for the subscription modal.
+func handleGetPresets(w http.ResponseWriter, r *http.Request, _ *Plugin) {
+	if r.Header.Get(config.HeaderMattermostUserID) == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	presets, err := store.LoadPresets()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	b, _ := json.Marshal(presets)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
@@ -20,11 +20,15 @@ var saveChannelSubscription = &Endpoint{
 	Execute: handleSaveSubscription,
 }
 
-func handleSaveSubscription(w http.ResponseWriter, r *http.Request, _ *Plugin) {
+func handleSaveSubscription(w http.ResponseWriter, r *http.Request, p *Plugin) {
 	params := mux.Vars(r)
 	channelID := params["channelID"]
 	subscriptionType := params["type"]
 	userID := r.Header.Get(config.HeaderMattermostUserID)
+	if !checkSubscriptionPermissionHTTP(w, userID, channelID) {
+		return
+	}
+
 	var subscription serializer.Subscription
 	var err error
 	if subscriptionType == serializer.SubscriptionTypeSpace {
@@ -41,7 +45,48 @@ func handleSaveSubscription(w http.ResponseWriter, r *http.Request, _ *Plugin) {
 			http.Error(w, "Could not decode request body", http.StatusBadRequest)
 			return
 		}
+	} else if subscriptionType == serializer.SubscriptionTypePageTree {
+		subscription, err = serializer.PageTreeSubscriptionFromJSON(r.Body)
+		if err != nil {
+			config.Mattermost.LogError("Error decoding request body.", "Error", err.Error())
+			http.Error(w, "Could not decode request body", http.StatusBadRequest)
+			return
+		}
+	} else if subscriptionType == serializer.SubscriptionTypeCQL {
+		subscription, err = serializer.CQLSubscriptionFromJSON(r.Body)
+		if err != nil {
+			config.Mattermost.LogError("Error decoding request body.", "Error", err.Error())
+			http.Error(w, "Could not decode request body", http.StatusBadRequest)
+			return
+		}
+	} else if subscriptionType == serializer.SubscriptionTypeAllSpaces {
+		subscription, err = serializer.AllSpacesSubscriptionFromJSON(r.Body)
+		if err != nil {
+			config.Mattermost.LogError("Error decoding request body.", "Error", err.Error())
+			http.Error(w, "Could not decode request body", http.StatusBadRequest)
+			return
+		}
 	}
+	if subscription == nil {
+		http.Error(w, "Invalid subscription type", http.StatusBadRequest)
+		return
+	}
+	if subscription.GetChannelID() != channelID {
+		http.Error(w, "The channel of the subscription does not match the channel of the request", http.StatusBadRequest)
+		return
+	}
+	subscription, targetStatus, rErr := p.resolveSubscriptionTarget(userID, subscription)
+	if rErr != nil {
+		http.Error(w, rErr.Error(), targetStatus)
+		return
+	}
+	subscription, presetStatus, pErr := applySubscriptionPreset(subscription)
+	if pErr != nil {
+		http.Error(w, pErr.Error(), presetStatus)
+		return
+	}
+
+	subscription = subscription.WithAuditInfo(serializer.NewAuditInfo(userID)).WithPauseState(serializer.PauseState{})
 	if statusCode, sErr := service.SaveSubscription(subscription); sErr != nil {
 		config.Mattermost.LogError(sErr.Error())
 		http.Error(w, sErr.Error(), statusCode)
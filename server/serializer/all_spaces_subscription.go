@@ -0,0 +1,161 @@
+package serializer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	url2 "net/url"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+)
+
+// personalSpacePrefix starts the keys of the personal spaces of Confluence users, like `~jsmith`.
+const personalSpacePrefix = "~"
+
+// AllSpacesSubscription follows every space of the instance, but the excluded ones.
+type AllSpacesSubscription struct {
+	ExcludeSpaces []string `json:"excludeSpaces,omitempty"`
+	// ExcludePersonalSpaces skips the personal spaces of users, whose keys start with `~`.
+	ExcludePersonalSpaces bool `json:"excludePersonalSpaces,omitempty"`
+	BaseSubscription
+}
+
+func (as AllSpacesSubscription) Add(s *Subscriptions) {
+	s.EnsureDefaults()
+
+	if _, valid := s.ByChannelID[as.ChannelID]; !valid {
+		s.ByChannelID[as.ChannelID] = make(StringSubscription)
+	}
+	s.ByChannelID[as.ChannelID][as.Alias] = as
+	key := store.GetURLCombinationKey(as.BaseURL)
+	if _, ok := s.ByURLAllSpaces[key]; !ok {
+		s.ByURLAllSpaces[key] = make(map[string][]string)
+	}
+	s.ByURLAllSpaces[key][as.ChannelID] = as.Events
+}
+
+func (as AllSpacesSubscription) Remove(s *Subscriptions) {
+	delete(s.ByChannelID[as.ChannelID], as.Alias)
+	key := store.GetURLCombinationKey(as.BaseURL)
+	delete(s.ByURLAllSpaces[key], as.ChannelID)
+}
+
+func (as AllSpacesSubscription) Edit(s *Subscriptions) {
+	as.Remove(s)
+	as.Add(s)
+}
+
+func (as AllSpacesSubscription) Name() string {
+	return SubscriptionTypeAllSpaces
+}
+
+func (as AllSpacesSubscription) GetAlias() string {
+	return as.Alias
+}
+
+func (as AllSpacesSubscription) GetChannelID() string {
+	return as.ChannelID
+}
+
+func (as AllSpacesSubscription) GetBaseURL() string {
+	return as.BaseURL
+}
+
+func (as AllSpacesSubscription) WithChannelID(channelID string) Subscription {
+	as.ChannelID = channelID
+	return as
+}
+
+func (as AllSpacesSubscription) WithAuditInfo(auditInfo AuditInfo) Subscription {
+	as.AuditInfo = auditInfo
+	return as
+}
+
+func (as AllSpacesSubscription) WithPauseState(pauseState PauseState) Subscription {
+	as.PauseState = pauseState
+	return as
+}
+
+func (as AllSpacesSubscription) WithTemplates(templates map[string]string) Subscription {
+	as.Templates = templates
+	return as
+}
+
+func (as AllSpacesSubscription) GetFormattedSubscription() string {
+	return fmt.Sprintf("\n|%s|%s|%s|%s|%s|%s|%s|", as.Alias, as.BaseURL, orDash(as.getFormattedExclusions()), as.getFormattedEvents(), as.getFormattedStatus(), as.getFormattedCreated(), as.getFormattedUpdated())
+}
+
+func (as AllSpacesSubscription) getFormattedExclusions() string {
+	exclusions := slices.Clone(as.ExcludeSpaces)
+	if as.ExcludePersonalSpaces {
+		exclusions = append(exclusions, "personal spaces")
+	}
+	return strings.Join(exclusions, ", ")
+}
+
+// MatchesSpace reports whether the space with the key is not excluded from the subscription.
+func (as AllSpacesSubscription) MatchesSpace(spaceKey string) bool {
+	if as.ExcludePersonalSpaces && strings.HasPrefix(spaceKey, personalSpacePrefix) {
+		return false
+	}
+	for _, excluded := range as.ExcludeSpaces {
+		if strings.EqualFold(strings.TrimSpace(excluded), spaceKey) {
+			return false
+		}
+	}
+	return true
+}
+
+func (as AllSpacesSubscription) IsValid() error {
+	if as.Alias == "" {
+		return errors.New("subscription name can not be empty")
+	}
+	if as.BaseURL == "" {
+		return errors.New("base url can not be empty")
+	}
+	if _, err := url2.Parse(as.BaseURL); err != nil {
+		return errors.New("enter a valid url")
+	}
+	if as.ChannelID == "" {
+		return errors.New("channel id can not be empty")
+	}
+	if err := as.validateAuthors(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func AllSpacesSubscriptionFromJSON(data io.Reader) (AllSpacesSubscription, error) {
+	var as AllSpacesSubscription
+	err := json.NewDecoder(data).Decode(&as)
+	return as, err
+}
+
+func (as AllSpacesSubscription) ValidateSubscription(subs *Subscriptions) error {
+	if err := as.IsValid(); err != nil {
+		return err
+	}
+	if channelSubscriptions, valid := subs.ByChannelID[as.ChannelID]; valid {
+		if _, ok := channelSubscriptions[as.Alias]; ok {
+			return errors.New(aliasAlreadyExist)
+		}
+	}
+	key := store.GetURLCombinationKey(as.BaseURL)
+	if urlAllSpacesSubscriptions, valid := subs.ByURLAllSpaces[key]; valid {
+		if _, ok := urlAllSpacesSubscriptions[as.ChannelID]; ok {
+			return errors.New(urlAllSpacesAlreadyExist)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the event is about a space of the instance that is not excluded, and passes the subscription filters.
+func (as AllSpacesSubscription) Matches(event NotificationEvent) bool {
+	if store.GetURLCombinationKey(as.BaseURL) != store.GetURLCombinationKey(event.BaseURL) {
+		return false
+	}
+	return as.MatchesSpace(event.SpaceKey) && as.matchesEvent(event)
+}
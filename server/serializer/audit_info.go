@@ -0,0 +1,67 @@
+package serializer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+)
+
+const auditDateFormat = "Jan 2, 2006"
+
+// AuditInfo records who created and last updated a subscription, and when.
+// The times are in milliseconds since the epoch, like Mattermost timestamps.
+type AuditInfo struct {
+	CreatedBy string `json:"createdBy,omitempty"`
+	CreatedAt int64  `json:"createdAt,omitempty"`
+	UpdatedBy string `json:"updatedBy,omitempty"`
+	UpdatedAt int64  `json:"updatedAt,omitempty"`
+}
+
+// NewAuditInfo returns the audit info of a subscription created now by the given user.
+func NewAuditInfo(userID string) AuditInfo {
+	now := model.GetMillis()
+	return AuditInfo{
+		CreatedBy: userID,
+		CreatedAt: now,
+		UpdatedBy: userID,
+		UpdatedAt: now,
+	}
+}
+
+// Updated returns the audit info of a subscription updated now by the given user, keeping who created it.
+func (a AuditInfo) Updated(userID string) AuditInfo {
+	a.UpdatedBy = userID
+	a.UpdatedAt = model.GetMillis()
+	return a
+}
+
+func (a AuditInfo) GetAuditInfo() AuditInfo {
+	return a
+}
+
+func (a AuditInfo) getFormattedCreated() string {
+	return formatAuditEntry(a.CreatedBy, a.CreatedAt)
+}
+
+func (a AuditInfo) getFormattedUpdated() string {
+	return formatAuditEntry(a.UpdatedBy, a.UpdatedAt)
+}
+
+// formatAuditEntry returns the username of the user with the date, subscriptions saved before audit info was recorded have none.
+func formatAuditEntry(userID string, at int64) string {
+	if userID == "" {
+		return "-"
+	}
+
+	name := userID
+	if user, appErr := config.Mattermost.GetUser(userID); appErr == nil {
+		name = "@" + user.Username
+	}
+	if at == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s, %s", name, time.UnixMilli(at).UTC().Format(auditDateFormat))
+}
@@ -2,26 +2,39 @@ package serializer
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
+	"slices"
+	"sort"
 	"strings"
 )
 
 const (
-	CommentCreatedEvent   = "comment_created"
-	CommentUpdatedEvent   = "comment_updated"
-	CommentRemovedEvent   = "comment_removed"
-	PageCreatedEvent      = "page_created"
-	PageUpdatedEvent      = "page_updated"
-	PageTrashedEvent      = "page_trashed"
-	PageRestoredEvent     = "page_restored"
-	PageRemovedEvent      = "page_removed"
-	SubscriptionTypeSpace = "space_subscription"
-	SpaceUpdatedEvent     = "space_updated"
-	SubscriptionTypePage  = "page_subscription"
-
-	aliasAlreadyExist       = "a subscription with the same name already exists in this channel"
-	urlSpaceKeyAlreadyExist = "a subscription with the same url and space key already exists in this channel"
-	urlPageIDAlreadyExist   = "a subscription with the same url and page id already exists in this channel"
+	CommentCreatedEvent       = "comment_created"
+	CommentUpdatedEvent       = "comment_updated"
+	CommentRemovedEvent       = "comment_removed"
+	PageCreatedEvent          = "page_created"
+	PageUpdatedEvent          = "page_updated"
+	PageTrashedEvent          = "page_trashed"
+	PageRestoredEvent         = "page_restored"
+	PageRemovedEvent          = "page_removed"
+	BlogCreatedEvent          = "blog_created"
+	BlogUpdatedEvent          = "blog_updated"
+	BlogTrashedEvent          = "blog_trashed"
+	BlogRemovedEvent          = "blog_removed"
+	SubscriptionTypeSpace     = "space_subscription"
+	SpaceUpdatedEvent         = "space_updated"
+	SubscriptionTypePage      = "page_subscription"
+	SubscriptionTypePageTree  = "page_tree_subscription"
+	SubscriptionTypeCQL       = "cql_subscription"
+	SubscriptionTypeAllSpaces = "all_spaces_subscription"
+
+	aliasAlreadyExist         = "a subscription with the same name already exists in this channel"
+	urlSpaceKeyAlreadyExist   = "a subscription with the same url and space key already exists in this channel"
+	urlPageIDAlreadyExist     = "a subscription with the same url and page id already exists in this channel"
+	urlPageTreeIDAlreadyExist = "a page tree subscription with the same url and page id already exists in this channel"
+	cqlAlreadyExist           = "a subscription with the same url and query already exists in this channel"
+	urlAllSpacesAlreadyExist  = "an all spaces subscription with the same url already exists in this channel"
 )
 
 var eventDisplayName = map[string]string{
@@ -33,6 +46,25 @@ var eventDisplayName = map[string]string{
 	PageTrashedEvent:    "Page Trash",
 	PageRestoredEvent:   "Page Restore",
 	PageRemovedEvent:    "Page Remove",
+	BlogCreatedEvent:    "Blog Post Create",
+	BlogUpdatedEvent:    "Blog Post Update",
+	BlogTrashedEvent:    "Blog Post Trash",
+	BlogRemovedEvent:    "Blog Post Remove",
+}
+
+// SupportedEvents returns the events subscriptions can be notified of, sorted by name.
+func SupportedEvents() []string {
+	events := make([]string, 0, len(eventDisplayName))
+	for event := range eventDisplayName {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	return events
+}
+
+func IsSupportedEvent(event string) bool {
+	_, ok := eventDisplayName[event]
+	return ok
 }
 
 type Subscription interface {
@@ -41,7 +73,18 @@ type Subscription interface {
 	Edit(*Subscriptions)
 	Name() string
 	GetAlias() string
+	GetChannelID() string
+	WithChannelID(string) Subscription
+	GetBaseURL() string
 	GetFormattedSubscription() string
+	GetAuditInfo() AuditInfo
+	WithAuditInfo(AuditInfo) Subscription
+	GetPauseState() PauseState
+	WithPauseState(PauseState) Subscription
+	GetPreset() string
+	GetTemplates() map[string]string
+	WithTemplates(map[string]string) Subscription
+	Matches(NotificationEvent) bool
 	IsValid() error
 	ValidateSubscription(*Subscriptions) error
 }
@@ -52,15 +95,32 @@ type BaseSubscription struct {
 	Events    []string `json:"events"`
 	ChannelID string   `json:"channelID"`
 	Type      string   `json:"subscriptionType"`
+
+	IgnoreMinorEdits bool     `json:"ignoreMinorEdits,omitempty"`
+	ExcludeAuthors   []string `json:"excludeAuthors,omitempty"`
+	IncludeAuthors   []string `json:"includeAuthors,omitempty"`
+	// Preset is the name of the preset the subscription was created from, so edits of the preset can be applied to it.
+	Preset string `json:"preset,omitempty"`
+	// Templates holds the notification templates of the subscription by event type, overriding the default ones.
+	Templates map[string]string `json:"templates,omitempty"`
+
+	AuditInfo
+	PauseState
 }
 
 type StringSubscription map[string]Subscription
 type StringArrayMap map[string][]string
 
 type Subscriptions struct {
-	ByChannelID   map[string]StringSubscription
-	ByURLPageID   map[string]StringArrayMap
-	ByURLSpaceKey map[string]StringArrayMap
+	ByChannelID     map[string]StringSubscription
+	ByURLPageID     map[string]StringArrayMap
+	ByURLSpaceKey   map[string]StringArrayMap
+	ByURLPageTreeID map[string]StringArrayMap
+	// ByURLCQL indexes the CQL subscriptions of each instance, by channel, with the names of the subscriptions
+	// rather than their events, since a channel can have several of them.
+	ByURLCQL map[string]StringArrayMap
+	// ByURLAllSpaces indexes the all spaces subscriptions of each instance, by channel, with their events.
+	ByURLAllSpaces map[string]StringArrayMap
 }
 
 func (s *Subscriptions) EnsureDefaults() {
@@ -73,16 +133,37 @@ func (s *Subscriptions) EnsureDefaults() {
 	if s.ByURLSpaceKey == nil {
 		s.ByURLSpaceKey = make(map[string]StringArrayMap)
 	}
+	if s.ByURLPageTreeID == nil {
+		s.ByURLPageTreeID = make(map[string]StringArrayMap)
+	}
+	if s.ByURLCQL == nil {
+		s.ByURLCQL = make(map[string]StringArrayMap)
+	}
+	if s.ByURLAllSpaces == nil {
+		s.ByURLAllSpaces = make(map[string]StringArrayMap)
+	}
 }
 
 func NewSubscriptions() *Subscriptions {
 	return &Subscriptions{
-		ByChannelID:   map[string]StringSubscription{},
-		ByURLPageID:   map[string]StringArrayMap{},
-		ByURLSpaceKey: map[string]StringArrayMap{},
+		ByChannelID:     map[string]StringSubscription{},
+		ByURLPageID:     map[string]StringArrayMap{},
+		ByURLSpaceKey:   map[string]StringArrayMap{},
+		ByURLPageTreeID: map[string]StringArrayMap{},
+		ByURLCQL:        map[string]StringArrayMap{},
+		ByURLAllSpaces:  map[string]StringArrayMap{},
 	}
 }
 
+// subscriptionTypes are the subscription types by the value of their `subscriptionType` field.
+var subscriptionTypes = map[string]reflect.Type{
+	SubscriptionTypePage:      reflect.TypeOf(PageSubscription{}),
+	SubscriptionTypeSpace:     reflect.TypeOf(SpaceSubscription{}),
+	SubscriptionTypePageTree:  reflect.TypeOf(PageTreeSubscription{}),
+	SubscriptionTypeCQL:       reflect.TypeOf(CQLSubscription{}),
+	SubscriptionTypeAllSpaces: reflect.TypeOf(AllSpacesSubscription{}),
+}
+
 func (s *StringSubscription) UnmarshalJSON(data []byte) error {
 	m := make(map[string]interface{})
 	if err := json.Unmarshal(data, &m); err != nil {
@@ -95,10 +176,7 @@ func (s *StringSubscription) UnmarshalJSON(data []byte) error {
 		if err != nil {
 			return err
 		}
-		value, err := UnmarshalCustomSubscription(bytes, "subscriptionType", map[string]reflect.Type{
-			SubscriptionTypePage:  reflect.TypeOf(PageSubscription{}),
-			SubscriptionTypeSpace: reflect.TypeOf(SpaceSubscription{}),
-		})
+		value, err := UnmarshalCustomSubscription(bytes, "subscriptionType", subscriptionTypes)
 		if err != nil {
 			return err
 		}
@@ -110,28 +188,32 @@ func (s *StringSubscription) UnmarshalJSON(data []byte) error {
 }
 
 // UnmarshalCustomSubscription returns subscription from bytes.
+// The subscription is returned by value, like SpaceSubscription rather than *SpaceSubscription.
+// Subscriptions are built, saved and compared as values everywhere else, so a stored subscription
+// must have the same dynamic type as a new one for type assertions and switches on it to hold.
 func UnmarshalCustomSubscription(data []byte, typeJSONField string, customTypes map[string]reflect.Type) (interface{}, error) {
 	m := make(map[string]interface{})
 	if err := json.Unmarshal(data, &m); err != nil {
 		return nil, err
 	}
 
-	typeName := m[typeJSONField].(string)
-	var value Subscription
-	if ty, found := customTypes[typeName]; found {
-		value = reflect.New(ty).Interface().(Subscription)
+	typeName, _ := m[typeJSONField].(string)
+	ty, found := customTypes[typeName]
+	if !found {
+		return nil, fmt.Errorf("unknown subscription type %q", typeName)
 	}
+	value := reflect.New(ty).Interface()
 
 	valueBytes, err := json.Marshal(m)
 	if err != nil {
 		return nil, err
 	}
 
-	if err = json.Unmarshal(valueBytes, &value); err != nil {
+	if err = json.Unmarshal(valueBytes, value); err != nil {
 		return nil, err
 	}
 
-	return value, nil
+	return reflect.ValueOf(value).Elem().Interface(), nil
 }
 
 func SubscriptionsFromJSON(bytes []byte) (*Subscriptions, error) {
@@ -148,25 +230,52 @@ func SubscriptionsFromJSON(bytes []byte) (*Subscriptions, error) {
 }
 
 func FormattedSubscriptionList(channelSubscriptions StringSubscription) string {
-	var pageSubscriptions, spaceSubscriptions, list string
-	pageSubscriptionsHeader := "| Name | Base Url | Page Id | Events|\n| :----|:--------| :--------| :-----|"
-	spaceSubscriptionsHeader := "| Name | Base Url | Space Key | Events|\n| :----|:--------| :--------| :-----|"
+	var pageSubscriptions, spaceSubscriptions, pageTreeSubscriptions, cqlSubscriptions, allSpacesSubscriptions, list string
+	pageSubscriptionsHeader := "| Name | Base Url | Page Id | Events| Status| Created By| Last Updated|\n| :----|:--------| :--------| :-----| :-----| :-----| :-----|"
+	pageTreeSubscriptionsHeader := "| Name | Base Url | Root Page Id | Events| Status| Created By| Last Updated|\n| :----|:--------| :--------| :-----| :-----| :-----| :-----|"
+	spaceSubscriptionsHeader := "| Name | Base Url | Space Key | Events| Content| Labels| Status| Created By| Last Updated|\n| :----|:--------| :--------| :-----| :-----| :-----| :-----| :-----| :-----|"
+	cqlSubscriptionsHeader := "| Name | Base Url | Query | Events| Status| Created By| Last Updated|\n| :----|:--------| :--------| :-----| :-----| :-----| :-----|"
+	allSpacesSubscriptionsHeader := "| Name | Base Url | Excluded Spaces | Events| Status| Created By| Last Updated|\n| :----|:--------| :--------| :-----| :-----| :-----| :-----|"
 	for _, sub := range channelSubscriptions {
 		if sub.Name() == SubscriptionTypePage {
 			pageSubscriptions += sub.GetFormattedSubscription()
 		} else if sub.Name() == SubscriptionTypeSpace {
 			spaceSubscriptions += sub.GetFormattedSubscription()
+		} else if sub.Name() == SubscriptionTypePageTree {
+			pageTreeSubscriptions += sub.GetFormattedSubscription()
+		} else if sub.Name() == SubscriptionTypeCQL {
+			cqlSubscriptions += sub.GetFormattedSubscription()
+		} else if sub.Name() == SubscriptionTypeAllSpaces {
+			allSpacesSubscriptions += sub.GetFormattedSubscription()
 		}
 	}
+	if allSpacesSubscriptions != "" {
+		list = "#### All Spaces Subscriptions \n" + allSpacesSubscriptionsHeader + allSpacesSubscriptions
+	}
+	if list != "" && spaceSubscriptions != "" {
+		list += "\n\n"
+	}
 	if spaceSubscriptions != "" {
-		list = "#### Space Subscriptions \n" + spaceSubscriptionsHeader + spaceSubscriptions
+		list += "#### Space Subscriptions \n" + spaceSubscriptionsHeader + spaceSubscriptions
 	}
-	if spaceSubscriptions != "" && pageSubscriptions != "" {
+	if list != "" && pageSubscriptions != "" {
 		list += "\n\n"
 	}
 	if pageSubscriptions != "" {
 		list += "#### Page Subscriptions \n" + pageSubscriptionsHeader + pageSubscriptions
 	}
+	if list != "" && pageTreeSubscriptions != "" {
+		list += "\n\n"
+	}
+	if pageTreeSubscriptions != "" {
+		list += "#### Page Tree Subscriptions \n" + pageTreeSubscriptionsHeader + pageTreeSubscriptions
+	}
+	if list != "" && cqlSubscriptions != "" {
+		list += "\n\n"
+	}
+	if cqlSubscriptions != "" {
+		list += "#### CQL Subscriptions \n" + cqlSubscriptionsHeader + cqlSubscriptions
+	}
 	return list
 }
 
@@ -180,3 +289,54 @@ func (s StringSubscription) GetInsensitiveCase(key string) (Subscription, bool)
 	}
 	return nil, false
 }
+
+// formatWithName returns the space key or page ID with the name it was resolved to, when there is one.
+func formatWithName(id, name string) string {
+	if name == "" {
+		return id
+	}
+	return fmt.Sprintf("%s (%s)", strings.ReplaceAll(name, "|", "\\|"), id)
+}
+
+// matchesEvent reports whether the subscription options let the event through.
+func (bs BaseSubscription) matchesEvent(event NotificationEvent) bool {
+	if !slices.Contains(bs.Events, event.EventType) {
+		return false
+	}
+	if bs.IgnoreMinorEdits && event.MinorEdit {
+		return false
+	}
+	for _, author := range bs.ExcludeAuthors {
+		if containsAuthor(event.Authors, author) {
+			return false
+		}
+	}
+	if len(bs.IncludeAuthors) == 0 {
+		return true
+	}
+	for _, author := range bs.IncludeAuthors {
+		if containsAuthor(event.Authors, author) {
+			return true
+		}
+	}
+	return false
+}
+
+func (bs BaseSubscription) validateAuthors() error {
+	for _, author := range bs.IncludeAuthors {
+		if containsAuthor(bs.ExcludeAuthors, author) {
+			return fmt.Errorf("author %q can not be both included and excluded", author)
+		}
+	}
+	return nil
+}
+
+func containsAuthor(authors []string, author string) bool {
+	author = strings.TrimSpace(author)
+	for _, a := range authors {
+		if a != "" && strings.EqualFold(strings.TrimSpace(a), author) {
+			return true
+		}
+	}
+	return false
+}
@@ -18,6 +18,8 @@ const (
 	confluenceCloudCommentCreateMessage = "A new [comment](%s) was posted on the [%s](%s) page."
 	confluenceCloudCommentUpdateMessage = "A [comment](%s) was updated on the [%s](%s) page."
 	confluenceCloudCommentDeleteMessage = "A comment was deleted from the [%s](%s) page."
+
+	cloudMinorEditUpdateTrigger = "minor_edit"
 )
 
 type ConfluenceCloudEvent struct {
@@ -97,6 +99,28 @@ func (e ConfluenceCloudEvent) GetNotificationPost(eventType string) *model.Post
 	return post
 }
 
+// GetTemplateData returns the data notification templates are rendered with for the event.
+// Confluence Cloud events carry neither names nor excerpts, so the author is the account id of the user.
+func (e ConfluenceCloudEvent) GetTemplateData(eventType string) TemplateData {
+	data := TemplateData{
+		Event:       eventType,
+		Author:      e.UserAccountID,
+		ContentType: e.GetContentType(),
+		SpaceKey:    e.GetSpaceKey(),
+	}
+	page := e.Page
+	if e.Comment != nil {
+		data.CommentURL = e.Comment.Self
+		page = e.Comment.Parent
+	}
+	if page != nil {
+		data.Title = page.Title
+		data.URL = page.Self
+		data.Version = page.Version
+	}
+	return data
+}
+
 func (e ConfluenceCloudEvent) GetURL() string {
 	if e.Comment != nil {
 		return e.Comment.Self
@@ -123,3 +147,48 @@ func (e ConfluenceCloudEvent) GetPageID() string {
 	}
 	return ""
 }
+
+// GetCommentID returns the id of the comment of comment events.
+func (e ConfluenceCloudEvent) GetCommentID() string {
+	if e.Comment == nil {
+		return ""
+	}
+	return strconv.Itoa(e.Comment.ID)
+}
+
+// GetParentCommentID returns the id of the comment a comment replies to.
+func (e ConfluenceCloudEvent) GetParentCommentID() string {
+	if e.Comment == nil || e.Comment.InReplyTo == nil {
+		return ""
+	}
+	return e.Comment.InReplyTo.ID
+}
+
+// GetLabels returns nil since Confluence Cloud events do not carry labels, so label filters are skipped for them.
+func (e ConfluenceCloudEvent) GetLabels() []string {
+	return nil
+}
+
+// GetAncestorIDs returns nil since Confluence Cloud events do not carry the page ancestors.
+func (e ConfluenceCloudEvent) GetAncestorIDs() []string {
+	return nil
+}
+
+// GetContentType returns the Confluence content type of the page or blog post the event is about.
+func (e ConfluenceCloudEvent) GetContentType() string {
+	if e.Comment != nil && e.Comment.Parent != nil {
+		return e.Comment.Parent.ContentTypes
+	} else if e.Page != nil {
+		return e.Page.ContentTypes
+	}
+	return ""
+}
+
+// GetAuthors returns the account id of the user who triggered the event.
+func (e ConfluenceCloudEvent) GetAuthors() []string {
+	return []string{e.UserAccountID}
+}
+
+func (e ConfluenceCloudEvent) MinorEdit() bool {
+	return e.UpdateTrigger == cloudMinorEditUpdateTrigger
+}
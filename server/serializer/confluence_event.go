@@ -6,15 +6,31 @@ import (
 
 type ConfluenceEvent interface {
 	GetNotificationPost(string) *model.Post
+	GetTemplateData(string) TemplateData
 	GetURL() string
 	GetSpaceKey() string
 	GetPageID() string
+	GetCommentID() string
+	GetParentCommentID() string
+	GetLabels() []string
+	GetAncestorIDs() []string
+	GetContentType() string
+	GetAuthors() []string
+	MinorEdit() bool
 }
 
 // for handling of confluence server version greater than 9 notifications
 type ConfluenceEventV2 interface {
 	GetNotificationPost(string, string, string) *model.Post
+	GetTemplateData(string) TemplateData
 	GetURL() string
 	GetSpaceKey() string
 	GetPageID() string
+	GetCommentID() string
+	GetParentCommentID() string
+	GetLabels() []string
+	GetAncestorIDs() []string
+	GetContentType() string
+	GetAuthors() []string
+	MinorEdit() bool
 }
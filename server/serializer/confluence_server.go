@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	url2 "net/url"
 	"strings"
 
 	"github.com/mattermost/mattermost/server/public/model"
@@ -21,6 +22,11 @@ const (
 	confluenceServerPageTrashedMessage            = "%s trashed %s in %s."
 	confluenceServerPageRestoredMessage           = "%s restored %s in %s."
 	confluenceServerPageRemovedMessage            = "%s removed **%s** in %s."
+	confluenceServerBlogCreatedMessage            = "%s published a new blog post in %s."
+	confluenceServerBlogCreatedWithoutBodyMessage = "%s published a new blog post %s in %s."
+	confluenceServerBlogUpdatedMessage            = "%s updated the blog post %s in %s."
+	confluenceServerBlogTrashedMessage            = "%s trashed the blog post %s in %s."
+	confluenceServerBlogRemovedMessage            = "%s removed the blog post **%s** in %s."
 
 	confluenceServerCommentCreatedMessage      = "%s commented on %s in %s."
 	confluenceServerEmptyCommentCreatedMessage = "%s [commented](%s) on %s in %s."
@@ -47,10 +53,23 @@ type ConfluenceServerSpace struct {
 }
 
 type ConfluenceServerPageAncestor struct {
+	ID    string `json:"id"`
 	Title string `json:"title"`
 	URL   string `json:"url"`
 }
 
+// GetID returns the id of the ancestor page, falling back to the pageId parameter of its url.
+func (a ConfluenceServerPageAncestor) GetID() string {
+	if a.ID != "" {
+		return a.ID
+	}
+	u, err := url2.Parse(a.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("pageId")
+}
+
 type ConfluenceServerPage struct {
 	IsHomePage    bool                           `json:"is_home_page"`
 	CreatedAt     int64                          `json:"created_at"`
@@ -160,6 +179,10 @@ type PagePayload struct {
 	ID int64 `json:"id"`
 }
 
+type BlogPayload struct {
+	ID int64 `json:"id"`
+}
+
 type SpacePayload struct {
 	ID       int64  `json:"id"`
 	SpaceKey string `json:"spaceKey"`
@@ -171,6 +194,7 @@ type ConfluenceServerWebhookPayload struct {
 	UserKey   string         `json:"userKey"`
 	Comment   CommentPayload `json:"comment"`
 	Page      PagePayload    `json:"page"`
+	Blog      BlogPayload    `json:"blog"`
 	Space     SpacePayload   `json:"space"`
 }
 
@@ -295,6 +319,39 @@ func (e ConfluenceServerEvent) GetNotificationPost(eventType string) *model.Post
 		// No link for page since the page was removed
 		post.Message = fmt.Sprintf(confluenceServerPageRemovedMessage, e.GetUserDisplayName(true), e.GetPageDisplayName(false), e.GetSpaceDisplayName(true))
 
+	case BlogCreatedEvent:
+		message := fmt.Sprintf(confluenceServerBlogCreatedMessage, e.GetUserDisplayName(true), e.GetSpaceDisplayName(true))
+		if strings.TrimSpace(e.Blog.Excerpt) != "" {
+			attachment = &model.SlackAttachment{
+				Fallback:  message,
+				Pretext:   message,
+				Title:     e.Blog.Title,
+				TitleLink: e.Blog.URL,
+				Text:      fmt.Sprintf("%s\n\n[**View in Confluence**](%s)", strings.TrimSpace(e.Blog.Excerpt), e.Blog.URL),
+			}
+		} else {
+			post.Message = fmt.Sprintf(confluenceServerBlogCreatedWithoutBodyMessage, e.GetUserDisplayName(true), e.GetBlogDisplayName(true), e.GetSpaceDisplayName(true))
+		}
+
+	case BlogUpdatedEvent:
+		message := fmt.Sprintf(confluenceServerBlogUpdatedMessage, e.GetUserDisplayName(true), e.GetBlogDisplayName(true), e.GetSpaceDisplayName(true))
+		if strings.TrimSpace(e.VersionComment) != "" {
+			attachment = &model.SlackAttachment{
+				Fallback: message,
+				Pretext:  message,
+				Text:     fmt.Sprintf("**What’s Changed?**\n> %s\n\n[**View in Confluence**](%s)", strings.TrimSpace(e.VersionComment), e.Blog.URL),
+			}
+		} else {
+			post.Message = message
+		}
+
+	case BlogTrashedEvent:
+		post.Message = fmt.Sprintf(confluenceServerBlogTrashedMessage, e.GetUserDisplayName(true), e.GetBlogDisplayName(true), e.GetSpaceDisplayName(true))
+
+	case BlogRemovedEvent:
+		// No link for blog post since the blog post was removed
+		post.Message = fmt.Sprintf(confluenceServerBlogRemovedMessage, e.GetUserDisplayName(true), e.GetBlogDisplayName(false), e.GetSpaceDisplayName(true))
+
 	case CommentCreatedEvent:
 		message := fmt.Sprintf(confluenceServerCommentCreatedMessage, e.GetUserDisplayName(true), e.GetCommentPageOrBlogDisplayName(true), e.GetSpaceDisplayName(true))
 
@@ -353,6 +410,35 @@ func (e ConfluenceServerEvent) GetNotificationPost(eventType string) *model.Post
 	return post
 }
 
+// GetTemplateData returns the data notification templates are rendered with for the event.
+func (e ConfluenceServerEvent) GetTemplateData(eventType string) TemplateData {
+	data := TemplateData{
+		Event:          eventType,
+		Author:         e.GetUserDisplayName(false),
+		ContentType:    e.GetContentType(),
+		Title:          e.GetCommentPageOrBlogDisplayName(false),
+		SpaceKey:       e.Space.Key,
+		SpaceName:      e.GetSpaceDisplayName(false),
+		SpaceURL:       e.Space.URL,
+		VersionComment: strings.TrimSpace(e.VersionComment),
+	}
+	switch {
+	case e.Page != nil:
+		data.URL = e.Page.TinyURL
+		data.Excerpt = strings.TrimSpace(e.Page.Excerpt)
+		data.Version = e.Page.Version
+	case e.Blog != nil:
+		data.URL = e.Blog.URL
+		data.Excerpt = strings.TrimSpace(e.Blog.Excerpt)
+		data.Version = e.Blog.Version
+	}
+	if e.Comment != nil {
+		data.CommentURL = e.Comment.URL
+		data.Excerpt = strings.TrimSpace(e.Comment.Excerpt)
+	}
+	return data
+}
+
 func (e ConfluenceServerEvent) GetURL() string {
 	return e.BaseURL
 }
@@ -365,5 +451,72 @@ func (e ConfluenceServerEvent) GetPageID() string {
 	if e.Page != nil {
 		return e.Page.ID
 	}
+	if e.Blog != nil {
+		return e.Blog.ID
+	}
 	return ""
 }
+
+// GetCommentID returns the id of the comment of comment events.
+func (e ConfluenceServerEvent) GetCommentID() string {
+	if e.Comment == nil {
+		return ""
+	}
+	return e.Comment.ID
+}
+
+// GetParentCommentID returns the id of the comment a comment replies to.
+func (e ConfluenceServerEvent) GetParentCommentID() string {
+	if e.Comment == nil || e.Comment.ParentComment == nil {
+		return ""
+	}
+	return e.Comment.ParentComment.ID
+}
+
+// GetContentType returns the Confluence content type of the page or blog post the event is about.
+func (e ConfluenceServerEvent) GetContentType() string {
+	if e.Page != nil {
+		return ConfluenceContentTypePage
+	}
+	if e.Blog != nil {
+		return ConfluenceContentTypeBlogPost
+	}
+	return ""
+}
+
+// GetLabels returns the labels of the page or blog post the event is about, or nil when the payload carries none.
+func (e ConfluenceServerEvent) GetLabels() []string {
+	if e.Page != nil {
+		return e.Page.Labels
+	}
+	if e.Blog != nil {
+		return e.Blog.Labels
+	}
+	return nil
+}
+
+// GetAncestorIDs returns the ids of the pages above the page the event is about, or the page a comment was made on.
+func (e ConfluenceServerEvent) GetAncestorIDs() []string {
+	if e.Page == nil {
+		return nil
+	}
+	var ids []string
+	for _, ancestor := range e.Page.Ancestors {
+		if id := ancestor.GetID(); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// GetAuthors returns the username of the user who triggered the event.
+func (e ConfluenceServerEvent) GetAuthors() []string {
+	if e.User == nil {
+		return nil
+	}
+	return []string{e.User.Username}
+}
+
+func (e ConfluenceServerEvent) MinorEdit() bool {
+	return e.IsMinorEdit
+}
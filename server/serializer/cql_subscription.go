@@ -0,0 +1,146 @@
+package serializer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	url2 "net/url"
+	"slices"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+)
+
+// CQLSubscription matches events for the content found by a Confluence Query Language expression,
+// like `type = page and label = incident and space in (OPS, SRE)`.
+type CQLSubscription struct {
+	CQL string `json:"cql"`
+	BaseSubscription
+}
+
+func (cs CQLSubscription) Add(s *Subscriptions) {
+	s.EnsureDefaults()
+
+	if _, valid := s.ByChannelID[cs.ChannelID]; !valid {
+		s.ByChannelID[cs.ChannelID] = make(StringSubscription)
+	}
+	s.ByChannelID[cs.ChannelID][cs.Alias] = cs
+	key := store.GetURLCombinationKey(cs.BaseURL)
+	if _, ok := s.ByURLCQL[key]; !ok {
+		s.ByURLCQL[key] = make(map[string][]string)
+	}
+	if aliases := s.ByURLCQL[key][cs.ChannelID]; !slices.Contains(aliases, cs.Alias) {
+		s.ByURLCQL[key][cs.ChannelID] = append(slices.Clone(aliases), cs.Alias)
+	}
+}
+
+func (cs CQLSubscription) Remove(s *Subscriptions) {
+	delete(s.ByChannelID[cs.ChannelID], cs.Alias)
+	key := store.GetURLCombinationKey(cs.BaseURL)
+	aliases := slices.DeleteFunc(slices.Clone(s.ByURLCQL[key][cs.ChannelID]), func(alias string) bool {
+		return alias == cs.Alias
+	})
+	if len(aliases) == 0 {
+		delete(s.ByURLCQL[key], cs.ChannelID)
+		return
+	}
+	s.ByURLCQL[key][cs.ChannelID] = aliases
+}
+
+func (cs CQLSubscription) Edit(s *Subscriptions) {
+	cs.Remove(s)
+	cs.Add(s)
+}
+
+func (cs CQLSubscription) Name() string {
+	return SubscriptionTypeCQL
+}
+
+func (cs CQLSubscription) GetAlias() string {
+	return cs.Alias
+}
+
+func (cs CQLSubscription) GetChannelID() string {
+	return cs.ChannelID
+}
+
+func (cs CQLSubscription) GetBaseURL() string {
+	return cs.BaseURL
+}
+
+func (cs CQLSubscription) WithChannelID(channelID string) Subscription {
+	cs.ChannelID = channelID
+	return cs
+}
+
+func (cs CQLSubscription) WithAuditInfo(auditInfo AuditInfo) Subscription {
+	cs.AuditInfo = auditInfo
+	return cs
+}
+
+func (cs CQLSubscription) WithPauseState(pauseState PauseState) Subscription {
+	cs.PauseState = pauseState
+	return cs
+}
+
+func (cs CQLSubscription) WithTemplates(templates map[string]string) Subscription {
+	cs.Templates = templates
+	return cs
+}
+
+func (cs CQLSubscription) GetFormattedSubscription() string {
+	return fmt.Sprintf("\n|%s|%s|`%s`|%s|%s|%s|%s|", cs.Alias, cs.BaseURL, strings.ReplaceAll(cs.CQL, "|", "\\|"), cs.getFormattedEvents(), cs.getFormattedStatus(), cs.getFormattedCreated(), cs.getFormattedUpdated())
+}
+
+func (cs CQLSubscription) IsValid() error {
+	if cs.Alias == "" {
+		return errors.New("subscription name can not be empty")
+	}
+	if cs.BaseURL == "" {
+		return errors.New("base url can not be empty")
+	}
+	if _, err := url2.Parse(cs.BaseURL); err != nil {
+		return errors.New("enter a valid url")
+	}
+	if strings.TrimSpace(cs.CQL) == "" {
+		return errors.New("query can not be empty")
+	}
+	if cs.ChannelID == "" {
+		return errors.New("channel id can not be empty")
+	}
+	if err := cs.validateAuthors(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func CQLSubscriptionFromJSON(data io.Reader) (CQLSubscription, error) {
+	var cs CQLSubscription
+	err := json.NewDecoder(data).Decode(&cs)
+	return cs, err
+}
+
+func (cs CQLSubscription) ValidateSubscription(subs *Subscriptions) error {
+	if err := cs.IsValid(); err != nil {
+		return err
+	}
+	key := store.GetURLCombinationKey(cs.BaseURL)
+	for alias, subscription := range subs.ByChannelID[cs.ChannelID] {
+		if alias == cs.Alias {
+			return errors.New(aliasAlreadyExist)
+		}
+		if other, ok := subscription.(CQLSubscription); ok && store.GetURLCombinationKey(other.BaseURL) == key && strings.TrimSpace(other.CQL) == strings.TrimSpace(cs.CQL) {
+			return errors.New(cqlAlreadyExist)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the content of the event is found by the query, and passes the subscription filters.
+func (cs CQLSubscription) Matches(event NotificationEvent) bool {
+	if store.GetURLCombinationKey(cs.BaseURL) != store.GetURLCombinationKey(event.BaseURL) {
+		return false
+	}
+	return event.MatchedCQL[cs.CQL] && cs.matchesEvent(event)
+}
@@ -0,0 +1,141 @@
+package serializer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+const (
+	digestHeader      = "#### Confluence digest\n%d notifications since %s."
+	digestDropped     = " %d more were left out, the digest is full."
+	digestSinceFormat = "Jan 2, 15:04 MST"
+)
+
+// NewDigestEvent returns the digest event of the notification post of an event, keeping the text it was rendered with.
+func NewDigestEvent(post *model.Post, event NotificationEvent, data TemplateData, createdAt time.Time) types.DigestEvent {
+	message := post.Message
+	if attachments := post.Attachments(); message == "" && len(attachments) > 0 {
+		message = attachments[0].Fallback
+	}
+	return types.DigestEvent{
+		EventType: event.EventType,
+		SpaceKey:  event.SpaceKey,
+		SpaceName: data.SpaceName,
+		SpaceURL:  data.SpaceURL,
+		PageID:    event.PageID,
+		Title:     data.Title,
+		URL:       data.URL,
+		Message:   message,
+		CreatedAt: createdAt.UnixMilli(),
+	}
+}
+
+// FormattedDigest returns the digest messages of the queued events, grouped by space and page,
+// with the count of each event type of a page and the latest of its notifications.
+// The digest is split into several messages when it does not fit in a post, each starting with the digest header.
+func FormattedDigest(queue *types.DigestQueue) []string {
+	type pageDigest struct {
+		title, url string
+		counts     map[string]int
+		latest     types.DigestEvent
+	}
+	type spaceDigest struct {
+		name, url string
+		pageIDs   []string
+		pages     map[string]*pageDigest
+	}
+
+	var since int64
+	spaces := map[string]*spaceDigest{}
+	for _, event := range queue.Events {
+		if since == 0 || event.CreatedAt < since {
+			since = event.CreatedAt
+		}
+
+		space, ok := spaces[event.SpaceKey]
+		if !ok {
+			space = &spaceDigest{name: event.SpaceKey, pages: map[string]*pageDigest{}}
+			spaces[event.SpaceKey] = space
+		}
+		if event.SpaceName != "" {
+			space.name = event.SpaceName
+		}
+		if event.SpaceURL != "" {
+			space.url = event.SpaceURL
+		}
+
+		page, ok := space.pages[event.PageID]
+		if !ok {
+			page = &pageDigest{counts: map[string]int{}}
+			space.pages[event.PageID] = page
+			space.pageIDs = append(space.pageIDs, event.PageID)
+		}
+		// The latest title and link are kept, as the page may have been renamed meanwhile.
+		if event.Title != "" {
+			page.title, page.url = event.Title, event.URL
+		}
+		page.counts[event.EventType]++
+		if event.CreatedAt >= page.latest.CreatedAt {
+			page.latest = event
+		}
+	}
+
+	spaceKeys := make([]string, 0, len(spaces))
+	for key := range spaces {
+		spaceKeys = append(spaceKeys, key)
+	}
+	sort.Slice(spaceKeys, func(i, j int) bool {
+		return strings.ToLower(spaces[spaceKeys[i]].name) < strings.ToLower(spaces[spaceKeys[j]].name)
+	})
+
+	header := fmt.Sprintf(digestHeader, len(queue.Events), time.UnixMilli(since).UTC().Format(digestSinceFormat))
+	if queue.Dropped > 0 {
+		header += fmt.Sprintf(digestDropped, queue.Dropped)
+	}
+	var lines []string
+	for _, key := range spaceKeys {
+		space := spaces[key]
+		lines = append(lines, "\n##### "+formatMarkdownLink(space.name, space.url))
+		for _, pageID := range space.pageIDs {
+			page := space.pages[pageID]
+			title := page.title
+			if pageID == "" || title == "" {
+				title = "Space"
+			}
+			lines = append(lines, fmt.Sprintf("\n* %s - %s. Latest: %s", formatMarkdownLink(title, page.url), formatDigestCounts(page.counts), strings.ReplaceAll(page.latest.Message, "\n", " ")))
+		}
+	}
+	return splitMessage(header, lines, "")
+}
+
+func formatMarkdownLink(name, url string) string {
+	if url == "" {
+		return name
+	}
+	return fmt.Sprintf("[%s](%s)", name, url)
+}
+
+// formatDigestCounts returns the counts of the event types, like `2 Page Update, 1 Comment Create`.
+func formatDigestCounts(counts map[string]int) string {
+	events := make([]string, 0, len(counts))
+	for event := range counts {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	formatted := make([]string, 0, len(events))
+	for _, event := range events {
+		name := eventDisplayName[event]
+		if name == "" {
+			name = event
+		}
+		formatted = append(formatted, fmt.Sprintf("%d %s", counts[event], name))
+	}
+	return strings.Join(formatted, ", ")
+}
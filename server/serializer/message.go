@@ -0,0 +1,51 @@
+package serializer
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// splitMessage groups the lines into messages that each fit in a post, starting every message with the header
+// so a table keeps its columns. The footer ends the last message, or gets a message of its own when it does not fit.
+// A line too long for a post on its own is cut.
+func splitMessage(header string, lines []string, footer string) []string {
+	return splitMessageRunes(header, lines, footer, model.PostMessageMaxRunesV2)
+}
+
+func splitMessageRunes(header string, lines []string, footer string, maxRunes int) []string {
+	headerRunes := utf8.RuneCountInString(header)
+	var messages []string
+	message, messageRunes := header, headerRunes
+	for _, line := range lines {
+		lineRunes := utf8.RuneCountInString(line)
+		if messageRunes+lineRunes > maxRunes && messageRunes > headerRunes {
+			messages = append(messages, message)
+			message, messageRunes = header, headerRunes
+		}
+		if messageRunes+lineRunes > maxRunes {
+			line = truncateRunes(line, maxRunes-messageRunes)
+			lineRunes = maxRunes - messageRunes
+		}
+		message += line
+		messageRunes += lineRunes
+	}
+
+	if footer != "" && messageRunes+utf8.RuneCountInString(footer) > maxRunes {
+		return append(messages, message, strings.TrimLeft(footer, "\n"))
+	}
+	return append(messages, message+footer)
+}
+
+// truncateRunes returns the text cut to at most the given number of runes, ending with an ellipsis when it is cut.
+func truncateRunes(text string, maxRunes int) string {
+	if utf8.RuneCountInString(text) <= maxRunes {
+		return text
+	}
+	if maxRunes <= 0 {
+		return ""
+	}
+	runes := []rune(text)
+	return string(runes[:maxRunes-1]) + "…"
+}
@@ -0,0 +1,57 @@
+package serializer
+
+// NotificationEvent holds what subscriptions are matched against when picking the channels to notify of an event.
+type NotificationEvent struct {
+	BaseURL  string
+	SpaceKey string
+	PageID   string
+	// CommentID and ParentCommentID are the comment of comment events and the comment it replies to, if any.
+	CommentID       string
+	ParentCommentID string
+	EventType       string
+	ContentType     string
+	// Labels is nil when the event does not carry the labels of its content, like Confluence Cloud events,
+	// and empty when the content has none. See SpaceSubscription.MatchesLabels.
+	Labels      []string
+	AncestorIDs []string
+	// Authors holds every identifier of the user who triggered the event, like the username and the account key.
+	Authors   []string
+	MinorEdit bool
+	// MatchedCQL holds the CQL expressions of the subscriptions found to match the content of the event.
+	// CQL is evaluated by Confluence, so it is filled in by the caller before the subscriptions are matched.
+	MatchedCQL map[string]bool
+}
+
+// NewNotificationEvent returns the notification event for a Confluence event.
+func NewNotificationEvent(event ConfluenceEvent, eventType string) NotificationEvent {
+	return NotificationEvent{
+		BaseURL:         event.GetURL(),
+		SpaceKey:        event.GetSpaceKey(),
+		PageID:          event.GetPageID(),
+		CommentID:       event.GetCommentID(),
+		ParentCommentID: event.GetParentCommentID(),
+		EventType:       eventType,
+		ContentType:     event.GetContentType(),
+		Labels:          event.GetLabels(),
+		AncestorIDs:     event.GetAncestorIDs(),
+		Authors:         event.GetAuthors(),
+		MinorEdit:       event.MinorEdit(),
+	}
+}
+
+// NewNotificationEventV2 returns the notification event for a Confluence event received from a server with version 9 or greater.
+func NewNotificationEventV2(event ConfluenceEventV2, eventType, spaceKey, pageID string) NotificationEvent {
+	return NotificationEvent{
+		BaseURL:         event.GetURL(),
+		SpaceKey:        spaceKey,
+		PageID:          pageID,
+		CommentID:       event.GetCommentID(),
+		ParentCommentID: event.GetParentCommentID(),
+		EventType:       eventType,
+		ContentType:     event.GetContentType(),
+		Labels:          event.GetLabels(),
+		AncestorIDs:     event.GetAncestorIDs(),
+		Authors:         event.GetAuthors(),
+		MinorEdit:       event.MinorEdit(),
+	}
+}
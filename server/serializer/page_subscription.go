@@ -6,13 +6,14 @@ import (
 	"fmt"
 	"io"
 	url2 "net/url"
-	"strings"
 
 	"github.com/mattermost/mattermost-plugin-confluence/server/store"
 )
 
 type PageSubscription struct {
 	PageID string `json:"pageID"`
+	// PageTitle is the title of the page when the subscription was saved, for display only.
+	PageTitle string `json:"pageTitle,omitempty"`
 	BaseSubscription
 }
 
@@ -49,12 +50,36 @@ func (ps PageSubscription) GetAlias() string {
 	return ps.Alias
 }
 
+func (ps PageSubscription) GetChannelID() string {
+	return ps.ChannelID
+}
+
+func (ps PageSubscription) GetBaseURL() string {
+	return ps.BaseURL
+}
+
+func (ps PageSubscription) WithChannelID(channelID string) Subscription {
+	ps.ChannelID = channelID
+	return ps
+}
+
+func (ps PageSubscription) WithAuditInfo(auditInfo AuditInfo) Subscription {
+	ps.AuditInfo = auditInfo
+	return ps
+}
+
+func (ps PageSubscription) WithPauseState(pauseState PauseState) Subscription {
+	ps.PauseState = pauseState
+	return ps
+}
+
+func (ps PageSubscription) WithTemplates(templates map[string]string) Subscription {
+	ps.Templates = templates
+	return ps
+}
+
 func (ps PageSubscription) GetFormattedSubscription() string {
-	var events []string
-	for _, event := range ps.Events {
-		events = append(events, eventDisplayName[event])
-	}
-	return fmt.Sprintf("\n|%s|%s|%s|%s|", ps.Alias, ps.BaseURL, ps.PageID, strings.Join(events, ", "))
+	return fmt.Sprintf("\n|%s|%s|%s|%s|%s|%s|%s|", ps.Alias, ps.BaseURL, formatWithName(ps.PageID, ps.PageTitle), ps.getFormattedEvents(), ps.getFormattedStatus(), ps.getFormattedCreated(), ps.getFormattedUpdated())
 }
 
 func (ps PageSubscription) IsValid() error {
@@ -73,6 +98,9 @@ func (ps PageSubscription) IsValid() error {
 	if ps.ChannelID == "" {
 		return errors.New("channel id can not be empty")
 	}
+	if err := ps.validateAuthors(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -99,3 +127,11 @@ func (ps PageSubscription) ValidateSubscription(subs *Subscriptions) error {
 	}
 	return nil
 }
+
+// Matches reports whether the event is about the subscribed page and passes the subscription filters.
+func (ps PageSubscription) Matches(event NotificationEvent) bool {
+	if store.GetURLPageIDCombinationKey(ps.BaseURL, ps.PageID) != store.GetURLPageIDCombinationKey(event.BaseURL, event.PageID) {
+		return false
+	}
+	return ps.matchesEvent(event)
+}
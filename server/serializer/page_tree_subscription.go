@@ -0,0 +1,141 @@
+package serializer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	url2 "net/url"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+)
+
+// PageTreeSubscription matches events for a page and every page below it in the page tree.
+type PageTreeSubscription struct {
+	PageID string `json:"pageID"`
+	// PageTitle is the title of the root page when the subscription was saved, for display only.
+	PageTitle string `json:"pageTitle,omitempty"`
+	BaseSubscription
+}
+
+func (pts PageTreeSubscription) Add(s *Subscriptions) {
+	s.EnsureDefaults()
+
+	if _, valid := s.ByChannelID[pts.ChannelID]; !valid {
+		s.ByChannelID[pts.ChannelID] = make(StringSubscription)
+	}
+	s.ByChannelID[pts.ChannelID][pts.Alias] = pts
+	key := store.GetURLPageIDCombinationKey(pts.BaseURL, pts.PageID)
+	if _, ok := s.ByURLPageTreeID[key]; !ok {
+		s.ByURLPageTreeID[key] = make(map[string][]string)
+	}
+	s.ByURLPageTreeID[key][pts.ChannelID] = pts.Events
+}
+
+func (pts PageTreeSubscription) Remove(s *Subscriptions) {
+	delete(s.ByChannelID[pts.ChannelID], pts.Alias)
+	key := store.GetURLPageIDCombinationKey(pts.BaseURL, pts.PageID)
+	delete(s.ByURLPageTreeID[key], pts.ChannelID)
+}
+
+func (pts PageTreeSubscription) Edit(s *Subscriptions) {
+	pts.Remove(s)
+	pts.Add(s)
+}
+
+func (pts PageTreeSubscription) Name() string {
+	return SubscriptionTypePageTree
+}
+
+func (pts PageTreeSubscription) GetAlias() string {
+	return pts.Alias
+}
+
+func (pts PageTreeSubscription) GetChannelID() string {
+	return pts.ChannelID
+}
+
+func (pts PageTreeSubscription) GetBaseURL() string {
+	return pts.BaseURL
+}
+
+func (pts PageTreeSubscription) WithChannelID(channelID string) Subscription {
+	pts.ChannelID = channelID
+	return pts
+}
+
+func (pts PageTreeSubscription) WithAuditInfo(auditInfo AuditInfo) Subscription {
+	pts.AuditInfo = auditInfo
+	return pts
+}
+
+func (pts PageTreeSubscription) WithPauseState(pauseState PauseState) Subscription {
+	pts.PauseState = pauseState
+	return pts
+}
+
+func (pts PageTreeSubscription) WithTemplates(templates map[string]string) Subscription {
+	pts.Templates = templates
+	return pts
+}
+
+func (pts PageTreeSubscription) GetFormattedSubscription() string {
+	return fmt.Sprintf("\n|%s|%s|%s|%s|%s|%s|%s|", pts.Alias, pts.BaseURL, formatWithName(pts.PageID, pts.PageTitle), pts.getFormattedEvents(), pts.getFormattedStatus(), pts.getFormattedCreated(), pts.getFormattedUpdated())
+}
+
+func (pts PageTreeSubscription) IsValid() error {
+	if pts.Alias == "" {
+		return errors.New("subscription name can not be empty")
+	}
+	if pts.BaseURL == "" {
+		return errors.New("base url can not be empty")
+	}
+	if _, err := url2.Parse(pts.BaseURL); err != nil {
+		return errors.New("enter a valid url")
+	}
+	if pts.PageID == "" {
+		return errors.New("page id can not be empty")
+	}
+	if pts.ChannelID == "" {
+		return errors.New("channel id can not be empty")
+	}
+	if err := pts.validateAuthors(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func PageTreeSubscriptionFromJSON(data io.Reader) (PageTreeSubscription, error) {
+	var pts PageTreeSubscription
+	err := json.NewDecoder(data).Decode(&pts)
+	return pts, err
+}
+
+func (pts PageTreeSubscription) ValidateSubscription(subs *Subscriptions) error {
+	if err := pts.IsValid(); err != nil {
+		return err
+	}
+	if channelSubscriptions, valid := subs.ByChannelID[pts.ChannelID]; valid {
+		if _, ok := channelSubscriptions[pts.Alias]; ok {
+			return errors.New(aliasAlreadyExist)
+		}
+	}
+	key := store.GetURLPageIDCombinationKey(pts.BaseURL, pts.PageID)
+	if urlPageTreeIDSubscriptions, valid := subs.ByURLPageTreeID[key]; valid {
+		if _, ok := urlPageTreeIDSubscriptions[pts.ChannelID]; ok {
+			return errors.New(urlPageTreeIDAlreadyExist)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the event is about the subscribed page or a page below it, and passes the subscription filters.
+func (pts PageTreeSubscription) Matches(event NotificationEvent) bool {
+	key := store.GetURLPageIDCombinationKey(pts.BaseURL, pts.PageID)
+	for _, pageID := range append([]string{event.PageID}, event.AncestorIDs...) {
+		if key == store.GetURLPageIDCombinationKey(event.BaseURL, pageID) {
+			return pts.matchesEvent(event)
+		}
+	}
+	return false
+}
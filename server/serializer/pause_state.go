@@ -0,0 +1,64 @@
+package serializer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+// PausedUntilFormat is the layout of the end of a pause shown to users.
+const PausedUntilFormat = "Jan 2, 15:04 MST"
+
+// PauseState holds when a subscription is silenced, either paused with `/confluence pause` or within its quiet hours,
+// and how many events it dropped meanwhile.
+type PauseState struct {
+	Paused bool `json:"paused,omitempty"`
+	// PausedUntil is when the pause ends in milliseconds since the epoch, zero pauses until the subscription is resumed.
+	PausedUntil      int64             `json:"pausedUntil,omitempty"`
+	QuietHours       *types.QuietHours `json:"quietHours,omitempty"`
+	SuppressedEvents int               `json:"suppressedEvents,omitempty"`
+	// Disabled is set while the channel of the subscription is archived, its events are dropped without being counted.
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+func (p PauseState) GetPauseState() PauseState {
+	return p
+}
+
+// IsPaused reports whether the subscription is paused at the given time.
+func (p PauseState) IsPaused(now time.Time) bool {
+	return p.Paused && (p.PausedUntil == 0 || now.UnixMilli() < p.PausedUntil)
+}
+
+// IsSilenced reports whether the events of the subscription are dropped at the given time,
+// because it is disabled, paused or within its own quiet hours or the ones of its channel.
+func (p PauseState) IsSilenced(now time.Time, channelQuietHours *types.QuietHours) bool {
+	return p.Disabled || p.IsPaused(now) || p.QuietHours.Contains(now) || channelQuietHours.Contains(now)
+}
+
+func (p PauseState) getFormattedStatus() string {
+	if p.Disabled {
+		return "Disabled, channel archived"
+	}
+
+	var status []string
+	now := time.Now()
+	switch {
+	case p.IsPaused(now) && p.PausedUntil == 0:
+		status = append(status, "Paused")
+	case p.IsPaused(now):
+		status = append(status, "Paused until "+time.UnixMilli(p.PausedUntil).UTC().Format(PausedUntilFormat))
+	}
+	if p.QuietHours != nil {
+		status = append(status, "Quiet "+p.QuietHours.String())
+	}
+	if len(status) == 0 {
+		return "Active"
+	}
+	if p.SuppressedEvents > 0 {
+		status = append(status, fmt.Sprintf("%d suppressed", p.SuppressedEvents))
+	}
+	return strings.Join(status, "; ")
+}
@@ -0,0 +1,98 @@
+package serializer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+// ValidatePreset checks the preset has a name, and only supported events and content type.
+func ValidatePreset(preset *types.Preset) error {
+	if strings.TrimSpace(preset.Name) == "" {
+		return errors.New("preset name can not be empty")
+	}
+	if len(preset.Events) == 0 {
+		return errors.New("preset events can not be empty")
+	}
+	for _, event := range preset.Events {
+		if !IsSupportedEvent(event) {
+			return fmt.Errorf("event **%s** is not supported, the supported events are %s", event, strings.Join(SupportedEvents(), ", "))
+		}
+	}
+	if _, ok := contentTypeFilterDisplayName[preset.ContentType]; preset.ContentType != "" && !ok {
+		return fmt.Errorf("content type **%s** is not supported, use pages, blogs or both", preset.ContentType)
+	}
+	return nil
+}
+
+// FormattedPresetList returns the presets as a markdown table.
+func FormattedPresetList(presets []*types.Preset) string {
+	list := "| Name | Events | Ignore Minor Edits | Authors | Content | Labels |\n| :----|:--------| :--------| :-----| :-----| :-----|"
+	for _, preset := range presets {
+		ignoreMinorEdits := "No"
+		if preset.IgnoreMinorEdits {
+			ignoreMinorEdits = "Yes"
+		}
+		list += fmt.Sprintf("\n|%s|%s|%s|%s|%s|%s|",
+			preset.Name,
+			BaseSubscription{Events: preset.Events}.getFormattedEvents(),
+			ignoreMinorEdits,
+			formatPresetFilter(preset.IncludeAuthors, preset.ExcludeAuthors),
+			orDash(contentTypeFilterDisplayName[preset.ContentType]),
+			formatPresetFilter(preset.IncludeLabels, preset.ExcludeLabels),
+		)
+	}
+	return list
+}
+
+func formatPresetFilter(include, exclude []string) string {
+	var filters []string
+	if len(include) > 0 {
+		filters = append(filters, "only "+strings.Join(include, ", "))
+	}
+	if len(exclude) > 0 {
+		filters = append(filters, "not "+strings.Join(exclude, ", "))
+	}
+	return orDash(strings.Join(filters, "; "))
+}
+
+// ApplyPreset returns the subscription with the events and filters of the preset, and referencing it.
+// The space settings of the preset are ignored for page and page tree subscriptions.
+func ApplyPreset(subscription Subscription, preset *types.Preset) Subscription {
+	switch s := subscription.(type) {
+	case SpaceSubscription:
+		s.BaseSubscription.applyPreset(preset)
+		s.ContentType = preset.ContentType
+		s.IncludeLabels = preset.IncludeLabels
+		s.ExcludeLabels = preset.ExcludeLabels
+		return s
+	case PageSubscription:
+		s.BaseSubscription.applyPreset(preset)
+		return s
+	case PageTreeSubscription:
+		s.BaseSubscription.applyPreset(preset)
+		return s
+	case CQLSubscription:
+		s.BaseSubscription.applyPreset(preset)
+		return s
+	case AllSpacesSubscription:
+		s.BaseSubscription.applyPreset(preset)
+		return s
+	default:
+		return subscription
+	}
+}
+
+func (bs BaseSubscription) GetPreset() string {
+	return bs.Preset
+}
+
+func (bs *BaseSubscription) applyPreset(preset *types.Preset) {
+	bs.Preset = preset.Name
+	bs.Events = preset.Events
+	bs.IgnoreMinorEdits = preset.IgnoreMinorEdits
+	bs.ExcludeAuthors = preset.ExcludeAuthors
+	bs.IncludeAuthors = preset.IncludeAuthors
+}
@@ -11,8 +11,25 @@ import (
 	"github.com/mattermost/mattermost-plugin-confluence/server/store"
 )
 
+const (
+	ContentTypeFilterPages = "pages"
+	ContentTypeFilterBlogs = "blogs"
+	ContentTypeFilterBoth  = "both"
+)
+
+var contentTypeFilterDisplayName = map[string]string{
+	ContentTypeFilterPages: "Pages",
+	ContentTypeFilterBlogs: "Blog Posts",
+	ContentTypeFilterBoth:  "Pages and Blog Posts",
+}
+
 type SpaceSubscription struct {
 	SpaceKey string `json:"spaceKey"`
+	// SpaceName is the name of the space when the subscription was saved, for display only.
+	SpaceName     string   `json:"spaceName,omitempty"`
+	ContentType   string   `json:"contentType,omitempty"`
+	IncludeLabels []string `json:"includeLabels,omitempty"`
+	ExcludeLabels []string `json:"excludeLabels,omitempty"`
 	BaseSubscription
 }
 
@@ -47,12 +64,99 @@ func (ss SpaceSubscription) GetAlias() string {
 	return ss.Alias
 }
 
+func (ss SpaceSubscription) GetChannelID() string {
+	return ss.ChannelID
+}
+
+func (ss SpaceSubscription) GetBaseURL() string {
+	return ss.BaseURL
+}
+
+func (ss SpaceSubscription) WithChannelID(channelID string) Subscription {
+	ss.ChannelID = channelID
+	return ss
+}
+
+func (ss SpaceSubscription) WithAuditInfo(auditInfo AuditInfo) Subscription {
+	ss.AuditInfo = auditInfo
+	return ss
+}
+
+func (ss SpaceSubscription) WithPauseState(pauseState PauseState) Subscription {
+	ss.PauseState = pauseState
+	return ss
+}
+
+func (ss SpaceSubscription) WithTemplates(templates map[string]string) Subscription {
+	ss.Templates = templates
+	return ss
+}
+
 func (ss SpaceSubscription) GetFormattedSubscription() string {
-	var events []string
-	for _, event := range ss.Events {
-		events = append(events, eventDisplayName[event])
+	return fmt.Sprintf("\n|%s|%s|%s|%s|%s|%s|%s|%s|%s|", ss.Alias, ss.BaseURL, formatWithName(ss.SpaceKey, ss.SpaceName), ss.getFormattedEvents(), contentTypeFilterDisplayName[ss.getContentType()], ss.getFormattedLabels(), ss.getFormattedStatus(), ss.getFormattedCreated(), ss.getFormattedUpdated())
+}
+
+// getContentType returns the content type filter, subscriptions saved without one follow both pages and blog posts.
+func (ss SpaceSubscription) getContentType() string {
+	if ss.ContentType == "" {
+		return ContentTypeFilterBoth
 	}
-	return fmt.Sprintf("\n|%s|%s|%s|%s|", ss.Alias, ss.BaseURL, ss.SpaceKey, strings.Join(events, ", "))
+	return ss.ContentType
+}
+
+// MatchesContentType reports whether content of the given Confluence content type passes the content type filter.
+// Events without a content type, like space updates, always pass.
+func (ss SpaceSubscription) MatchesContentType(contentType string) bool {
+	switch contentType {
+	case ConfluenceContentTypePage:
+		return ss.getContentType() != ContentTypeFilterBlogs
+	case ConfluenceContentTypeBlogPost:
+		return ss.getContentType() != ContentTypeFilterPages
+	default:
+		return true
+	}
+}
+
+func (ss SpaceSubscription) getFormattedLabels() string {
+	var filters []string
+	if len(ss.IncludeLabels) > 0 {
+		filters = append(filters, "Include: "+strings.Join(ss.IncludeLabels, ", "))
+	}
+	if len(ss.ExcludeLabels) > 0 {
+		filters = append(filters, "Exclude: "+strings.Join(ss.ExcludeLabels, ", "))
+	}
+	return strings.Join(filters, "; ")
+}
+
+// MatchesLabels reports whether content with the given labels passes the label filters.
+// Content must carry at least one of the included labels, when there are any, and none of the excluded ones.
+// Nil labels are unknown, like for Confluence Cloud events: the excluded labels can not be checked and are ignored,
+// but the content can not be shown to carry an included label, so it is left out.
+func (ss SpaceSubscription) MatchesLabels(labels []string) bool {
+	for _, label := range ss.ExcludeLabels {
+		if containsLabel(labels, label) {
+			return false
+		}
+	}
+
+	if len(ss.IncludeLabels) == 0 {
+		return true
+	}
+	for _, label := range ss.IncludeLabels {
+		if containsLabel(labels, label) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if strings.EqualFold(strings.TrimSpace(l), strings.TrimSpace(label)) {
+			return true
+		}
+	}
+	return false
 }
 
 func (ss SpaceSubscription) IsValid() error {
@@ -71,6 +175,17 @@ func (ss SpaceSubscription) IsValid() error {
 	if ss.ChannelID == "" {
 		return errors.New("channel id can not be empty")
 	}
+	if err := ss.validateAuthors(); err != nil {
+		return err
+	}
+	if _, ok := contentTypeFilterDisplayName[ss.getContentType()]; !ok {
+		return errors.New("content type must be one of pages, blogs or both")
+	}
+	for _, label := range ss.IncludeLabels {
+		if containsLabel(ss.ExcludeLabels, label) {
+			return fmt.Errorf("label %q can not be both included and excluded", label)
+		}
+	}
 	return nil
 }
 
@@ -97,3 +212,17 @@ func (ss SpaceSubscription) ValidateSubscription(subs *Subscriptions) error {
 	}
 	return nil
 }
+
+// Matches reports whether the event is about the subscribed space and passes the subscription filters.
+func (ss SpaceSubscription) Matches(event NotificationEvent) bool {
+	if store.GetURLSpaceKeyCombinationKey(ss.BaseURL, ss.SpaceKey) != store.GetURLSpaceKeyCombinationKey(event.BaseURL, event.SpaceKey) {
+		return false
+	}
+	if !ss.matchesEvent(event) {
+		return false
+	}
+	if event.EventType == SpaceUpdatedEvent {
+		return true
+	}
+	return ss.MatchesContentType(event.ContentType) && ss.MatchesLabels(event.Labels)
+}
@@ -0,0 +1,62 @@
+package serializer
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SubscriptionExportVersion is the version of the export format, raised when it changes in an incompatible way.
+const SubscriptionExportVersion = 1
+
+// SubscriptionExport is the JSON document written by `/confluence export` and read by `/confluence import`.
+type SubscriptionExport struct {
+	Version       int            `json:"version"`
+	ExportedAt    int64          `json:"exportedAt"`
+	Subscriptions []Subscription `json:"subscriptions"`
+	// Channels holds the team and channel names of the channels of the subscriptions by channel ID,
+	// so the subscriptions can be imported into the channels of the same names on another server.
+	Channels map[string]ExportChannel `json:"channels,omitempty"`
+}
+
+// ExportChannel is the team and channel name of a channel of an export. Direct and group messages have no team.
+type ExportChannel struct {
+	TeamName    string `json:"teamName,omitempty"`
+	ChannelName string `json:"channelName"`
+}
+
+// SubscriptionImport is a SubscriptionExport whose subscriptions are not decoded yet,
+// so each of them can be decoded and reported on separately.
+type SubscriptionImport struct {
+	Version       int                      `json:"version"`
+	Subscriptions []json.RawMessage        `json:"subscriptions"`
+	Channels      map[string]ExportChannel `json:"channels,omitempty"`
+}
+
+func SubscriptionImportFromJSON(data []byte) (*SubscriptionImport, error) {
+	var subscriptionImport SubscriptionImport
+	if err := json.Unmarshal(data, &subscriptionImport); err != nil {
+		return nil, fmt.Errorf("the file is not a subscription export: %v", err)
+	}
+	if subscriptionImport.Version != SubscriptionExportVersion {
+		return nil, fmt.Errorf("version %d of the subscription export is not supported", subscriptionImport.Version)
+	}
+	return &subscriptionImport, nil
+}
+
+// SubscriptionFromJSON returns the subscription of the JSON object, of the type of its `subscriptionType` field.
+func SubscriptionFromJSON(data []byte) (Subscription, error) {
+	var base BaseSubscription
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, err
+	}
+
+	if _, found := subscriptionTypes[base.Type]; !found {
+		return nil, fmt.Errorf("subscription type %q is not supported", base.Type)
+	}
+
+	value, err := UnmarshalCustomSubscription(data, "subscriptionType", subscriptionTypes)
+	if err != nil {
+		return nil, err
+	}
+	return value.(Subscription), nil
+}
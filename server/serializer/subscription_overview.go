@@ -0,0 +1,90 @@
+package serializer
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ChannelStatusDeleted  = "deleted"
+	ChannelStatusArchived = "archived"
+)
+
+// SubscriptionOverview is a subscription with the team and channel it posts to, for the server wide list of subscriptions.
+type SubscriptionOverview struct {
+	TeamName    string `json:"teamName,omitempty"`
+	ChannelName string `json:"channelName,omitempty"`
+	// ChannelStatus is set when the channel was deleted or archived, leaving the subscription orphaned.
+	ChannelStatus string       `json:"channelStatus,omitempty"`
+	Owner         string       `json:"owner,omitempty"`
+	Subscription  Subscription `json:"subscription"`
+}
+
+func (o SubscriptionOverview) IsOrphaned() bool {
+	return o.ChannelStatus != ""
+}
+
+// FormattedSubscriptionOverviewList returns the table of the subscriptions,
+// split into as many messages as it takes for each to fit in a post.
+func FormattedSubscriptionOverviewList(overviews []SubscriptionOverview) []string {
+	header := "| Team | Channel | Name | Subscribed To | Base Url | Events | Owner | Status |\n| :----|:--------| :--------| :-----| :-----| :-----| :-----| :-----|"
+	rows := make([]string, 0, len(overviews))
+	orphaned := 0
+	for _, overview := range overviews {
+		channel := "~" + overview.ChannelName
+		if overview.IsOrphaned() {
+			channel = fmt.Sprintf("%s (**%s**)", overview.ChannelName, overview.ChannelStatus)
+			orphaned++
+		}
+		owner := "-"
+		if overview.Owner != "" {
+			owner = "@" + overview.Owner
+		}
+
+		target, events := getFormattedTarget(overview.Subscription)
+		rows = append(rows, fmt.Sprintf("\n|%s|%s|%s|%s|%s|%s|%s|%s|", orDash(overview.TeamName), channel, overview.Subscription.GetAlias(), target,
+			overview.Subscription.GetBaseURL(), events, owner, overview.Subscription.GetPauseState().getFormattedStatus()))
+	}
+
+	footer := ""
+	if orphaned > 0 {
+		footer = fmt.Sprintf("\n\n%d of these subscriptions belong to deleted or archived channels.", orphaned)
+	}
+	return splitMessage(header, rows, footer)
+}
+
+// getFormattedTarget returns what the subscription follows, and the events it notifies.
+func getFormattedTarget(subscription Subscription) (string, string) {
+	switch s := subscription.(type) {
+	case SpaceSubscription:
+		return "Space " + formatWithName(s.SpaceKey, s.SpaceName), s.getFormattedEvents()
+	case PageSubscription:
+		return "Page " + formatWithName(s.PageID, s.PageTitle), s.getFormattedEvents()
+	case PageTreeSubscription:
+		return "Page tree " + formatWithName(s.PageID, s.PageTitle), s.getFormattedEvents()
+	case CQLSubscription:
+		return "CQL `" + strings.ReplaceAll(s.CQL, "|", "\\|") + "`", s.getFormattedEvents()
+	case AllSpacesSubscription:
+		if exclusions := s.getFormattedExclusions(); exclusions != "" {
+			return "All spaces except " + exclusions, s.getFormattedEvents()
+		}
+		return "All spaces", s.getFormattedEvents()
+	default:
+		return "-", "-"
+	}
+}
+
+func (bs BaseSubscription) getFormattedEvents() string {
+	var events []string
+	for _, event := range bs.Events {
+		events = append(events, eventDisplayName[event])
+	}
+	return strings.Join(events, ", ")
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
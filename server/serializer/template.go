@@ -0,0 +1,163 @@
+package serializer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// templateExcerptMaxRunes bounds the excerpt of the template data, like the short excerpt Confluence sends
+// in the events of the older versions, as the newer ones send the whole content.
+const templateExcerptMaxRunes = 500
+
+// TemplateData is what notification templates are rendered with, like `{{.Author}} updated [{{.Title}}]({{.URL}})`.
+// Fields the Confluence event does not carry are empty, like the space name of Confluence Cloud events.
+type TemplateData struct {
+	// Event is the event type, like `page_updated`.
+	Event string
+	// Author is the name of the user who triggered the event, the account id on Confluence Cloud.
+	Author string
+	// ContentType is the type of the content the event is about, `page` or `blogpost`, and empty for space events.
+	ContentType string
+	// Title and URL are the ones of the page or blog post, or of the content a comment was made on.
+	Title string
+	URL   string
+	// Excerpt is the beginning of the page, blog post or comment, see TemplateExcerpt.
+	Excerpt   string
+	SpaceKey  string
+	SpaceName string
+	SpaceURL  string
+	// CommentURL is the URL of the comment for comment events.
+	CommentURL string
+	// Version is the version number of the page or blog post.
+	Version int
+	// VersionComment is the comment the author gave to the new version, only sent by Confluence Server before version 9.
+	VersionComment string
+}
+
+// TemplateExcerpt returns the excerpt of the template data for the text of a page, blog post or comment,
+// cut to its beginning when it is longer than an excerpt.
+func TemplateExcerpt(text string) string {
+	return truncateRunes(strings.TrimSpace(text), templateExcerptMaxRunes)
+}
+
+// RenderTemplate renders the notification template with the data.
+// A template that can not be parsed, refers to unknown fields or renders no text is an error.
+func RenderTemplate(text string, data TemplateData) (string, error) {
+	tmpl, err := template.New("notification").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var message strings.Builder
+	if err = tmpl.Execute(&message, data); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(message.String()) == "" {
+		return "", errors.New("the template renders an empty message")
+	}
+	if utf8.RuneCountInString(message.String()) > model.PostMessageMaxRunesV2 {
+		return "", errors.New("the template renders a message longer than a post can be")
+	}
+	return message.String(), nil
+}
+
+// ValidateTemplate checks the template is for a supported event, and renders the sample data of the event.
+func ValidateTemplate(eventType, text string) error {
+	if !IsSupportedEvent(eventType) {
+		return fmt.Errorf("event **%s** is not supported, the supported events are %s", eventType, strings.Join(SupportedEvents(), ", "))
+	}
+	if _, err := RenderTemplate(text, SampleTemplateData(eventType)); err != nil {
+		return fmt.Errorf("invalid template: %s", err.Error())
+	}
+	return nil
+}
+
+// TemplatePost returns the post notifying of an event with the message rendered from the template.
+// The template replaces the whole built-in notification, attachments included.
+func TemplatePost(post *model.Post, text string, data TemplateData) (*model.Post, error) {
+	message, err := RenderTemplate(text, data)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Post{
+		UserId:    post.UserId,
+		ChannelId: post.ChannelId,
+		Type:      model.PostTypeDefault,
+		Message:   message,
+	}, nil
+}
+
+// SampleTemplateData returns the data of a made up event of the type, to preview and validate templates with.
+func SampleTemplateData(eventType string) TemplateData {
+	data := TemplateData{
+		Event:          eventType,
+		Author:         "jsmith",
+		ContentType:    ConfluenceContentTypePage,
+		Title:          "Release Notes",
+		URL:            "https://confluence.example.com/display/DOCS/Release+Notes",
+		Excerpt:        "The release brings faster search and a new editor.",
+		SpaceKey:       "DOCS",
+		SpaceName:      "Documentation",
+		SpaceURL:       "https://confluence.example.com/display/DOCS",
+		Version:        4,
+		VersionComment: "Added the known issues",
+	}
+	if strings.HasPrefix(eventType, "blog_") {
+		data.ContentType = ConfluenceContentTypeBlogPost
+		data.Title = "Release Announcement"
+		data.URL = "https://confluence.example.com/display/DOCS/2024/05/02/Release+Announcement"
+	}
+	if strings.HasPrefix(eventType, "comment_") {
+		data.Excerpt = "Should the known issues list the search limits?"
+		data.CommentURL = data.URL + "?focusedCommentId=1234#comment-1234"
+	}
+	return data
+}
+
+// FormattedTemplateList returns the templates of each event as a markdown table,
+// with the subscriptions their templates are set on, or `Default` for the default templates.
+func FormattedTemplateList(defaults map[string]string, subscriptions StringSubscription) string {
+	type row struct {
+		event, subscription, text string
+	}
+	var rows []row
+	for event, text := range defaults {
+		rows = append(rows, row{event: event, text: text})
+	}
+	for alias, subscription := range subscriptions {
+		for event, text := range subscription.GetTemplates() {
+			rows = append(rows, row{event: event, subscription: alias, text: text})
+		}
+	}
+	// The default template of an event comes first, then the ones of the subscriptions overriding it.
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].event != rows[j].event {
+			return rows[i].event < rows[j].event
+		}
+		return rows[i].subscription < rows[j].subscription
+	})
+
+	list := "| Event | Applies To | Template |\n| :----|:--------| :--------|"
+	for _, r := range rows {
+		appliesTo := "Default"
+		if r.subscription != "" {
+			appliesTo = r.subscription
+		}
+		list += fmt.Sprintf("\n|%s|%s|`%s`|", r.event, appliesTo, escapeTemplateCell(r.text))
+	}
+	return list
+}
+
+func escapeTemplateCell(text string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ", "`", "'").Replace(text)
+}
+
+func (bs BaseSubscription) GetTemplates() map[string]string {
+	return bs.Templates
+}
@@ -0,0 +1,38 @@
+package serializer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+const (
+	updateBurstMessage   = "%s was updated %d times"
+	updateBurstByAuthors = " by %s"
+)
+
+// UpdateBurstPost returns the notification of the latest update of a page, headed by the count and authors of the updates of the burst.
+// The post keeps the latest notification, so its changes and links are the current ones.
+func UpdateBurstPost(post *model.Post, data TemplateData, burst *types.UpdateBurst) *model.Post {
+	page := "The page"
+	if data.Title != "" {
+		page = formatMarkdownLink(data.Title, data.URL)
+	}
+	summary := fmt.Sprintf(updateBurstMessage, page, burst.Count)
+	if len(burst.Authors) > 0 {
+		summary += fmt.Sprintf(updateBurstByAuthors, strings.Join(burst.Authors, ", "))
+	}
+	summary += "."
+
+	updated := post.Clone()
+	updated.Id = burst.PostID
+	if updated.Message == "" {
+		updated.Message = summary
+	} else {
+		updated.Message = summary + "\n" + updated.Message
+	}
+	return updated
+}
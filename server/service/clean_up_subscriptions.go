@@ -0,0 +1,68 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+)
+
+// SubscriptionCleanUp is a subscription of a deleted or archived channel, and what the clean up did with it.
+type SubscriptionCleanUp struct {
+	Subscription serializer.Subscription
+	ChannelName  string
+	// Removed is set when the channel was deleted, otherwise the channel is archived and the subscription was disabled.
+	Removed bool
+}
+
+// CleanUpSubscriptions removes the subscriptions of deleted channels and disables the ones of archived channels,
+// so no notification is posted to them anymore. Subscriptions are enabled again when their channel is restored.
+// It returns the subscriptions it removed or disabled, the ones already disabled are not returned again.
+func CleanUpSubscriptions() ([]SubscriptionCleanUp, error) {
+	subscriptions, err := GetSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	var cleanUps []SubscriptionCleanUp
+	for channelID, channelSubscriptions := range subscriptions.ByChannelID {
+		if len(channelSubscriptions) == 0 {
+			continue
+		}
+
+		channel, appErr := config.Mattermost.GetChannel(channelID)
+		if appErr != nil && appErr.StatusCode != http.StatusNotFound {
+			config.Mattermost.LogWarn("Unable to get the channel of subscriptions to clean up.", "ChannelID", channelID, "Error", appErr.Error())
+			continue
+		}
+
+		for _, subscription := range channelSubscriptions {
+			switch {
+			case appErr != nil:
+				if rErr := modifySubscriptionRecords(subscription.Remove, subscription); rErr != nil {
+					config.Mattermost.LogError("Unable to remove the subscription of a deleted channel.", "ChannelID", channelID, "Subscription", subscription.GetAlias(), "Error", rErr.Error())
+					continue
+				}
+				cleanUps = append(cleanUps, SubscriptionCleanUp{Subscription: subscription, ChannelName: channelID, Removed: true})
+			case channel.DeleteAt > 0 && !subscription.GetPauseState().Disabled:
+				if dErr := setSubscriptionDisabled(subscription, true); dErr != nil {
+					config.Mattermost.LogError("Unable to disable the subscription of an archived channel.", "ChannelID", channelID, "Subscription", subscription.GetAlias(), "Error", dErr.Error())
+					continue
+				}
+				cleanUps = append(cleanUps, SubscriptionCleanUp{Subscription: subscription, ChannelName: channel.Name})
+			case channel.DeleteAt == 0 && subscription.GetPauseState().Disabled:
+				if dErr := setSubscriptionDisabled(subscription, false); dErr != nil {
+					config.Mattermost.LogError("Unable to enable the subscription of a restored channel.", "ChannelID", channelID, "Subscription", subscription.GetAlias(), "Error", dErr.Error())
+				}
+			}
+		}
+	}
+	return cleanUps, nil
+}
+
+func setSubscriptionDisabled(subscription serializer.Subscription, disabled bool) error {
+	_, err := modifyPauseState(subscription.GetChannelID(), subscription.GetAlias(), func(pauseState *serializer.PauseState) {
+		pauseState.Disabled = disabled
+	})
+	return err
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+)
+
+func TestCleanUpSubscriptions(t *testing.T) {
+	defer monkey.UnpatchAll()
+	deletedSubscription := serializer.SpaceSubscription{
+		SpaceKey:         "TS",
+		BaseSubscription: serializer.BaseSubscription{Alias: "deleted", BaseURL: "https://test.com", ChannelID: "deletedchannel"},
+	}
+	archivedSubscription := serializer.PageSubscription{
+		PageID:           "1234",
+		BaseSubscription: serializer.BaseSubscription{Alias: "archived", BaseURL: "https://test.com", ChannelID: "archivedchannel"},
+	}
+	disabledSubscription := serializer.PageSubscription{
+		PageID: "1234",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:      "disabled",
+			BaseURL:    "https://test.com",
+			ChannelID:  "archivedchannel",
+			PauseState: serializer.PauseState{Disabled: true},
+		},
+	}
+	restoredSubscription := serializer.PageSubscription{
+		PageID: "1234",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:      "restored",
+			BaseURL:    "https://test.com",
+			ChannelID:  "restoredchannel",
+			PauseState: serializer.PauseState{Disabled: true},
+		},
+	}
+
+	mockAPI := baseMock()
+	mockAPI.On("GetChannel", "deletedchannel").Return(nil, &model.AppError{StatusCode: http.StatusNotFound})
+	mockAPI.On("GetChannel", "archivedchannel").Return(&model.Channel{Id: "archivedchannel", Name: "archived", DeleteAt: 1}, nil)
+	mockAPI.On("GetChannel", "restoredchannel").Return(&model.Channel{Id: "restoredchannel", Name: "restored"}, nil)
+
+	monkey.Patch(GetSubscriptions, func() (serializer.Subscriptions, error) {
+		subscriptions := serializer.NewSubscriptions()
+		deletedSubscription.Add(subscriptions)
+		archivedSubscription.Add(subscriptions)
+		disabledSubscription.Add(subscriptions)
+		restoredSubscription.Add(subscriptions)
+		return *subscriptions, nil
+	})
+	var removed []string
+	monkey.Patch(modifySubscriptionRecords, func(modify func(*serializer.Subscriptions), subscriptions ...serializer.Subscription) error {
+		for _, subscription := range subscriptions {
+			removed = append(removed, subscription.GetAlias())
+		}
+		return nil
+	})
+	disabled := map[string]bool{}
+	monkey.Patch(modifyPauseState, func(channelID, alias string, modify func(pauseState *serializer.PauseState)) (serializer.PauseState, error) {
+		var pauseState serializer.PauseState
+		modify(&pauseState)
+		disabled[alias] = pauseState.Disabled
+		return serializer.PauseState{}, nil
+	})
+
+	cleanUps, err := CleanUpSubscriptions()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []SubscriptionCleanUp{
+		{Subscription: deletedSubscription, ChannelName: "deletedchannel", Removed: true},
+		{Subscription: archivedSubscription, ChannelName: "archived"},
+	}, cleanUps)
+	assert.Equal(t, []string{"deleted"}, removed)
+	assert.Equal(t, map[string]bool{"archived": true, "restored": false}, disabled)
+}
@@ -7,10 +7,11 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"path"
 	"strings"
 
 	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/util"
 )
 
 const ErrorStatusNotFound = "No content found"
@@ -23,35 +24,20 @@ type ErrorResponse struct {
 	Message string `json:"message"`
 }
 
-func NormalizeConfluenceURL(confluenceURL string) (string, error) {
-	u, err := url.Parse(confluenceURL)
-	if err != nil {
-		return "", fmt.Errorf("could not parse confluence url: %w", err)
-	}
-
-	// If the parsed URL does not contain a host, trying to extract the host from the path
-	if u.Host == "" {
-		ss := strings.Split(u.Path, "/")
-		if len(ss) > 0 && ss[0] != "" {
-			u.Host = ss[0]
-			u.Path = path.Join(ss[1:]...)
-		}
-		u, err = url.Parse(u.String())
-		if err != nil {
-			return "", err
-		}
-	}
+// ResponseError is returned when Confluence responds with an error status, so callers can tell a missing
+// or forbidden resource from other failures.
+type ResponseError struct {
+	StatusCode int
+	Message    string
+}
 
-	// If the URL still lacks a hostname, return an error
-	if u.Host == "" {
-		return "", errors.Errorf("Invalid URL, no hostname: %q", confluenceURL)
-	}
-	if u.Scheme == "" {
-		u.Scheme = "https"
-	}
+func (e *ResponseError) Error() string {
+	return e.Message
+}
 
-	confluenceURL = strings.TrimSuffix(u.String(), "/")
-	return confluenceURL, nil
+// NormalizeConfluenceURL returns the URL with a scheme and without a trailing slash. See util.NormalizeConfluenceURL.
+func NormalizeConfluenceURL(confluenceURL string) (string, error) {
+	return util.NormalizeConfluenceURL(confluenceURL)
 }
 
 // CheckConfluenceURL checks if the `/status` endpoint of the Confluence URL is accessible
@@ -157,8 +143,8 @@ func call(basePath, method, path, contentType string, inBody io.Reader, out inte
 
 	errResp := ErrorResponse{}
 	if json.Unmarshal(responseData, &errResp) == nil && errResp.Message != "" {
-		return nil, statusCode, errors.New(errResp.Message)
+		return nil, statusCode, &ResponseError{StatusCode: statusCode, Message: errResp.Message}
 	}
 
-	return nil, statusCode, errors.Errorf("unexpected response status: %d", statusCode)
+	return nil, statusCode, &ResponseError{StatusCode: statusCode, Message: fmt.Sprintf("unexpected response status: %d", statusCode)}
 }
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	invalidConfluenceURL = "**%s** is not a Confluence space or page URL"
+	invalidTinyLink      = "**%s** is not a valid Confluence short link"
+	pageTitleNeedsLookup = "the page of **%s** can only be found by its title, please connect your Confluence account or use the page ID"
+
+	// tinyIDLength is the length of the base64 encoding of a 64-bit page ID without its padding.
+	tinyIDLength = 11
+)
+
+// confluenceURLPaths are the path segments that start the part of a Confluence URL after its base URL.
+var confluenceURLPaths = []string{"display", "spaces", "pages", "x"}
+
+// ConfluenceURL is what a space or page URL copied from the browser points to.
+type ConfluenceURL struct {
+	BaseURL  string
+	SpaceKey string
+	PageID   string
+	// PageTitle is set instead of PageID by `/display/<space-key>/<title>` URLs, which only name the page.
+	PageTitle string
+}
+
+// PageFinder looks up pages with the Confluence REST API, acting as the connected user.
+type PageFinder interface {
+	GetPageIDByTitle(spaceKey, title string) (string, error)
+}
+
+// IsConfluenceURL reports whether the value is a URL rather than a space key or page ID.
+func IsConfluenceURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// ParseConfluenceURL returns what the URL points to. These forms are supported, the base URL being
+// whatever comes before them, like `https://example.atlassian.net/wiki`:
+//   - `/display/<space-key>` and `/display/<space-key>/<title>`
+//   - `/spaces/<space-key>/...` and `/spaces/<space-key>/pages/<page-id>/<title>`
+//   - `/spaces/viewspace.action?key=<space-key>` and `/pages/viewpage.action?pageId=<page-id>`
+//   - `/x/<tiny-id>` short links, whose tiny ID encodes the page ID
+func ParseConfluenceURL(rawURL string) (*ConfluenceURL, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf(invalidConfluenceURL, rawURL)
+	}
+
+	// The escaped path is split, so titles containing an encoded `/` or `+` are kept whole.
+	segments := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+	start := -1
+	for i, segment := range segments {
+		if slices.Contains(confluenceURLPaths, segment) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf(invalidConfluenceURL, rawURL)
+	}
+
+	baseURL, err := NormalizeConfluenceURL(u.Scheme + "://" + u.Host + "/" + strings.Join(segments[:start], "/"))
+	if err != nil {
+		return nil, fmt.Errorf(invalidConfluenceURL, rawURL)
+	}
+	confluenceURL := &ConfluenceURL{BaseURL: baseURL}
+
+	segments = segments[start:]
+	switch {
+	case len(segments) == 2 && segments[0] == "pages" && segments[1] == "viewpage.action":
+		confluenceURL.PageID = u.Query().Get("pageId")
+	case len(segments) == 2 && segments[0] == "spaces" && segments[1] == "viewspace.action":
+		confluenceURL.SpaceKey = u.Query().Get("key")
+	case segments[0] == "x" && len(segments) == 2:
+		if confluenceURL.PageID, err = decodeTinyID(segments[1]); err != nil {
+			return nil, fmt.Errorf(invalidTinyLink, rawURL)
+		}
+	case segments[0] == "display" && len(segments) >= 2:
+		confluenceURL.SpaceKey, _ = url.PathUnescape(segments[1])
+		if len(segments) >= 3 {
+			// Spaces in titles are encoded as `+` in display URLs.
+			confluenceURL.PageTitle, _ = url.QueryUnescape(segments[2])
+		}
+	case segments[0] == "spaces" && len(segments) >= 2:
+		confluenceURL.SpaceKey, _ = url.PathUnescape(segments[1])
+		if len(segments) >= 4 && segments[2] == "pages" {
+			confluenceURL.PageID = segments[3]
+		}
+	}
+
+	if confluenceURL.PageID != "" {
+		if _, err = strconv.ParseUint(confluenceURL.PageID, 10, 64); err != nil {
+			return nil, fmt.Errorf(invalidConfluenceURL, rawURL)
+		}
+	}
+	if confluenceURL.SpaceKey == "" && confluenceURL.PageID == "" {
+		return nil, fmt.Errorf(invalidConfluenceURL, rawURL)
+	}
+	return confluenceURL, nil
+}
+
+// IsPage reports whether the URL points to a page rather than to a space.
+func (c *ConfluenceURL) IsPage() bool {
+	return c.PageID != "" || c.PageTitle != ""
+}
+
+// ResolvePageID returns the ID of the page of the URL, finding it by its title with finder when the URL only names it.
+// The finder may be nil when the user has no Confluence account connected.
+func (c *ConfluenceURL) ResolvePageID(finder PageFinder) (string, error) {
+	if c.PageID != "" {
+		return c.PageID, nil
+	}
+	if c.PageTitle == "" {
+		return "", errors.New("the url does not point to a page")
+	}
+	if finder == nil {
+		return "", fmt.Errorf(pageTitleNeedsLookup, c.PageTitle)
+	}
+	return finder.GetPageIDByTitle(c.SpaceKey, c.PageTitle)
+}
+
+// decodeTinyID returns the page ID of a tiny ID of a short link, which is the page ID as little endian bytes
+// encoded in base64 with `-` and `_` for `/` and `+`, and without its trailing zero bytes and padding.
+func decodeTinyID(tinyID string) (string, error) {
+	if tinyID == "" || len(tinyID) > tinyIDLength {
+		return "", errors.New("invalid tiny id length")
+	}
+
+	encoded := strings.NewReplacer("-", "/", "_", "+").Replace(tinyID)
+	encoded += strings.Repeat("A", tinyIDLength-len(encoded)) + "="
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	pageID := binary.LittleEndian.Uint64(decoded)
+	if pageID == 0 {
+		return "", errors.New("invalid tiny id")
+	}
+	return strconv.FormatUint(pageID, 10), nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPageFinder struct{}
+
+func (testPageFinder) GetPageIDByTitle(spaceKey, title string) (string, error) {
+	if spaceKey == "ENG" && title == "Release Notes+FAQ" {
+		return "777", nil
+	}
+	return "", &ResponseError{StatusCode: 404, Message: "page not found"}
+}
+
+func TestParseConfluenceURL(t *testing.T) {
+	for name, val := range map[string]struct {
+		url         string
+		expected    *ConfluenceURL
+		expectError bool
+	}{
+		"display space": {
+			url:      "https://confluence.example.com/display/ENG",
+			expected: &ConfluenceURL{BaseURL: "https://confluence.example.com", SpaceKey: "ENG"},
+		},
+		"display page": {
+			url:      "https://confluence.example.com/confluence/display/ENG/Release+Notes%2BFAQ",
+			expected: &ConfluenceURL{BaseURL: "https://confluence.example.com/confluence", SpaceKey: "ENG", PageTitle: "Release Notes+FAQ"},
+		},
+		"cloud page": {
+			url:      "https://example.atlassian.net/wiki/spaces/ENG/pages/12345/Runbook",
+			expected: &ConfluenceURL{BaseURL: "https://example.atlassian.net/wiki", SpaceKey: "ENG", PageID: "12345"},
+		},
+		"space overview": {
+			url:      "https://example.atlassian.net/wiki/spaces/ENG/overview",
+			expected: &ConfluenceURL{BaseURL: "https://example.atlassian.net/wiki", SpaceKey: "ENG"},
+		},
+		"view page action": {
+			url:      "https://confluence.example.com/pages/viewpage.action?pageId=98310",
+			expected: &ConfluenceURL{BaseURL: "https://confluence.example.com", PageID: "98310"},
+		},
+		"view space action": {
+			url:      "https://confluence.example.com/spaces/viewspace.action?key=ENG",
+			expected: &ConfluenceURL{BaseURL: "https://confluence.example.com", SpaceKey: "ENG"},
+		},
+		"tiny link": {
+			url:      "https://confluence.example.com/x/BoAB",
+			expected: &ConfluenceURL{BaseURL: "https://confluence.example.com", PageID: "98310"},
+		},
+		"tiny link with a trailing slash": {
+			url:      "https://confluence.example.com/x/OT/",
+			expected: &ConfluenceURL{BaseURL: "https://confluence.example.com", PageID: "12345"},
+		},
+		"invalid tiny link": {
+			url:         "https://confluence.example.com/x/AAAA",
+			expectError: true,
+		},
+		"non numeric page id": {
+			url:         "https://confluence.example.com/pages/viewpage.action?pageId=abc",
+			expectError: true,
+		},
+		"other url": {
+			url:         "https://confluence.example.com/login.action",
+			expectError: true,
+		},
+		"not a url": {
+			url:         "ENG",
+			expectError: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			confluenceURL, err := ParseConfluenceURL(val.url)
+			if val.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, val.expected, confluenceURL)
+		})
+	}
+}
+
+func TestResolvePageID(t *testing.T) {
+	pageURL := &ConfluenceURL{BaseURL: "https://confluence.example.com", PageID: "12345"}
+	pageID, err := pageURL.ResolvePageID(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "12345", pageID)
+
+	titleURL := &ConfluenceURL{BaseURL: "https://confluence.example.com", SpaceKey: "ENG", PageTitle: "Release Notes+FAQ"}
+	_, err = titleURL.ResolvePageID(nil)
+	assert.Error(t, err)
+
+	pageID, err = titleURL.ResolvePageID(testPageFinder{})
+	assert.NoError(t, err)
+	assert.Equal(t, "777", pageID)
+
+	spaceURL := &ConfluenceURL{BaseURL: "https://confluence.example.com", SpaceKey: "ENG"}
+	_, err = spaceURL.ResolvePageID(testPageFinder{})
+	assert.Error(t, err)
+}
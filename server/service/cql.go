@@ -0,0 +1,196 @@
+package service
+
+import (
+	"container/list"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+)
+
+const (
+	// cqlCacheTTL is how long the result of a CQL search for a content is reused,
+	// so several subscriptions with the same query and bursts of events on a page search Confluence once.
+	cqlCacheTTL = time.Minute
+	// cqlCacheSize bounds the search results kept, the least recently used one is dropped to make room for a new one.
+	cqlCacheSize = 1000
+
+	cqlNotSupported = "CQL subscriptions are only supported on Confluence Server and Data Center instances of version 9 or later, %s is not one"
+)
+
+// CQLSearcher runs the CQL searches of the CQL subscriptions on a Confluence instance.
+type CQLSearcher interface {
+	// ContentMatchesCQL reports whether the content with the ID is found by the CQL expression.
+	ContentMatchesCQL(cql, contentID string) (bool, error)
+}
+
+type cqlCacheEntry struct {
+	key     string
+	matches bool
+	expiry  time.Time
+}
+
+// cqlResultCache is a least recently used cache of CQL search results, so adding and dropping a result takes constant time.
+type cqlResultCache struct {
+	sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+func newCQLResultCache(size int) *cqlResultCache {
+	return &cqlResultCache{size: size, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+var cqlCache = newCQLResultCache(cqlCacheSize)
+
+// get returns the result cached for the key, unless there is none or it expired.
+func (c *cqlResultCache) get(key string, now time.Time) (bool, bool) {
+	c.Lock()
+	defer c.Unlock()
+	element, found := c.entries[key]
+	if !found {
+		return false, false
+	}
+	entry := element.Value.(*cqlCacheEntry)
+	if now.After(entry.expiry) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return false, false
+	}
+	c.order.MoveToFront(element)
+	return entry.matches, true
+}
+
+func (c *cqlResultCache) put(key string, matches bool, expiry time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	if element, found := c.entries[key]; found {
+		element.Value = &cqlCacheEntry{key: key, matches: matches, expiry: expiry}
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cqlCacheEntry{key: key, matches: matches, expiry: expiry})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cqlCacheEntry).key)
+	}
+}
+
+// CheckCQLSubscription refuses a CQL subscription for an instance whose events come without the means to run CQL searches,
+// which is any instance but the installed Server and Data Center instances of version 9 or later.
+// The events of Confluence Cloud and of the legacy Server webhooks would never notify it.
+func CheckCQLSubscription(subscription serializer.Subscription) (int, error) {
+	s, ok := subscription.(serializer.CQLSubscription)
+	if !ok {
+		return http.StatusOK, nil
+	}
+	if store.IsConfluenceCloudURL(s.BaseURL) {
+		return http.StatusBadRequest, fmt.Errorf(cqlNotSupported, s.BaseURL)
+	}
+
+	instanceURL, err := NormalizeConfluenceURL(s.BaseURL)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	instance, err := store.LoadInstance(instanceURL)
+	if err != nil {
+		if errors.Cause(err) == store.ErrNotFound {
+			return http.StatusBadRequest, fmt.Errorf(cqlNotSupported, s.BaseURL)
+		}
+		return http.StatusInternalServerError, err
+	}
+	if !instance.ServerVersionGreaterthan9 {
+		return http.StatusBadRequest, fmt.Errorf(cqlNotSupported, s.BaseURL)
+	}
+	return http.StatusOK, nil
+}
+
+// GetCQLChannelIDs returns the channels with a CQL subscription for the event type whose query finds the content of the event.
+// The queries found to match are recorded in the event, for the subscriptions to match it afterwards.
+func GetCQLChannelIDs(event *serializer.NotificationEvent, searcher CQLSearcher) []string {
+	if searcher == nil || event.PageID == "" {
+		return nil
+	}
+
+	urlCQLSubscriptions, err := GetSubscriptionsByURLCQL(event.BaseURL)
+	if err != nil {
+		config.Mattermost.LogError("Unable to get subscribed channels for CQL.", "Error", err.Error())
+		return nil
+	}
+
+	var channelIDs []string
+	for channelID, aliases := range urlCQLSubscriptions {
+		channelSubscriptions, gErr := GetSubscriptionsByChannelID(channelID)
+		if gErr != nil {
+			config.Mattermost.LogError("Unable to get channel subscriptions.", "ChannelID", channelID, "Error", gErr.Error())
+			continue
+		}
+		for _, alias := range aliases {
+			subscription, ok := channelSubscriptions[alias].(serializer.CQLSubscription)
+			if !ok || !slices.Contains(subscription.Events, event.EventType) {
+				continue
+			}
+			if matchesCQL(event, subscription.CQL, searcher) {
+				channelIDs = append(channelIDs, channelID)
+			}
+		}
+	}
+	return channelIDs
+}
+
+// warnUnsupportedCQLSubscriptions logs the channels with CQL subscriptions for the instance of an event that came without
+// the means to run CQL searches, like the events of Confluence Cloud and of the Server versions before 9.
+// Saving such subscriptions is refused by CheckCQLSubscription, so these are left from an instance that was installed again with another version.
+func warnUnsupportedCQLSubscriptions(event serializer.NotificationEvent) {
+	urlCQLSubscriptions, err := GetSubscriptionsByURLCQL(event.BaseURL)
+	if err != nil {
+		config.Mattermost.LogError("Unable to get subscribed channels for CQL.", "Error", err.Error())
+		return
+	}
+	if len(urlCQLSubscriptions) == 0 {
+		return
+	}
+
+	channelIDs := make([]string, 0, len(urlCQLSubscriptions))
+	for channelID := range urlCQLSubscriptions {
+		channelIDs = append(channelIDs, channelID)
+	}
+	slices.Sort(channelIDs)
+	config.Mattermost.LogWarn("CQL subscriptions are only supported on Confluence Server and Data Center instances of version 9 or later, they are skipped for this event.", "BaseURL", event.BaseURL, "ChannelIDs", strings.Join(channelIDs, ", "))
+}
+
+// matchesCQL reports whether the content of the event is found by the query, reusing a recent search result when there is one.
+func matchesCQL(event *serializer.NotificationEvent, cql string, searcher CQLSearcher) bool {
+	if matches, searched := event.MatchedCQL[cql]; searched {
+		return matches
+	}
+
+	key := event.BaseURL + "|" + cql + "|" + event.PageID
+	now := time.Now()
+	matches, cached := cqlCache.get(key, now)
+	if !cached {
+		var err error
+		if matches, err = searcher.ContentMatchesCQL(cql, event.PageID); err != nil {
+			config.Mattermost.LogError("Unable to run the CQL search of a subscription.", "CQL", cql, "Error", err.Error())
+			return false
+		}
+		cqlCache.put(key, matches, now.Add(cqlCacheTTL))
+	}
+
+	if event.MatchedCQL == nil {
+		event.MatchedCQL = map[string]bool{}
+	}
+	event.MatchedCQL[cql] = matches
+	return matches
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+type testCQLSearcher struct {
+	matches  map[string]bool
+	searches int
+}
+
+func (s *testCQLSearcher) ContentMatchesCQL(cql, contentID string) (bool, error) {
+	s.searches++
+	return s.matches[cql], nil
+}
+
+func TestGetCQLChannelIDs(t *testing.T) {
+	defer monkey.UnpatchAll()
+	incidents := serializer.CQLSubscription{
+		CQL: "label = incident",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "incidents",
+			BaseURL:   "https://test.com",
+			ChannelID: "testtesttesttest",
+			Events:    []string{serializer.PageUpdatedEvent},
+			Type:      serializer.SubscriptionTypeCQL,
+		},
+	}
+	sameQuery := incidents
+	sameQuery.Alias = "other incidents"
+	sameQuery.ChannelID = "testtesttest1234"
+	runbooks := serializer.CQLSubscription{
+		CQL: "label = runbook",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "runbooks",
+			BaseURL:   "https://test.com",
+			ChannelID: "testtesttest5678",
+			Events:    []string{serializer.PageUpdatedEvent},
+			Type:      serializer.SubscriptionTypeCQL,
+		},
+	}
+	createdOnly := serializer.CQLSubscription{
+		CQL: "label = created",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "created",
+			BaseURL:   "https://test.com",
+			ChannelID: "testtesttest5678",
+			Events:    []string{serializer.PageCreatedEvent},
+			Type:      serializer.SubscriptionTypeCQL,
+		},
+	}
+
+	subscriptions := serializer.NewSubscriptions()
+	for _, subscription := range []serializer.Subscription{incidents, sameQuery, runbooks, createdOnly} {
+		subscription.Add(subscriptions)
+	}
+	monkey.Patch(GetSubscriptionsByURLCQL, func(url string) (serializer.StringArrayMap, error) {
+		return subscriptions.ByURLCQL[store.GetURLCombinationKey(url)], nil
+	})
+	monkey.Patch(GetSubscriptionsByChannelID, func(channelID string) (serializer.StringSubscription, error) {
+		return subscriptions.ByChannelID[channelID], nil
+	})
+
+	searcher := &testCQLSearcher{matches: map[string]bool{"label = incident": true}}
+	event := &serializer.NotificationEvent{
+		BaseURL:   "https://test.com",
+		PageID:    "1234",
+		EventType: serializer.PageUpdatedEvent,
+	}
+	channelIDs := GetCQLChannelIDs(event, searcher)
+	assert.ElementsMatch(t, []string{"testtesttesttest", "testtesttest1234"}, channelIDs)
+	assert.Equal(t, 2, searcher.searches)
+	assert.Equal(t, map[string]bool{"label = incident": true, "label = runbook": false}, event.MatchedCQL)
+	assert.True(t, incidents.Matches(*event))
+	assert.False(t, runbooks.Matches(*event))
+
+	repeated := &serializer.NotificationEvent{
+		BaseURL:   "https://test.com",
+		PageID:    "1234",
+		EventType: serializer.PageUpdatedEvent,
+	}
+	assert.ElementsMatch(t, channelIDs, GetCQLChannelIDs(repeated, searcher))
+	assert.Equal(t, 2, searcher.searches, "recent search results are reused")
+
+	spaceEvent := &serializer.NotificationEvent{
+		BaseURL:   "https://test.com",
+		SpaceKey:  "TEST",
+		EventType: serializer.PageUpdatedEvent,
+	}
+	assert.Empty(t, GetCQLChannelIDs(spaceEvent, searcher))
+	assert.Empty(t, GetCQLChannelIDs(event, nil))
+}
+
+func TestCQLResultCache(t *testing.T) {
+	cache := newCQLResultCache(2)
+	now := time.Now()
+	cache.put("first", true, now.Add(time.Minute))
+	cache.put("second", false, now.Add(time.Minute))
+
+	matches, found := cache.get("first", now)
+	assert.True(t, found)
+	assert.True(t, matches)
+
+	cache.put("third", true, now.Add(time.Minute))
+	_, found = cache.get("second", now)
+	assert.False(t, found, "the least recently used result is dropped")
+	_, found = cache.get("first", now)
+	assert.True(t, found)
+
+	_, found = cache.get("third", now.Add(2*time.Minute))
+	assert.False(t, found, "expired results are not reused")
+	assert.Equal(t, 1, cache.order.Len())
+	assert.Len(t, cache.entries, 1)
+}
+
+func TestCheckCQLSubscription(t *testing.T) {
+	defer monkey.UnpatchAll()
+	monkey.Patch(store.LoadInstance, func(instanceURL string) (*types.Instance, error) {
+		switch instanceURL {
+		case "https://test.com":
+			return &types.Instance{URL: instanceURL, ServerVersionGreaterthan9: true}, nil
+		case "https://legacy.com":
+			return &types.Instance{URL: instanceURL}, nil
+		case "https://test.atlassian.net":
+			return &types.Instance{URL: instanceURL, ServerVersionGreaterthan9: true}, nil
+		default:
+			return nil, store.ErrNotFound
+		}
+	})
+
+	for name, val := range map[string]struct {
+		subscription serializer.Subscription
+		statusCode   int
+	}{
+		"server 9 instance": {
+			subscription: serializer.CQLSubscription{CQL: "label = incident", BaseSubscription: serializer.BaseSubscription{BaseURL: "https://test.com"}},
+			statusCode:   http.StatusOK,
+		},
+		"server instance before version 9": {
+			subscription: serializer.CQLSubscription{CQL: "label = incident", BaseSubscription: serializer.BaseSubscription{BaseURL: "https://legacy.com"}},
+			statusCode:   http.StatusBadRequest,
+		},
+		"cloud instance": {
+			subscription: serializer.CQLSubscription{CQL: "label = incident", BaseSubscription: serializer.BaseSubscription{BaseURL: "https://test.atlassian.net/wiki"}},
+			statusCode:   http.StatusBadRequest,
+		},
+		"instance not installed": {
+			subscription: serializer.CQLSubscription{CQL: "label = incident", BaseSubscription: serializer.BaseSubscription{BaseURL: "https://unknown.com"}},
+			statusCode:   http.StatusBadRequest,
+		},
+		"space subscription": {
+			subscription: serializer.SpaceSubscription{SpaceKey: "TS", BaseSubscription: serializer.BaseSubscription{BaseURL: "https://test.atlassian.net/wiki"}},
+			statusCode:   http.StatusOK,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			statusCode, err := CheckCQLSubscription(val.subscription)
+			assert.Equal(t, val.statusCode, statusCode)
+			if val.statusCode == http.StatusOK {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, fmt.Sprintf(cqlNotSupported, val.subscription.GetBaseURL()))
+			}
+		})
+	}
+}
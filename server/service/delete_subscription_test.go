@@ -90,7 +90,7 @@ func TestDeleteSubscription(t *testing.T) {
 					},
 				},
 			}
-			monkey.Patch(GetSubscriptions, func() (serializer.Subscriptions, error) {
+			monkey.Patch(loadSubscriptionRecords, func(...subscriptionRecord) (serializer.Subscriptions, error) {
 				return subscriptions, nil
 			})
 			monkey.Patch(store.AtomicModify, func(key string, modify func(initialValue []byte) ([]byte, error)) error {
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+// maxDigestEvents bounds the events held for the digest of a channel, so a busy space can not grow it past the KV value limit.
+const maxDigestEvents = 500
+
+// NotifyChannel delivers the notification of the event to the channel of the post,
+// queueing it for the digest of the channel if it has one or else posting it right away.
+// Updates of a page within the debounce window of its first update notification edit that notification.
+func NotifyChannel(post *model.Post, event serializer.NotificationEvent, data serializer.TemplateData) error {
+	digest, err := store.LoadChannelDigest(post.ChannelId)
+	if err != nil {
+		// The notification is posted rather than lost.
+		config.Mattermost.LogError("Unable to get the channel digest.", "ChannelID", post.ChannelId, "Error", err.Error())
+	}
+	if digest != nil {
+		return store.QueueDigestEvent(post.ChannelId, serializer.NewDigestEvent(post, event, data, time.Now()), maxDigestEvents)
+	}
+
+	if window := config.GetConfig().GetUpdateDebounce(); window > 0 && event.EventType == serializer.PageUpdatedEvent && event.PageID != "" {
+		return createPageUpdatePost(post, event, data, window)
+	}
+	_, err = CreateNotificationPost(post, event)
+	return err
+}
+
+// DeliverDueDigests posts the digests due at the given time, as the given user.
+// Channels whose digest was turned off meanwhile receive the notifications left in their queue right away.
+// The events are only removed from the queue once their digest is posted, a digest that fails is posted again the next time.
+func DeliverDueDigests(userID string, now time.Time) error {
+	pending, err := store.LoadPendingDigests()
+	if err != nil {
+		return err
+	}
+
+	for channelID, since := range pending {
+		digest, dErr := store.LoadChannelDigest(channelID)
+		if dErr != nil {
+			config.Mattermost.LogError("Unable to get the channel digest.", "ChannelID", channelID, "Error", dErr.Error())
+			continue
+		}
+		if digest.NextDelivery(time.UnixMilli(since)).After(now) {
+			continue
+		}
+
+		queue, qErr := store.LoadDigestQueue(channelID)
+		if qErr != nil {
+			config.Mattermost.LogError("Unable to get the digest queue.", "ChannelID", channelID, "Error", qErr.Error())
+			continue
+		}
+		if len(queue.Events) > 0 && !postDigest(userID, channelID, queue) {
+			continue
+		}
+		if rErr := store.RemoveDigestEvents(channelID, queue); rErr != nil {
+			config.Mattermost.LogError("Unable to remove the delivered events from the digest queue.", "ChannelID", channelID, "Error", rErr.Error())
+		}
+	}
+	return nil
+}
+
+// postDigest posts the digest of the queue to the channel, in several posts when it does not fit in one,
+// and reports whether all of them were created. It stops at the first failure.
+func postDigest(userID, channelID string, queue *types.DigestQueue) bool {
+	for _, message := range serializer.FormattedDigest(queue) {
+		post := &model.Post{
+			UserId:    userID,
+			ChannelId: channelID,
+			Message:   message,
+		}
+		if _, appErr := config.Mattermost.CreatePost(post); appErr != nil {
+			config.Mattermost.LogError("Unable to post the digest.", "ChannelID", channelID, "Events", len(queue.Events), "Error", appErr.Error())
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"bou.ke/monkey"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+func TestNotifyChannel(t *testing.T) {
+	for name, val := range map[string]struct {
+		digest         *types.Digest
+		expectedQueued bool
+	}{
+		"channel without digest is notified right away": {},
+		"channel with digest queues the notification": {
+			digest:         &types.Digest{Mode: types.DigestHourly},
+			expectedQueued: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer monkey.UnpatchAll()
+			config.SetConfig(&config.Configuration{})
+			mockAPI := baseMock()
+			mockAPI.On("CreatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{Id: "newpost"}, nil)
+
+			monkey.Patch(store.LoadChannelDigest, func(channelID string) (*types.Digest, error) {
+				return val.digest, nil
+			})
+			var queued []types.DigestEvent
+			monkey.Patch(store.QueueDigestEvent, func(channelID string, event types.DigestEvent, maxEvents int) error {
+				queued = append(queued, event)
+				return nil
+			})
+
+			post := &model.Post{ChannelId: "testtesttesttest", Message: "jsmith updated Release Notes"}
+			event := serializer.NotificationEvent{SpaceKey: "DOC", PageID: "1234", EventType: serializer.PageUpdatedEvent}
+			data := serializer.TemplateData{Title: "Release Notes", URL: "https://test.atlassian.net/wiki/1234", SpaceName: "Documentation"}
+			assert.NoError(t, NotifyChannel(post, event, data))
+
+			if !val.expectedQueued {
+				assert.Empty(t, queued)
+				mockAPI.AssertNumberOfCalls(t, "CreatePost", 1)
+				return
+			}
+			mockAPI.AssertNotCalled(t, "CreatePost", mock.Anything)
+			assert.Len(t, queued, 1)
+			assert.Equal(t, "1234", queued[0].PageID)
+			assert.Equal(t, "Release Notes", queued[0].Title)
+			assert.Equal(t, "jsmith updated Release Notes", queued[0].Message)
+		})
+	}
+}
+
+func TestDeliverDueDigests(t *testing.T) {
+	defer monkey.UnpatchAll()
+	mockAPI := baseMock()
+	var posts []*model.Post
+	mockAPI.On("CreatePost", mock.AnythingOfType("*model.Post")).Run(func(args mock.Arguments) {
+		posts = append(posts, args.Get(0).(*model.Post))
+	}).Return(&model.Post{Id: "newpost"}, nil)
+
+	now := time.Date(2024, 3, 4, 9, 2, 0, 0, time.UTC)
+	monkey.Patch(store.LoadPendingDigests, func() (map[string]int64, error) {
+		return map[string]int64{
+			"hourlychannel": now.Add(-time.Hour).UnixMilli(),
+			"dailychannel":  now.Add(-time.Hour).UnixMilli(),
+			"offchannel":    now.Add(-time.Minute).UnixMilli(),
+		}, nil
+	})
+	monkey.Patch(store.LoadChannelDigest, func(channelID string) (*types.Digest, error) {
+		switch channelID {
+		case "hourlychannel":
+			return &types.Digest{Mode: types.DigestHourly}, nil
+		case "dailychannel":
+			return &types.Digest{Mode: types.DigestDaily, Time: "08:30", TimeZone: "Europe/Paris"}, nil
+		}
+		return nil, nil
+	})
+	var loaded, removed []string
+	monkey.Patch(store.LoadDigestQueue, func(channelID string) (*types.DigestQueue, error) {
+		loaded = append(loaded, channelID)
+		return &types.DigestQueue{Events: []types.DigestEvent{
+			{EventType: serializer.PageUpdatedEvent, SpaceKey: "DOC", SpaceName: "Documentation", PageID: "1234", Title: "Release Notes", URL: "https://test/1234", Message: "first", CreatedAt: now.Add(-time.Hour).UnixMilli()},
+			{EventType: serializer.PageUpdatedEvent, SpaceKey: "DOC", SpaceName: "Documentation", PageID: "1234", Title: "Release Notes", URL: "https://test/1234", Message: "second", CreatedAt: now.Add(-30 * time.Minute).UnixMilli()},
+			{EventType: serializer.CommentCreatedEvent, SpaceKey: "DOC", SpaceName: "Documentation", PageID: "1234", Title: "Release Notes", URL: "https://test/1234", Message: "third", CreatedAt: now.Add(-40 * time.Minute).UnixMilli()},
+		}}, nil
+	})
+	monkey.Patch(store.RemoveDigestEvents, func(channelID string, delivered *types.DigestQueue) error {
+		removed = append(removed, channelID)
+		assert.Len(t, delivered.Events, 3)
+		return nil
+	})
+
+	assert.NoError(t, DeliverDueDigests("botuserid", now))
+	// The daily digest is due at 08:30 in Paris, 07:30 UTC, after the first event at 08:02 UTC.
+	assert.ElementsMatch(t, []string{"hourlychannel", "offchannel"}, loaded)
+	assert.ElementsMatch(t, []string{"hourlychannel", "offchannel"}, removed)
+	assert.Len(t, posts, 2)
+	for _, post := range posts {
+		assert.Equal(t, "botuserid", post.UserId)
+		assert.True(t, strings.Contains(post.Message, "* [Release Notes](https://test/1234) - 1 Comment Create, 2 Page Update. Latest: second"), post.Message)
+	}
+}
+
+func TestDeliverDueDigestsKeepsFailedDigests(t *testing.T) {
+	defer monkey.UnpatchAll()
+	mockAPI := baseMock()
+	mockAPI.On("CreatePost", mock.AnythingOfType("*model.Post")).Return(nil, &model.AppError{Message: "failed"})
+	mockAPI.On("LogError", mock.AnythingOfType("string"), mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	now := time.Date(2024, 3, 4, 9, 2, 0, 0, time.UTC)
+	monkey.Patch(store.LoadPendingDigests, func() (map[string]int64, error) {
+		return map[string]int64{"hourlychannel": now.Add(-time.Hour).UnixMilli()}, nil
+	})
+	monkey.Patch(store.LoadChannelDigest, func(channelID string) (*types.Digest, error) {
+		return &types.Digest{Mode: types.DigestHourly}, nil
+	})
+	monkey.Patch(store.LoadDigestQueue, func(channelID string) (*types.DigestQueue, error) {
+		return &types.DigestQueue{Events: []types.DigestEvent{
+			{EventType: serializer.PageUpdatedEvent, SpaceKey: "DOC", PageID: "1234", Title: "Release Notes", Message: "first", CreatedAt: now.Add(-time.Hour).UnixMilli()},
+		}}, nil
+	})
+	removed := false
+	monkey.Patch(store.RemoveDigestEvents, func(channelID string, delivered *types.DigestQueue) error {
+		removed = true
+		return nil
+	})
+
+	assert.NoError(t, DeliverDueDigests("botuserid", now))
+	mockAPI.AssertNumberOfCalls(t, "CreatePost", 1)
+	assert.False(t, removed, "the events of a digest that failed to post stay queued")
+}
+
+func TestFormattedDigestSplit(t *testing.T) {
+	now := time.Date(2024, 3, 4, 9, 2, 0, 0, time.UTC)
+	queue := &types.DigestQueue{}
+	for i := 0; i < 200; i++ {
+		pageID := strconv.Itoa(i)
+		queue.Events = append(queue.Events, types.DigestEvent{
+			EventType: serializer.PageUpdatedEvent,
+			SpaceKey:  "DOC",
+			PageID:    pageID,
+			Title:     "Page " + pageID,
+			Message:   strings.Repeat("x", 200),
+			CreatedAt: now.UnixMilli(),
+		})
+	}
+
+	messages := serializer.FormattedDigest(queue)
+	assert.Greater(t, len(messages), 1)
+	pages := 0
+	for _, message := range messages {
+		assert.LessOrEqual(t, utf8.RuneCountInString(message), model.PostMessageMaxRunesV2)
+		assert.True(t, strings.HasPrefix(message, "#### Confluence digest\n200 notifications"), message)
+		pages += strings.Count(message, "\n* Page ")
+	}
+	assert.Equal(t, 200, pages)
+}
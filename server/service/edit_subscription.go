@@ -1,26 +1,43 @@
 package service
 
 import (
-	"encoding/json"
-
 	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
-	"github.com/mattermost/mattermost-plugin-confluence/server/store"
 )
 
 func EditSubscription(subscription serializer.Subscription) error {
-	key := store.GetSubscriptionKey()
-	err := store.AtomicModify(key, func(initialBytes []byte) ([]byte, error) {
-		subscriptions, err := serializer.SubscriptionsFromJSON(initialBytes)
-		if err != nil {
-			return nil, err
-		}
-		subscription.Edit(subscriptions)
-		modifiedBytes, marshalErr := json.Marshal(subscriptions)
-		if marshalErr != nil {
-			return nil, marshalErr
-		}
-		return modifiedBytes, nil
-	})
+	if _, err := CheckCQLSubscription(subscription); err != nil {
+		return err
+	}
+
+	channelSubscriptions, err := GetSubscriptionsByChannelID(subscription.GetChannelID())
+	if err != nil {
+		return err
+	}
 
-	return err
+	// Who created the subscription is taken from the stored one, never from the request.
+	// Pauses, quiet hours and templates are set with their own commands, so editing the subscription keeps them.
+	existing, found := channelSubscriptions.GetInsensitiveCase(subscription.GetAlias())
+	auditInfo := subscription.GetAuditInfo()
+	auditInfo.CreatedBy, auditInfo.CreatedAt = "", 0
+	pauseState := serializer.PauseState{}
+	var templates map[string]string
+	if found {
+		auditInfo.CreatedBy, auditInfo.CreatedAt = existing.GetAuditInfo().CreatedBy, existing.GetAuditInfo().CreatedAt
+		pauseState = existing.GetPauseState()
+		templates = existing.GetTemplates()
+	}
+	subscription = subscription.WithAuditInfo(auditInfo).WithPauseState(pauseState).WithTemplates(templates)
+
+	// The edited subscription may point at another page or space, so the records of the stored one are updated as well.
+	subscriptions := []serializer.Subscription{subscription}
+	if found {
+		subscriptions = append(subscriptions, existing)
+	}
+
+	return modifySubscriptionRecords(func(s *serializer.Subscriptions) {
+		if found {
+			existing.Remove(s)
+		}
+		subscription.Edit(s)
+	}, subscriptions...)
 }
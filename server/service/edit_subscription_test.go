@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+)
+
+func TestEditSubscriptionKeepsCreator(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	kv := map[string][]byte{}
+	monkey.Patch(store.AtomicModify, func(key string, modify func(initialValue []byte) ([]byte, error)) error {
+		modified, err := modify(kv[key])
+		if err != nil {
+			return err
+		}
+		kv[key] = modified
+		return nil
+	})
+
+	stored := serializer.PageSubscription{
+		PageID: "1234",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "page",
+			BaseURL:   "https://test.com",
+			ChannelID: "testtesttesttest",
+			Events:    []string{serializer.PageUpdatedEvent},
+			Type:      serializer.SubscriptionTypePage,
+			AuditInfo: serializer.AuditInfo{CreatedBy: "creator", CreatedAt: 1000, UpdatedBy: "creator", UpdatedAt: 1000},
+		},
+	}
+	monkey.Patch(GetSubscriptionsByChannelID, func(channelID string) (serializer.StringSubscription, error) {
+		return serializer.StringSubscription{"page": stored}, nil
+	})
+
+	edited := stored
+	edited.Events = []string{serializer.PageRemovedEvent}
+	edited.AuditInfo = serializer.AuditInfo{CreatedBy: "spoofed", CreatedAt: 1, UpdatedBy: "editor", UpdatedAt: 2000}
+	assert.Nil(t, EditSubscription(edited))
+
+	subscriptions := serializer.NewSubscriptions()
+	record := subscriptionRecord{kind: channelRecord, id: "testtesttesttest"}
+	assert.Nil(t, record.unmarshal(kv[store.GetChannelSubscriptionsKey("testtesttesttest")], subscriptions))
+	saved := subscriptions.ByChannelID["testtesttesttest"]["page"]
+	assert.Equal(t, serializer.AuditInfo{CreatedBy: "creator", CreatedAt: 1000, UpdatedBy: "editor", UpdatedAt: 2000}, saved.GetAuditInfo())
+	assert.Equal(t, []string{serializer.PageRemovedEvent}, saved.(serializer.PageSubscription).Events)
+}
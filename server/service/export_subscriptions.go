@@ -0,0 +1,174 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+)
+
+const (
+	generalExportError     = "an error occurred while exporting the subscriptions"
+	importChannelError     = "channel **%s** does not exist"
+	importTeamChannelError = "channel **%s** does not exist in team **%s**"
+)
+
+// SubscriptionResolver checks an imported subscription the way a subscription created by the importing user is checked,
+// and returns it as it should be saved along with the status code of the check.
+type SubscriptionResolver func(subscription serializer.Subscription) (serializer.Subscription, int, error)
+
+// SubscriptionImportResult is the outcome of importing one subscription of an export, Error is empty when it was saved.
+type SubscriptionImportResult struct {
+	// Entry is the position of the subscription in the export, starting at 1.
+	Entry     int    `json:"entry"`
+	Alias     string `json:"alias,omitempty"`
+	ChannelID string `json:"channelID,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ExportSubscriptions returns the JSON export of the subscriptions of the channel, or of every channel when channelID is empty.
+func ExportSubscriptions(channelID string) ([]byte, error) {
+	var subscriptions []serializer.Subscription
+	if channelID != "" {
+		channelSubscriptions, err := GetSubscriptionsByChannelID(channelID)
+		if err != nil {
+			return nil, err
+		}
+		for _, subscription := range channelSubscriptions {
+			subscriptions = append(subscriptions, subscription)
+		}
+	} else {
+		allSubscriptions, err := GetSubscriptions()
+		if err != nil {
+			return nil, err
+		}
+		for _, channelSubscriptions := range allSubscriptions.ByChannelID {
+			for _, subscription := range channelSubscriptions {
+				subscriptions = append(subscriptions, subscription)
+			}
+		}
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		if subscriptions[i].GetChannelID() != subscriptions[j].GetChannelID() {
+			return subscriptions[i].GetChannelID() < subscriptions[j].GetChannelID()
+		}
+		return subscriptions[i].GetAlias() < subscriptions[j].GetAlias()
+	})
+
+	channels := map[string]serializer.ExportChannel{}
+	teamNames := map[string]string{}
+	for _, subscription := range subscriptions {
+		channelID := subscription.GetChannelID()
+		if _, found := channels[channelID]; found {
+			continue
+		}
+		if overview := getChannelOverview(channelID, teamNames); overview.ChannelStatus != serializer.ChannelStatusDeleted && overview.ChannelName != channelID {
+			channels[channelID] = serializer.ExportChannel{TeamName: overview.TeamName, ChannelName: overview.ChannelName}
+		}
+	}
+
+	data, err := json.MarshalIndent(serializer.SubscriptionExport{
+		Version:       serializer.SubscriptionExportVersion,
+		ExportedAt:    model.GetMillis(),
+		Subscriptions: subscriptions,
+		Channels:      channels,
+	}, "", "  ")
+	if err != nil {
+		config.Mattermost.LogError("Unable to marshal the subscription export.", "Error", err.Error())
+		return nil, errors.New(generalExportError)
+	}
+	return data, nil
+}
+
+// ImportSubscriptions saves the subscriptions of a JSON export, each checked by resolve and ValidateSubscription
+// like a new subscription, and returns the outcome of each of them. The subscriptions are imported into the channel
+// when channelID is set, otherwise into the channels of the team and channel names they were exported from,
+// or of the same IDs for exports without the names. These channels must exist.
+// The imported subscriptions are recorded as created by the user, and their pause counters are reset.
+func ImportSubscriptions(data []byte, channelID, userID string, resolve SubscriptionResolver) ([]SubscriptionImportResult, error) {
+	subscriptionImport, err := serializer.SubscriptionImportFromJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SubscriptionImportResult, 0, len(subscriptionImport.Subscriptions))
+	for i, entry := range subscriptionImport.Subscriptions {
+		result := SubscriptionImportResult{Entry: i + 1}
+		subscription, sErr := serializer.SubscriptionFromJSON(entry)
+		if sErr != nil {
+			result.Error = sErr.Error()
+			results = append(results, result)
+			continue
+		}
+
+		result.Alias = subscription.GetAlias()
+		targetChannelID := channelID
+		if targetChannelID == "" {
+			var cErr error
+			if targetChannelID, cErr = getImportChannelID(subscription.GetChannelID(), subscriptionImport.Channels); cErr != nil {
+				result.ChannelID = subscription.GetChannelID()
+				result.Error = cErr.Error()
+				results = append(results, result)
+				continue
+			}
+		}
+		subscription = subscription.WithChannelID(targetChannelID)
+		result.ChannelID = targetChannelID
+
+		pauseState := subscription.GetPauseState()
+		pauseState.SuppressedEvents = 0
+		pauseState.Disabled = false
+		subscription = subscription.WithPauseState(pauseState).WithAuditInfo(serializer.NewAuditInfo(userID))
+
+		subscription, _, rErr := resolve(subscription)
+		if rErr != nil {
+			result.Error = rErr.Error()
+			results = append(results, result)
+			continue
+		}
+
+		if _, saveErr := SaveSubscription(subscription); saveErr != nil {
+			result.Error = saveErr.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// getImportChannelID returns the ID of the channel to import the subscriptions exported from the given channel into.
+// It is the channel of the same team and channel names when the export has them, otherwise the channel of the same ID.
+func getImportChannelID(exportedChannelID string, channels map[string]serializer.ExportChannel) (string, error) {
+	exportChannel, found := channels[exportedChannelID]
+	if !found || exportChannel.TeamName == "" {
+		return exportedChannelID, checkImportChannel(exportedChannelID)
+	}
+
+	channel, appErr := config.Mattermost.GetChannelByNameForTeamName(exportChannel.TeamName, exportChannel.ChannelName, false)
+	if appErr != nil {
+		if appErr.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf(importTeamChannelError, exportChannel.ChannelName, exportChannel.TeamName)
+		}
+		return "", appErr
+	}
+	return channel.Id, nil
+}
+
+func checkImportChannel(channelID string) error {
+	if channelID == "" {
+		return errors.New("channel id can not be empty")
+	}
+	if _, appErr := config.Mattermost.GetChannel(channelID); appErr != nil {
+		if appErr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf(importChannelError, channelID)
+		}
+		return appErr
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+)
+
+func resolveUnchanged(subscription serializer.Subscription) (serializer.Subscription, int, error) {
+	return subscription, http.StatusOK, nil
+}
+
+func TestExportImportSubscriptions(t *testing.T) {
+	defer monkey.UnpatchAll()
+	mockAPI := baseMock()
+	mockAPI.On("GetChannel", "sourcechannel").Return(&model.Channel{Id: "sourcechannel", Name: "source", TeamId: "sourceteam"}, nil)
+	mockAPI.On("GetTeam", "sourceteam").Return(&model.Team{Id: "sourceteam", Name: "engineering"}, nil)
+	spaceSubscription := serializer.SpaceSubscription{
+		SpaceKey: "TS",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:      "space",
+			BaseURL:    "https://test.com",
+			ChannelID:  "sourcechannel",
+			Events:     []string{serializer.PageCreatedEvent},
+			Type:       serializer.SubscriptionTypeSpace,
+			PauseState: serializer.PauseState{SuppressedEvents: 3},
+		},
+	}
+	pageSubscription := serializer.PageSubscription{
+		PageID: "1234",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "page",
+			BaseURL:   "https://test.com",
+			ChannelID: "sourcechannel",
+			Events:    []string{serializer.CommentCreatedEvent},
+			Type:      serializer.SubscriptionTypePage,
+		},
+	}
+
+	monkey.Patch(GetSubscriptionsByChannelID, func(channelID string) (serializer.StringSubscription, error) {
+		return serializer.StringSubscription{
+			spaceSubscription.Alias: spaceSubscription,
+			pageSubscription.Alias:  pageSubscription,
+		}, nil
+	})
+	data, err := ExportSubscriptions("sourcechannel")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"teamName": "engineering"`)
+
+	var saved []serializer.Subscription
+	monkey.Patch(SaveSubscription, func(subscription serializer.Subscription) (int, error) {
+		if subscription.GetAlias() == "page" {
+			return http.StatusBadRequest, errors.New("a subscription with the same name already exists in this channel")
+		}
+		saved = append(saved, subscription)
+		return http.StatusOK, nil
+	})
+
+	results, err := ImportSubscriptions(data, "targetchannel", "user", resolveUnchanged)
+	assert.NoError(t, err)
+	assert.Equal(t, []SubscriptionImportResult{
+		{Entry: 1, Alias: "page", ChannelID: "targetchannel", Error: "a subscription with the same name already exists in this channel"},
+		{Entry: 2, Alias: "space", ChannelID: "targetchannel"},
+	}, results)
+
+	assert.Len(t, saved, 1)
+	imported, ok := saved[0].(serializer.SpaceSubscription)
+	assert.True(t, ok)
+	assert.Equal(t, "TS", imported.SpaceKey)
+	assert.Equal(t, "targetchannel", imported.ChannelID)
+	assert.Equal(t, []string{serializer.PageCreatedEvent}, imported.Events)
+	assert.Equal(t, 0, imported.PauseState.SuppressedEvents)
+	assert.Equal(t, "user", imported.GetAuditInfo().CreatedBy)
+}
+
+func TestImportSubscriptions(t *testing.T) {
+	defer monkey.UnpatchAll()
+	mockAPI := baseMock()
+	mockAPI.On("GetChannel", "existingchannel").Return(&model.Channel{Id: "existingchannel"}, nil)
+	mockAPI.On("GetChannel", "deletedchannel").Return(nil, &model.AppError{StatusCode: http.StatusNotFound})
+	mockAPI.On("GetChannelByNameForTeamName", "engineering", "docs", false).Return(&model.Channel{Id: "importedchannel"}, nil)
+	mockAPI.On("GetChannelByNameForTeamName", "engineering", "gone", false).Return(nil, &model.AppError{StatusCode: http.StatusNotFound})
+
+	var saved []string
+	monkey.Patch(SaveSubscription, func(subscription serializer.Subscription) (int, error) {
+		saved = append(saved, subscription.GetAlias())
+		return http.StatusOK, nil
+	})
+
+	for name, val := range map[string]struct {
+		data           string
+		expectedError  string
+		expectedResult []SubscriptionImportResult
+		expectedSaved  []string
+	}{
+		"server export": {
+			data: `{"version": 1, "subscriptions": [
+				{"subscriptionType": "page_subscription", "alias": "page", "channelID": "existingchannel", "pageID": "1234", "baseURL": "https://test.com"},
+				{"subscriptionType": "space_subscription", "alias": "space", "channelID": "deletedchannel", "spaceKey": "TS", "baseURL": "https://test.com"}
+			]}`,
+			expectedResult: []SubscriptionImportResult{
+				{Entry: 1, Alias: "page", ChannelID: "existingchannel"},
+				{Entry: 2, Alias: "space", ChannelID: "deletedchannel", Error: "channel **deletedchannel** does not exist"},
+			},
+			expectedSaved: []string{"page"},
+		},
+		"server export with channel names": {
+			data: `{"version": 1, "subscriptions": [
+				{"subscriptionType": "page_subscription", "alias": "page", "channelID": "otherserverid", "pageID": "1234", "baseURL": "https://test.com"},
+				{"subscriptionType": "space_subscription", "alias": "space", "channelID": "goneid", "spaceKey": "TS", "baseURL": "https://test.com"}
+			], "channels": {
+				"otherserverid": {"teamName": "engineering", "channelName": "docs"},
+				"goneid": {"teamName": "engineering", "channelName": "gone"}
+			}}`,
+			expectedResult: []SubscriptionImportResult{
+				{Entry: 1, Alias: "page", ChannelID: "importedchannel"},
+				{Entry: 2, Alias: "space", ChannelID: "goneid", Error: "channel **gone** does not exist in team **engineering**"},
+			},
+			expectedSaved: []string{"page"},
+		},
+		"subscription rejected by the checks": {
+			data: `{"version": 1, "subscriptions": [
+				{"subscriptionType": "cql_subscription", "alias": "cql", "channelID": "existingchannel", "cql": "type = page", "baseURL": "https://cloud.test.com"}
+			]}`,
+			expectedResult: []SubscriptionImportResult{
+				{Entry: 1, Alias: "cql", ChannelID: "existingchannel", Error: "CQL subscriptions are not supported"},
+			},
+		},
+		"unknown subscription type": {
+			data: `{"version": 1, "subscriptions": [{"subscriptionType": "blog_subscription", "alias": "blog", "channelID": "existingchannel"}]}`,
+			expectedResult: []SubscriptionImportResult{
+				{Entry: 1, Error: `subscription type "blog_subscription" is not supported`},
+			},
+		},
+		"unsupported version": {
+			data:          `{"version": 2, "subscriptions": []}`,
+			expectedError: "version 2 of the subscription export is not supported",
+		},
+		"not an export": {
+			data:          `not json`,
+			expectedError: "the file is not a subscription export",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			saved = nil
+			results, err := ImportSubscriptions([]byte(val.data), "", "user", func(subscription serializer.Subscription) (serializer.Subscription, int, error) {
+				if _, ok := subscription.(serializer.CQLSubscription); ok {
+					return subscription, http.StatusBadRequest, errors.New("CQL subscriptions are not supported")
+				}
+				return subscription, http.StatusOK, nil
+			})
+			if val.expectedError != "" {
+				assert.ErrorContains(t, err, val.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, val.expectedResult, results)
+			assert.Equal(t, val.expectedSaved, saved)
+		})
+	}
+}
+
+func TestExportSubscriptionsFormat(t *testing.T) {
+	defer monkey.UnpatchAll()
+	mockAPI := baseMock()
+	mockAPI.On("GetChannel", "channel").Return(nil, &model.AppError{StatusCode: http.StatusNotFound})
+	monkey.Patch(GetSubscriptions, func() (serializer.Subscriptions, error) {
+		subscriptions := serializer.NewSubscriptions()
+		serializer.PageSubscription{
+			PageID:           "1234",
+			BaseSubscription: serializer.BaseSubscription{Alias: "page", ChannelID: "channel", Type: serializer.SubscriptionTypePage},
+		}.Add(subscriptions)
+		return *subscriptions, nil
+	})
+
+	data, err := ExportSubscriptions("")
+	assert.NoError(t, err)
+
+	var export map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &export))
+	assert.Equal(t, float64(serializer.SubscriptionExportVersion), export["version"])
+	assert.Len(t, export["subscriptions"], 1)
+}
@@ -10,41 +10,89 @@ import (
 
 const getChannelSubscriptionsError = " Error getting channel subscriptions."
 
+// GetSubscriptions returns every subscription along with its indexes.
+// It reads all channel records, so it should not be used when handling events.
 func GetSubscriptions() (serializer.Subscriptions, error) {
-	key := store.GetSubscriptionKey()
-	initialBytes, appErr := config.Mattermost.KVGet(key)
-	if appErr != nil {
-		return serializer.Subscriptions{}, errors.New(getChannelSubscriptionsError)
-	}
-	subscriptions, err := serializer.SubscriptionsFromJSON(initialBytes)
-	if err != nil {
-		return serializer.Subscriptions{}, errors.New(getChannelSubscriptionsError)
+	subscriptions := serializer.NewSubscriptions()
+	for page := 0; ; page++ {
+		keys, appErr := config.Mattermost.KVList(page, kvListPerPage)
+		if appErr != nil {
+			return serializer.Subscriptions{}, errors.New(getChannelSubscriptionsError)
+		}
+
+		for _, key := range keys {
+			channelID, ok := store.ParseChannelSubscriptionsKey(key)
+			if !ok {
+				continue
+			}
+			channelSubscriptions, err := GetSubscriptionsByChannelID(channelID)
+			if err != nil {
+				return serializer.Subscriptions{}, err
+			}
+			for _, subscription := range channelSubscriptions {
+				subscription.Add(subscriptions)
+			}
+		}
+
+		if len(keys) < kvListPerPage {
+			break
+		}
 	}
 	return *subscriptions, nil
 }
 
 func GetSubscriptionsByChannelID(channelID string) (serializer.StringSubscription, error) {
-	subscriptions, err := GetSubscriptions()
+	subscriptions, err := loadSubscriptionRecords(subscriptionRecord{kind: channelRecord, id: channelID})
 	if err != nil {
-		return nil, err
+		return nil, errors.New(getChannelSubscriptionsError)
 	}
 	return subscriptions.ByChannelID[channelID], nil
 }
 
 func GetSubscriptionsByURLSpaceKey(url, spaceKey string) (serializer.StringArrayMap, error) {
 	key := store.GetURLSpaceKeyCombinationKey(url, spaceKey)
-	subscriptions, err := GetSubscriptions()
+	subscriptions, err := loadSubscriptionRecords(subscriptionRecord{kind: urlSpaceKeyRecord, id: key})
 	if err != nil {
-		return nil, err
+		return nil, errors.New(getChannelSubscriptionsError)
 	}
 	return subscriptions.ByURLSpaceKey[key], nil
 }
 
 func GetSubscriptionsByURLPageID(url, pageID string) (serializer.StringArrayMap, error) {
 	key := store.GetURLPageIDCombinationKey(url, pageID)
-	subscriptions, err := GetSubscriptions()
+	subscriptions, err := loadSubscriptionRecords(subscriptionRecord{kind: urlPageIDRecord, id: key})
 	if err != nil {
-		return nil, err
+		return nil, errors.New(getChannelSubscriptionsError)
 	}
 	return subscriptions.ByURLPageID[key], nil
 }
+
+// GetSubscriptionsByURLPageTreeID returns the events of the page tree subscription of each channel rooted at the page.
+func GetSubscriptionsByURLPageTreeID(url, pageID string) (serializer.StringArrayMap, error) {
+	key := store.GetURLPageIDCombinationKey(url, pageID)
+	subscriptions, err := loadSubscriptionRecords(subscriptionRecord{kind: urlPageTreeIDRecord, id: key})
+	if err != nil {
+		return nil, errors.New(getChannelSubscriptionsError)
+	}
+	return subscriptions.ByURLPageTreeID[key], nil
+}
+
+// GetSubscriptionsByURLCQL returns the names of the CQL subscriptions of each channel for the instance.
+func GetSubscriptionsByURLCQL(url string) (serializer.StringArrayMap, error) {
+	key := store.GetURLCombinationKey(url)
+	subscriptions, err := loadSubscriptionRecords(subscriptionRecord{kind: urlCQLRecord, id: key})
+	if err != nil {
+		return nil, errors.New(getChannelSubscriptionsError)
+	}
+	return subscriptions.ByURLCQL[key], nil
+}
+
+// GetSubscriptionsByURLAllSpaces returns the events of the all spaces subscription of each channel for the instance.
+func GetSubscriptionsByURLAllSpaces(url string) (serializer.StringArrayMap, error) {
+	key := store.GetURLCombinationKey(url)
+	subscriptions, err := loadSubscriptionRecords(subscriptionRecord{kind: urlAllSpacesRecord, id: key})
+	if err != nil {
+		return nil, errors.New(getChannelSubscriptionsError)
+	}
+	return subscriptions.ByURLAllSpaces[key], nil
+}
@@ -93,18 +93,18 @@ func TestGetSubscriptionsByChannelID(t *testing.T) {
 					},
 				},
 				ByURLSpaceKey: map[string]serializer.StringArrayMap{
-					"confluence_subs/test.com/TS": {
+					"confluence_subs/https://test.com/TS": {
 						"testtesttesttest": {serializer.CommentRemovedEvent, serializer.CommentUpdatedEvent},
 						"testtesttesttes1": {serializer.CommentRemovedEvent, serializer.CommentUpdatedEvent},
 					},
 				},
 				ByURLPageID: map[string]serializer.StringArrayMap{
-					"confluence_subs/test.com/1234": {
+					"confluence_subs/https://test.com/1234": {
 						"testtesttesttes1": {serializer.CommentCreatedEvent, serializer.CommentUpdatedEvent},
 					},
 				},
 			}
-			monkey.Patch(GetSubscriptions, func() (serializer.Subscriptions, error) {
+			monkey.Patch(loadSubscriptionRecords, func(...subscriptionRecord) (serializer.Subscriptions, error) {
 				return subscriptions, nil
 			})
 			sub, err := GetSubscriptionsByChannelID(val.channelID)
@@ -196,22 +196,22 @@ func TestGetSubscriptionsByURLPageID(t *testing.T) {
 					},
 				},
 				ByURLSpaceKey: map[string]serializer.StringArrayMap{
-					"confluence_subs/test.com/TS": {
+					"confluence_subs/https://test.com/TS": {
 						"testtesttesttest": {serializer.CommentRemovedEvent, serializer.CommentUpdatedEvent},
 						"testtesttesttes1": {serializer.CommentRemovedEvent, serializer.CommentUpdatedEvent},
 					},
 				},
 				ByURLPageID: map[string]serializer.StringArrayMap{
-					"confluence_subs/test.com/1234": {
+					"confluence_subs/https://test.com/1234": {
 						"testtesttesttes1": {serializer.CommentCreatedEvent, serializer.CommentUpdatedEvent},
 					},
-					"confluence_subs/test.com/12345": {
+					"confluence_subs/https://test.com/12345": {
 						"testtesttesttest": {serializer.CommentCreatedEvent, serializer.CommentUpdatedEvent},
 						"testtesttesttes1": {serializer.CommentCreatedEvent, serializer.CommentUpdatedEvent},
 					},
 				},
 			}
-			monkey.Patch(GetSubscriptions, func() (serializer.Subscriptions, error) {
+			monkey.Patch(loadSubscriptionRecords, func(...subscriptionRecord) (serializer.Subscriptions, error) {
 				return subscriptions, nil
 			})
 			sub, err := GetSubscriptionsByURLPageID(val.url, val.pageID)
@@ -312,25 +312,25 @@ func TestGetSubscriptionsByURLSpaceKey(t *testing.T) {
 					},
 				},
 				ByURLSpaceKey: map[string]serializer.StringArrayMap{
-					"confluence_subs/test.com/TS": {
+					"confluence_subs/https://test.com/TS": {
 						"testtesttesttest": {serializer.CommentRemovedEvent, serializer.CommentUpdatedEvent},
 						"testtesttesttes1": {serializer.CommentRemovedEvent, serializer.CommentUpdatedEvent},
 					},
-					"confluence_subs/test.com/TS1": {
+					"confluence_subs/https://test.com/TS1": {
 						"testtesttesttes1": {serializer.CommentRemovedEvent, serializer.CommentUpdatedEvent},
 					},
 				},
 				ByURLPageID: map[string]serializer.StringArrayMap{
-					"confluence_subs/test.com/1234": {
+					"confluence_subs/https://test.com/1234": {
 						"testtesttesttes1": {serializer.CommentCreatedEvent, serializer.CommentUpdatedEvent},
 					},
-					"confluence_subs/test.com/12345": {
+					"confluence_subs/https://test.com/12345": {
 						"testtesttesttest": {serializer.CommentCreatedEvent, serializer.CommentUpdatedEvent},
 						"testtesttesttes1": {serializer.CommentCreatedEvent, serializer.CommentUpdatedEvent},
 					},
 				},
 			}
-			monkey.Patch(GetSubscriptions, func() (serializer.Subscriptions, error) {
+			monkey.Patch(loadSubscriptionRecords, func(...subscriptionRecord) (serializer.Subscriptions, error) {
 				return subscriptions, nil
 			})
 			sub, err := GetSubscriptionsByURLSpaceKey(val.url, val.spaceKey)
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+)
+
+// GetSubscriptionOverviews returns every subscription of the server with the team and channel it posts to,
+// sorted by team, channel and name. Only the space subscriptions of the space are returned when spaceKey is not empty.
+func GetSubscriptionOverviews(spaceKey string) ([]serializer.SubscriptionOverview, error) {
+	subscriptions, err := GetSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	teamNames := map[string]string{}
+	usernames := map[string]string{}
+	var overviews []serializer.SubscriptionOverview
+	for channelID, channelSubscriptions := range subscriptions.ByChannelID {
+		if len(channelSubscriptions) == 0 {
+			continue
+		}
+
+		channelOverview := getChannelOverview(channelID, teamNames)
+		for _, subscription := range channelSubscriptions {
+			if spaceKey != "" {
+				spaceSubscription, ok := subscription.(serializer.SpaceSubscription)
+				if !ok || !strings.EqualFold(spaceSubscription.SpaceKey, spaceKey) {
+					continue
+				}
+			}
+
+			overview := channelOverview
+			overview.Owner = getUsername(subscription.GetAuditInfo().CreatedBy, usernames)
+			overview.Subscription = subscription
+			overviews = append(overviews, overview)
+		}
+	}
+
+	sort.Slice(overviews, func(i, j int) bool {
+		if overviews[i].TeamName != overviews[j].TeamName {
+			return overviews[i].TeamName < overviews[j].TeamName
+		}
+		if overviews[i].ChannelName != overviews[j].ChannelName {
+			return overviews[i].ChannelName < overviews[j].ChannelName
+		}
+		return overviews[i].Subscription.GetAlias() < overviews[j].Subscription.GetAlias()
+	})
+	return overviews, nil
+}
+
+// getChannelOverview returns the team and channel names of the channel, and whether it was deleted or archived.
+// A channel that can not be looked up is shown by its ID, so one failure does not hide every other subscription.
+func getChannelOverview(channelID string, teamNames map[string]string) serializer.SubscriptionOverview {
+	channel, appErr := config.Mattermost.GetChannel(channelID)
+	if appErr != nil {
+		if appErr.StatusCode == http.StatusNotFound {
+			return serializer.SubscriptionOverview{ChannelName: channelID, ChannelStatus: serializer.ChannelStatusDeleted}
+		}
+		config.Mattermost.LogWarn("Unable to get the channel of subscriptions.", "ChannelID", channelID, "Error", appErr.Error())
+		return serializer.SubscriptionOverview{ChannelName: channelID}
+	}
+
+	overview := serializer.SubscriptionOverview{ChannelName: channel.Name}
+	if channel.DeleteAt > 0 {
+		overview.ChannelStatus = serializer.ChannelStatusArchived
+	}
+	if channel.TeamId == "" {
+		return overview
+	}
+
+	teamName, found := teamNames[channel.TeamId]
+	if !found {
+		if team, tErr := config.Mattermost.GetTeam(channel.TeamId); tErr == nil {
+			teamName = team.Name
+		}
+		teamNames[channel.TeamId] = teamName
+	}
+	overview.TeamName = teamName
+	return overview
+}
+
+func getUsername(userID string, usernames map[string]string) string {
+	if userID == "" {
+		return ""
+	}
+	username, found := usernames[userID]
+	if !found {
+		if user, appErr := config.Mattermost.GetUser(userID); appErr == nil {
+			username = user.Username
+		}
+		usernames[userID] = username
+	}
+	return username
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"bou.ke/monkey"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+)
+
+func TestGetSubscriptionOverviews(t *testing.T) {
+	spaceSubscription := serializer.SpaceSubscription{
+		SpaceKey: "ENG",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "eng",
+			BaseURL:   "https://test.com",
+			ChannelID: "activechannel",
+			Events:    []string{serializer.PageCreatedEvent},
+			AuditInfo: serializer.AuditInfo{CreatedBy: "owner"},
+		},
+	}
+	pageSubscription := serializer.PageSubscription{
+		PageID: "1234",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "runbook",
+			BaseURL:   "https://test.com",
+			ChannelID: "archivedchannel",
+			Events:    []string{serializer.PageUpdatedEvent},
+		},
+	}
+	otherSpaceSubscription := serializer.SpaceSubscription{
+		SpaceKey: "OPS",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "ops",
+			BaseURL:   "https://test.com",
+			ChannelID: "deletedchannel",
+			Events:    []string{serializer.PageCreatedEvent},
+		},
+	}
+
+	for name, val := range map[string]struct {
+		spaceKey string
+		expected []serializer.SubscriptionOverview
+	}{
+		"all subscriptions": {
+			expected: []serializer.SubscriptionOverview{
+				{ChannelName: "deletedchannel", ChannelStatus: serializer.ChannelStatusDeleted, Subscription: otherSpaceSubscription},
+				{TeamName: "team", ChannelName: "active", Owner: "alice", Subscription: spaceSubscription},
+				{TeamName: "team", ChannelName: "archived", ChannelStatus: serializer.ChannelStatusArchived, Subscription: pageSubscription},
+			},
+		},
+		"subscriptions of a space": {
+			spaceKey: "eng",
+			expected: []serializer.SubscriptionOverview{
+				{TeamName: "team", ChannelName: "active", Owner: "alice", Subscription: spaceSubscription},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer monkey.UnpatchAll()
+			mockAPI := baseMock()
+			mockAPI.On("GetChannel", "activechannel").Return(&model.Channel{Id: "activechannel", Name: "active", TeamId: "teamid"}, nil)
+			mockAPI.On("GetChannel", "archivedchannel").Return(&model.Channel{Id: "archivedchannel", Name: "archived", TeamId: "teamid", DeleteAt: 1}, nil)
+			mockAPI.On("GetChannel", "deletedchannel").Return(nil, &model.AppError{StatusCode: http.StatusNotFound})
+			mockAPI.On("GetTeam", "teamid").Return(&model.Team{Id: "teamid", Name: "team"}, nil)
+			mockAPI.On("GetUser", "owner").Return(&model.User{Id: "owner", Username: "alice"}, nil)
+
+			monkey.Patch(GetSubscriptions, func() (serializer.Subscriptions, error) {
+				subscriptions := serializer.NewSubscriptions()
+				spaceSubscription.Add(subscriptions)
+				pageSubscription.Add(subscriptions)
+				otherSpaceSubscription.Add(subscriptions)
+				return *subscriptions, nil
+			})
+
+			overviews, err := GetSubscriptionOverviews(val.spaceKey)
+			assert.NoError(t, err)
+			assert.Equal(t, val.expected, overviews)
+		})
+	}
+}
+
+func TestFormattedSubscriptionOverviewList(t *testing.T) {
+	var overviews []serializer.SubscriptionOverview
+	for i := 0; i < 300; i++ {
+		overviews = append(overviews, serializer.SubscriptionOverview{
+			TeamName:    "team",
+			ChannelName: "town-square",
+			Subscription: serializer.CQLSubscription{
+				CQL: "type = page and (label = a | label = b) and space in (ENG, OPS, SRE, DOCS)",
+				BaseSubscription: serializer.BaseSubscription{
+					Alias:   "subscription " + strconv.Itoa(i),
+					BaseURL: "https://test.com",
+					Events:  serializer.SupportedEvents(),
+				},
+			},
+		})
+	}
+
+	messages := serializer.FormattedSubscriptionOverviewList(overviews)
+	assert.Greater(t, len(messages), 1)
+	rows := 0
+	for _, message := range messages {
+		assert.LessOrEqual(t, utf8.RuneCountInString(message), model.PostMessageMaxRunesV2)
+		assert.True(t, strings.HasPrefix(message, "| Team | Channel |"))
+		assert.NotContains(t, message, "a | label")
+		rows += strings.Count(message, "\n|team|")
+	}
+	assert.Equal(t, len(overviews), rows)
+}
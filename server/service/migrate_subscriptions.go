@@ -0,0 +1,152 @@
+package service
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+)
+
+// MigrateSubscriptions moves subscriptions out of the legacy KV value holding all of them
+// into per-channel and per-index records. The legacy value is deleted once every subscription
+// has been moved, so running it again is a no-op.
+func MigrateSubscriptions() error {
+	key := store.GetSubscriptionKey()
+	data, appErr := config.Mattermost.KVGet(key)
+	if appErr != nil {
+		return errors.Wrap(appErr, "failed to load legacy subscriptions")
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	legacy, err := serializer.SubscriptionsFromJSON(data)
+	if err != nil {
+		return errors.Wrap(err, "failed to unmarshal legacy subscriptions")
+	}
+
+	count := 0
+	for _, channelSubscriptions := range legacy.ByChannelID {
+		for _, subscription := range channelSubscriptions {
+			if mErr := modifySubscriptionRecords(subscription.Add, subscription); mErr != nil {
+				return errors.Wrapf(mErr, "failed to migrate subscription %q", subscription.GetAlias())
+			}
+			count++
+		}
+	}
+
+	if dErr := config.Mattermost.KVDelete(key); dErr != nil {
+		return errors.Wrap(dErr, "failed to delete legacy subscriptions")
+	}
+
+	config.Mattermost.LogInfo("Migrated Confluence subscriptions to per-channel records.", "Count", count)
+	return nil
+}
+
+// MigrateSubscriptionIndexKeys re-keys the page, page tree and space indexes from the hostname of the Confluence URL
+// to the normalized instance URL, so instances sharing a host no longer collide. Every subscription is added again
+// under the new keys before the legacy index records are deleted, so no subscription is dropped.
+// Subscriptions were matched to events by hostname, so the URL saved with each one is replaced by the URL of the
+// installed instance on its host, like `https://wiki.corp/confluence` for `http://wiki.corp`, which the events carry.
+// Subscriptions matching no instance keep their URL and are logged, as their events may not match them anymore.
+func MigrateSubscriptionIndexKeys() error {
+	data, appErr := config.Mattermost.KVGet(store.SubscriptionIndexMigrationKey)
+	if appErr != nil {
+		return errors.Wrap(appErr, "failed to load the subscription index migration state")
+	}
+	if len(data) != 0 {
+		return nil
+	}
+
+	subscriptions, err := GetSubscriptions()
+	if err != nil {
+		return errors.Wrap(err, "failed to load subscriptions")
+	}
+	instances, err := store.LoadInstances()
+	if err != nil {
+		return errors.Wrap(err, "failed to load the Confluence instances")
+	}
+	instanceURLs := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		instanceURLs = append(instanceURLs, instance.URL)
+	}
+
+	count := 0
+	legacyKeys := map[string]bool{}
+	currentKeys := map[string]bool{}
+	for _, channelSubscriptions := range subscriptions.ByChannelID {
+		for _, legacySubscription := range channelSubscriptions {
+			for _, record := range legacySubscriptionRecords(legacySubscription) {
+				legacyKeys[record.key()] = true
+			}
+			subscription := withInstanceURL(legacySubscription, instanceURLs)
+			if mErr := modifySubscriptionRecords(subscription.Add, subscription); mErr != nil {
+				return errors.Wrapf(mErr, "failed to re-key subscription %q", subscription.GetAlias())
+			}
+			for _, record := range subscriptionRecords(subscription) {
+				currentKeys[record.key()] = true
+			}
+			count++
+		}
+	}
+
+	for key := range legacyKeys {
+		if currentKeys[key] {
+			continue
+		}
+		if dErr := config.Mattermost.KVDelete(key); dErr != nil {
+			return errors.Wrap(dErr, "failed to delete legacy subscription index")
+		}
+	}
+
+	if sErr := config.Mattermost.KVSet(store.SubscriptionIndexMigrationKey, []byte("done")); sErr != nil {
+		return errors.Wrap(sErr, "failed to store the subscription index migration state")
+	}
+
+	config.Mattermost.LogInfo("Re-keyed Confluence subscription indexes by instance URL.", "Count", count)
+	return nil
+}
+
+// withInstanceURL returns the subscription with the URL of the instance it belongs to, see store.MatchInstanceURL.
+func withInstanceURL(subscription serializer.Subscription, instanceURLs []string) serializer.Subscription {
+	if len(legacySubscriptionRecords(subscription)) == 0 {
+		return subscription
+	}
+	instanceURL, ok := store.MatchInstanceURL(subscription.GetBaseURL(), instanceURLs)
+	if !ok {
+		config.Mattermost.LogWarn("The URL of a subscription matches no installed Confluence instance, it may not receive notifications anymore.", "ChannelID", subscription.GetChannelID(), "Subscription", subscription.GetAlias(), "BaseURL", subscription.GetBaseURL())
+		return subscription
+	}
+	if instanceURL == subscription.GetBaseURL() {
+		return subscription
+	}
+
+	switch s := subscription.(type) {
+	case serializer.SpaceSubscription:
+		s.BaseURL = instanceURL
+		return s
+	case serializer.PageSubscription:
+		s.BaseURL = instanceURL
+		return s
+	case serializer.PageTreeSubscription:
+		s.BaseURL = instanceURL
+		return s
+	default:
+		return subscription
+	}
+}
+
+// legacySubscriptionRecords returns the index records a subscription was stored in before index keys held the instance URL.
+func legacySubscriptionRecords(subscription serializer.Subscription) []subscriptionRecord {
+	switch sub := subscription.(type) {
+	case serializer.SpaceSubscription:
+		return []subscriptionRecord{{kind: urlSpaceKeyRecord, id: store.GetLegacyURLCombinationKey(sub.BaseURL, sub.SpaceKey)}}
+	case serializer.PageSubscription:
+		return []subscriptionRecord{{kind: urlPageIDRecord, id: store.GetLegacyURLCombinationKey(sub.BaseURL, sub.PageID)}}
+	case serializer.PageTreeSubscription:
+		return []subscriptionRecord{{kind: urlPageTreeIDRecord, id: store.GetLegacyURLCombinationKey(sub.BaseURL, sub.PageID)}}
+	default:
+		return nil
+	}
+}
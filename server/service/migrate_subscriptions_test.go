@@ -0,0 +1,187 @@
+package service
+
+import (
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/mattermost/mattermost/server/public/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+func TestMigrateSubscriptionIndexKeys(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	contextPathSubscription := serializer.SpaceSubscription{
+		SpaceKey: "TS",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "context path",
+			BaseURL:   "https://wiki.corp/confluence",
+			ChannelID: "testtesttesttest",
+			Events:    []string{serializer.PageCreatedEvent},
+			Type:      serializer.SubscriptionTypeSpace,
+		},
+	}
+	portSubscription := serializer.SpaceSubscription{
+		SpaceKey: "TS",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "port",
+			BaseURL:   "https://wiki.corp:8443",
+			ChannelID: "testtesttesttes1",
+			Events:    []string{serializer.PageUpdatedEvent},
+			Type:      serializer.SubscriptionTypeSpace,
+		},
+	}
+	pageSubscription := serializer.PageSubscription{
+		PageID: "1234",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "page",
+			BaseURL:   "https://wiki.corp/confluence",
+			ChannelID: "testtesttesttest",
+			Events:    []string{serializer.CommentCreatedEvent},
+			Type:      serializer.SubscriptionTypePage,
+		},
+	}
+	schemeSubscription := serializer.PageTreeSubscription{
+		PageID: "5678",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "scheme",
+			BaseURL:   "http://wiki.corp/confluence",
+			ChannelID: "testtesttesttes2",
+			Events:    []string{serializer.PageUpdatedEvent},
+			Type:      serializer.SubscriptionTypePageTree,
+		},
+	}
+
+	legacySpaceKey := store.GetURLSpaceKeySubscriptionsKey(store.GetLegacyURLCombinationKey("https://wiki.corp", "TS"))
+	legacyPageKey := store.GetURLPageIDSubscriptionsKey(store.GetLegacyURLCombinationKey("https://wiki.corp", "1234"))
+	kv := map[string][]byte{
+		legacySpaceKey: []byte(`{"testtesttesttes1":["page_updated"]}`),
+		legacyPageKey:  []byte(`{"testtesttesttest":["comment_created"]}`),
+	}
+
+	monkey.Patch(GetSubscriptions, func() (serializer.Subscriptions, error) {
+		subscriptions := serializer.NewSubscriptions()
+		contextPathSubscription.Add(subscriptions)
+		portSubscription.Add(subscriptions)
+		pageSubscription.Add(subscriptions)
+		schemeSubscription.Add(subscriptions)
+		return *subscriptions, nil
+	})
+	monkey.Patch(store.LoadInstances, func() ([]*types.Instance, error) {
+		return []*types.Instance{{URL: "https://wiki.corp/confluence"}, {URL: "https://wiki.corp:8443"}}, nil
+	})
+	monkey.Patch(store.AtomicModify, func(key string, modify func(initialValue []byte) ([]byte, error)) error {
+		modified, err := modify(kv[key])
+		if err != nil {
+			return err
+		}
+		if modified == nil {
+			delete(kv, key)
+			return nil
+		}
+		kv[key] = modified
+		return nil
+	})
+
+	mockAPI := &plugintest.API{}
+	config.Mattermost = mockAPI
+	mockAPI.On("KVGet", store.SubscriptionIndexMigrationKey).Return(nil, nil)
+	mockAPI.On("KVDelete", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		delete(kv, args.String(0))
+	}).Return(nil)
+	mockAPI.On("KVSet", store.SubscriptionIndexMigrationKey, mock.Anything).Return(nil)
+	mockAPI.On("LogInfo", mock.Anything, mock.Anything, mock.Anything).Return()
+
+	assert.Nil(t, MigrateSubscriptionIndexKeys())
+
+	assert.NotContains(t, kv, legacySpaceKey)
+	assert.NotContains(t, kv, legacyPageKey)
+
+	contextPathKey := store.GetURLSpaceKeySubscriptionsKey(store.GetURLSpaceKeyCombinationKey("https://wiki.corp/confluence", "TS"))
+	portKey := store.GetURLSpaceKeySubscriptionsKey(store.GetURLSpaceKeyCombinationKey("https://wiki.corp:8443", "TS"))
+	pageKey := store.GetURLPageIDSubscriptionsKey(store.GetURLPageIDCombinationKey("https://wiki.corp/confluence", "1234"))
+	assert.NotEqual(t, contextPathKey, portKey)
+	assert.JSONEq(t, `{"testtesttesttest":["page_created"]}`, string(kv[contextPathKey]))
+	assert.JSONEq(t, `{"testtesttesttes1":["page_updated"]}`, string(kv[portKey]))
+	assert.JSONEq(t, `{"testtesttesttest":["comment_created"]}`, string(kv[pageKey]))
+
+	schemeKey := store.GetURLPageTreeIDSubscriptionsKey(store.GetURLPageIDCombinationKey("https://wiki.corp/confluence", "5678"))
+	assert.JSONEq(t, `{"testtesttesttes2":["page_updated"]}`, string(kv[schemeKey]))
+	assert.Contains(t, string(kv[store.GetChannelSubscriptionsKey("testtesttesttes2")]), `"baseURL":"https://wiki.corp/confluence"`)
+	mockAPI.AssertNotCalled(t, "LogWarn", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockAPI.AssertCalled(t, "KVSet", store.SubscriptionIndexMigrationKey, mock.Anything)
+}
+
+func TestMigrateSubscriptionIndexKeysInstanceURL(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	rootSubscription := serializer.SpaceSubscription{
+		SpaceKey: "TS",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "root",
+			BaseURL:   "http://wiki.corp",
+			ChannelID: "testtesttesttest",
+			Events:    []string{serializer.PageCreatedEvent},
+			Type:      serializer.SubscriptionTypeSpace,
+		},
+	}
+	unknownSubscription := serializer.SpaceSubscription{
+		SpaceKey: "TS",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "unknown",
+			BaseURL:   "https://other.corp",
+			ChannelID: "testtesttesttes1",
+			Events:    []string{serializer.PageCreatedEvent},
+			Type:      serializer.SubscriptionTypeSpace,
+		},
+	}
+
+	kv := map[string][]byte{}
+	monkey.Patch(GetSubscriptions, func() (serializer.Subscriptions, error) {
+		subscriptions := serializer.NewSubscriptions()
+		rootSubscription.Add(subscriptions)
+		unknownSubscription.Add(subscriptions)
+		return *subscriptions, nil
+	})
+	monkey.Patch(store.LoadInstances, func() ([]*types.Instance, error) {
+		return []*types.Instance{{URL: "https://wiki.corp/confluence"}}, nil
+	})
+	monkey.Patch(store.AtomicModify, func(key string, modify func(initialValue []byte) ([]byte, error)) error {
+		modified, err := modify(kv[key])
+		if err != nil {
+			return err
+		}
+		if modified == nil {
+			delete(kv, key)
+			return nil
+		}
+		kv[key] = modified
+		return nil
+	})
+
+	mockAPI := &plugintest.API{}
+	config.Mattermost = mockAPI
+	mockAPI.On("KVGet", store.SubscriptionIndexMigrationKey).Return(nil, nil)
+	mockAPI.On("KVDelete", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		delete(kv, args.String(0))
+	}).Return(nil)
+	mockAPI.On("KVSet", store.SubscriptionIndexMigrationKey, mock.Anything).Return(nil)
+	mockAPI.On("LogInfo", mock.Anything, mock.Anything, mock.Anything).Return()
+	mockAPI.On("LogWarn", mock.Anything, "ChannelID", "testtesttesttes1", "Subscription", "unknown", "BaseURL", "https://other.corp").Return()
+
+	assert.Nil(t, MigrateSubscriptionIndexKeys())
+
+	rootKey := store.GetURLSpaceKeySubscriptionsKey(store.GetURLSpaceKeyCombinationKey("https://wiki.corp/confluence", "TS"))
+	assert.JSONEq(t, `{"testtesttesttest":["page_created"]}`, string(kv[rootKey]))
+	assert.Contains(t, string(kv[store.GetChannelSubscriptionsKey("testtesttesttest")]), `"baseURL":"https://wiki.corp/confluence"`)
+
+	unknownKey := store.GetURLSpaceKeySubscriptionsKey(store.GetURLSpaceKeyCombinationKey("https://other.corp", "TS"))
+	assert.JSONEq(t, `{"testtesttesttes1":["page_created"]}`, string(kv[unknownKey]))
+	mockAPI.AssertCalled(t, "LogWarn", mock.Anything, "ChannelID", "testtesttesttes1", "Subscription", "unknown", "BaseURL", "https://other.corp")
+}
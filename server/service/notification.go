@@ -2,9 +2,11 @@ package service
 
 import (
 	"slices"
+	"time"
 
 	"github.com/mattermost/mattermost-plugin-confluence/server/config"
 	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
 	"github.com/mattermost/mattermost-plugin-confluence/server/util"
 )
 
@@ -17,22 +19,29 @@ func SendConfluenceNotifications(event serializer.ConfluenceEvent, eventType str
 	if post == nil || pageID == "" || url == "" || spaceKey == "" {
 		return
 	}
-	subscriptionChannelIDs := getNotificationChannelIDs(url, spaceKey, pageID, eventType)
-	for _, channelID := range subscriptionChannelIDs {
-		post.ChannelId = channelID
-		if _, err := config.Mattermost.CreatePost(post); err != nil {
+	notificationEvent := serializer.NewNotificationEvent(event, eventType)
+	channels := getNotifiedChannels(notificationEvent)
+	if len(channels) == 0 {
+		return
+	}
+
+	templates := LoadDefaultTemplates()
+	data := event.GetTemplateData(eventType)
+	for _, channel := range channels {
+		post.ChannelId = channel.ChannelID
+		if err := NotifyChannel(ChannelNotificationPost(post, notificationEvent, data, templates, channel.Subscriptions), notificationEvent, data); err != nil {
 			config.Mattermost.LogError("Unable to create Post in Mattermost", "Error", err.Error())
 		}
 	}
 }
 
-func getNotificationChannelIDs(url, spaceKey, pageID, eventType string) []string {
-	urlSpaceKeySubscriptions, err := GetSubscriptionsByURLSpaceKey(url, spaceKey)
+func getNotifiedChannels(event serializer.NotificationEvent) []NotifiedChannel {
+	urlSpaceKeySubscriptions, err := GetSubscriptionsByURLSpaceKey(event.BaseURL, event.SpaceKey)
 	if err != nil {
 		config.Mattermost.LogError("Unable to get subscribed channels.", "Error", err.Error())
 		return nil
 	}
-	urlPageIDSubscriptions, err := GetSubscriptionsByURLPageID(url, pageID)
+	urlPageIDSubscriptions, err := GetSubscriptionsByURLPageID(event.BaseURL, event.PageID)
 	if err != nil {
 		config.Mattermost.LogError("Unable to get subscribed channels.", "Error", err.Error())
 		return nil
@@ -41,12 +50,12 @@ func getNotificationChannelIDs(url, spaceKey, pageID, eventType string) []string
 	urlSpaceKeySubscriptionChannelIDs := make([]string, 0)
 	urlPageIDSubscriptionChannelIDs := make([]string, 0)
 	for channelID, events := range urlPageIDSubscriptions {
-		if slices.Contains(events, eventType) {
+		if slices.Contains(events, event.EventType) {
 			urlPageIDSubscriptionChannelIDs = append(urlPageIDSubscriptionChannelIDs, channelID)
 		}
 	}
 	for channelID, events := range urlSpaceKeySubscriptions {
-		if slices.Contains(events, eventType) {
+		if slices.Contains(events, event.EventType) {
 			urlSpaceKeySubscriptionChannelIDs = append(urlSpaceKeySubscriptionChannelIDs, channelID)
 		}
 	}
@@ -54,6 +63,99 @@ func getNotificationChannelIDs(url, spaceKey, pageID, eventType string) []string
 	var channelIDs []string
 	channelIDs = append(channelIDs, urlSpaceKeySubscriptionChannelIDs...)
 	channelIDs = append(channelIDs, urlPageIDSubscriptionChannelIDs...)
+	channelIDs = append(channelIDs, GetPageTreeChannelIDs(event.BaseURL, append([]string{event.PageID}, event.AncestorIDs...), event.EventType)...)
+	channelIDs = append(channelIDs, GetAllSpacesChannelIDs(event.BaseURL, event.EventType)...)
+	warnUnsupportedCQLSubscriptions(event)
+
+	return FilterNotifiedChannels(util.Deduplicate(channelIDs), event)
+}
+
+// NotifiedChannel is a channel to notify of an event, with the subscriptions of the channel that let the event through.
+type NotifiedChannel struct {
+	ChannelID     string
+	Subscriptions []serializer.Subscription
+}
+
+// FilterNotifiedChannels drops the channels where none of the subscriptions let the event through their filters,
+// like the labels and content type of space subscriptions or the author and minor edit options.
+// Subscriptions that are disabled, paused or within quiet hours let no event through, and count the events they suppress
+// unless they are disabled.
+// If the subscriptions of a channel can not be loaded, the channel is kept without subscriptions so no notification is lost.
+func FilterNotifiedChannels(channelIDs []string, event serializer.NotificationEvent) []NotifiedChannel {
+	now := time.Now()
+	filtered := make([]NotifiedChannel, 0, len(channelIDs))
+	for _, channelID := range channelIDs {
+		channelSubscriptions, err := GetSubscriptionsByChannelID(channelID)
+		if err != nil {
+			config.Mattermost.LogError("Unable to get channel subscriptions.", "ChannelID", channelID, "Error", err.Error())
+			filtered = append(filtered, NotifiedChannel{ChannelID: channelID})
+			continue
+		}
+
+		channelQuietHours, err := store.LoadChannelQuietHours(channelID)
+		if err != nil {
+			config.Mattermost.LogError("Unable to get channel quiet hours.", "ChannelID", channelID, "Error", err.Error())
+		}
+
+		var matched, silenced []serializer.Subscription
+		for _, subscription := range channelSubscriptions {
+			if !subscription.Matches(event) {
+				continue
+			}
+			if pauseState := subscription.GetPauseState(); pauseState.IsSilenced(now, channelQuietHours) {
+				if !pauseState.Disabled {
+					silenced = append(silenced, subscription)
+				}
+				continue
+			}
+			matched = append(matched, subscription)
+		}
+		if len(matched) > 0 || len(channelSubscriptions) == 0 {
+			filtered = append(filtered, NotifiedChannel{ChannelID: channelID, Subscriptions: matched})
+			continue
+		}
+
+		for _, subscription := range silenced {
+			if cErr := countSuppressedEvent(subscription); cErr != nil {
+				config.Mattermost.LogError("Unable to count the suppressed event.", "ChannelID", channelID, "Subscription", subscription.GetAlias(), "Error", cErr.Error())
+			}
+		}
+	}
+	return filtered
+}
+
+// GetPageTreeChannelIDs returns the channels with a page tree subscription for the event rooted at any of the given pages.
+func GetPageTreeChannelIDs(url string, pageIDs []string, eventType string) []string {
+	var channelIDs []string
+	for _, pageID := range pageIDs {
+		urlPageTreeIDSubscriptions, err := GetSubscriptionsByURLPageTreeID(url, pageID)
+		if err != nil {
+			config.Mattermost.LogError("Unable to get subscribed channels for page tree.", "PageID", pageID, "Error", err.Error())
+			continue
+		}
+		for channelID, events := range urlPageTreeIDSubscriptions {
+			if slices.Contains(events, eventType) {
+				channelIDs = append(channelIDs, channelID)
+			}
+		}
+	}
+	return channelIDs
+}
 
-	return util.Deduplicate(channelIDs)
+// GetAllSpacesChannelIDs returns the channels with an all spaces subscription to the instance for the event.
+// The excluded spaces are left for the subscriptions to match.
+func GetAllSpacesChannelIDs(url, eventType string) []string {
+	urlAllSpacesSubscriptions, err := GetSubscriptionsByURLAllSpaces(url)
+	if err != nil {
+		config.Mattermost.LogError("Unable to get subscribed channels for all spaces.", "Error", err.Error())
+		return nil
+	}
+
+	var channelIDs []string
+	for channelID, events := range urlAllSpacesSubscriptions {
+		if slices.Contains(events, eventType) {
+			channelIDs = append(channelIDs, channelID)
+		}
+	}
+	return channelIDs
 }
@@ -2,6 +2,7 @@ package service
 
 import (
 	"testing"
+	"time"
 
 	"bou.ke/monkey"
 	"github.com/mattermost/mattermost/server/public/model"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/mattermost/mattermost-plugin-confluence/server/config"
 	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
 )
 
 func baseMock() *plugintest.API {
@@ -26,9 +29,19 @@ func TestGetNotificationsChannelIDs(t *testing.T) {
 		spaceKey                            string
 		pageID                              string
 		event                               string
+		contentType                         string
+		labels                              []string
+		ancestorIDs                         []string
+		authors                             []string
+		minorEdit                           bool
 		expected                            int
 		urlSpaceKeyCombinationSubscriptions serializer.StringArrayMap
 		urlPageIDCombinationSubscriptions   serializer.StringArrayMap
+		channelSubscriptions                serializer.StringSubscription
+		urlPageTreeIDSubscriptions          map[string]serializer.StringArrayMap
+		urlAllSpacesSubscriptions           serializer.StringArrayMap
+		channelQuietHours                   *types.QuietHours
+		suppressed                          int
 	}{
 		"duplicated channel ids": {
 			baseURL:  "https://test.com",
@@ -106,6 +119,424 @@ func TestGetNotificationsChannelIDs(t *testing.T) {
 			urlPageIDCombinationSubscriptions: serializer.StringArrayMap{},
 			expected:                          1,
 		},
+		"page tree subscription on ancestor": {
+			baseURL:                             "https://test.com",
+			spaceKey:                            "TEST",
+			pageID:                              "3",
+			ancestorIDs:                         []string{"1", "2"},
+			event:                               serializer.PageUpdatedEvent,
+			urlSpaceKeyCombinationSubscriptions: serializer.StringArrayMap{},
+			urlPageIDCombinationSubscriptions:   serializer.StringArrayMap{},
+			urlPageTreeIDSubscriptions: map[string]serializer.StringArrayMap{
+				"1": {"testtesttesttest": {serializer.PageUpdatedEvent}},
+				"2": {"testtesttest1234": {serializer.PageCreatedEvent}},
+				"4": {"testtesttest1235": {serializer.PageUpdatedEvent}},
+			},
+			expected: 1,
+		},
+		"content type filter excludes blog post": {
+			baseURL:     "https://test.com",
+			spaceKey:    "TEST",
+			event:       serializer.BlogCreatedEvent,
+			contentType: serializer.ConfluenceContentTypeBlogPost,
+			urlSpaceKeyCombinationSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.BlogCreatedEvent},
+			},
+			urlPageIDCombinationSubscriptions: serializer.StringArrayMap{},
+			channelSubscriptions: serializer.StringSubscription{
+				"test": serializer.SpaceSubscription{
+					SpaceKey:    "TEST",
+					ContentType: serializer.ContentTypeFilterPages,
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:     "test",
+						BaseURL:   "https://test.com",
+						ChannelID: "testtesttesttest",
+						Events:    []string{serializer.BlogCreatedEvent},
+					},
+				},
+			},
+			expected: 0,
+		},
+		"content type filter includes blog post": {
+			baseURL:     "https://test.com",
+			spaceKey:    "TEST",
+			event:       serializer.BlogCreatedEvent,
+			contentType: serializer.ConfluenceContentTypeBlogPost,
+			urlSpaceKeyCombinationSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.BlogCreatedEvent},
+			},
+			urlPageIDCombinationSubscriptions: serializer.StringArrayMap{},
+			channelSubscriptions: serializer.StringSubscription{
+				"test": serializer.SpaceSubscription{
+					SpaceKey:    "TEST",
+					ContentType: serializer.ContentTypeFilterBlogs,
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:     "test",
+						BaseURL:   "https://test.com",
+						ChannelID: "testtesttesttest",
+						Events:    []string{serializer.BlogCreatedEvent},
+					},
+				},
+			},
+			expected: 1,
+		},
+		"minor edit ignored": {
+			baseURL:                             "https://test.com",
+			spaceKey:                            "TEST",
+			pageID:                              "1234",
+			event:                               serializer.PageUpdatedEvent,
+			minorEdit:                           true,
+			urlSpaceKeyCombinationSubscriptions: serializer.StringArrayMap{},
+			urlPageIDCombinationSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageUpdatedEvent},
+			},
+			channelSubscriptions: serializer.StringSubscription{
+				"test": serializer.PageSubscription{
+					PageID: "1234",
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:            "test",
+						BaseURL:          "https://test.com",
+						ChannelID:        "testtesttesttest",
+						Events:           []string{serializer.PageUpdatedEvent},
+						IgnoreMinorEdits: true,
+					},
+				},
+			},
+			expected: 0,
+		},
+		"excluded author": {
+			baseURL:                             "https://test.com",
+			spaceKey:                            "TEST",
+			pageID:                              "1234",
+			event:                               serializer.PageUpdatedEvent,
+			authors:                             []string{"8a7f808a", "bulk-bot"},
+			urlSpaceKeyCombinationSubscriptions: serializer.StringArrayMap{},
+			urlPageIDCombinationSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageUpdatedEvent},
+			},
+			channelSubscriptions: serializer.StringSubscription{
+				"test": serializer.PageSubscription{
+					PageID: "1234",
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:          "test",
+						BaseURL:        "https://test.com",
+						ChannelID:      "testtesttesttest",
+						Events:         []string{serializer.PageUpdatedEvent},
+						ExcludeAuthors: []string{"Bulk-Bot"},
+					},
+				},
+			},
+			expected: 0,
+		},
+		"allowed author": {
+			baseURL:  "https://test.com",
+			spaceKey: "TEST",
+			pageID:   "1234",
+			event:    serializer.PageUpdatedEvent,
+			authors:  []string{"8a7f808a", "jdoe"},
+			urlSpaceKeyCombinationSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageUpdatedEvent},
+			},
+			urlPageIDCombinationSubscriptions: serializer.StringArrayMap{},
+			channelSubscriptions: serializer.StringSubscription{
+				"test": serializer.SpaceSubscription{
+					SpaceKey: "TEST",
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:          "test",
+						BaseURL:        "https://test.com",
+						ChannelID:      "testtesttesttest",
+						Events:         []string{serializer.PageUpdatedEvent},
+						IncludeAuthors: []string{"8a7f808a"},
+					},
+				},
+			},
+			expected: 1,
+		},
+		"label filter excludes space subscription": {
+			baseURL:  "https://test.com",
+			spaceKey: "TEST",
+			event:    serializer.PageCreatedEvent,
+			labels:   []string{"draft", "release"},
+			urlSpaceKeyCombinationSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageCreatedEvent},
+			},
+			urlPageIDCombinationSubscriptions: serializer.StringArrayMap{},
+			channelSubscriptions: serializer.StringSubscription{
+				"test": serializer.SpaceSubscription{
+					SpaceKey:      "TEST",
+					IncludeLabels: []string{"release"},
+					ExcludeLabels: []string{"draft"},
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:     "test",
+						BaseURL:   "https://test.com",
+						ChannelID: "testtesttesttest",
+						Events:    []string{serializer.PageCreatedEvent},
+					},
+				},
+			},
+			expected: 0,
+		},
+		"label filter includes space subscription": {
+			baseURL:  "https://test.com",
+			spaceKey: "TEST",
+			event:    serializer.PageCreatedEvent,
+			labels:   []string{"Release"},
+			urlSpaceKeyCombinationSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageCreatedEvent},
+			},
+			urlPageIDCombinationSubscriptions: serializer.StringArrayMap{},
+			channelSubscriptions: serializer.StringSubscription{
+				"test": serializer.SpaceSubscription{
+					SpaceKey:      "TEST",
+					IncludeLabels: []string{"release"},
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:     "test",
+						BaseURL:   "https://test.com",
+						ChannelID: "testtesttesttest",
+						Events:    []string{serializer.PageCreatedEvent},
+					},
+				},
+			},
+			expected: 1,
+		},
+		"include label filter rejects events without labels": {
+			baseURL:  "https://test.com",
+			spaceKey: "TEST",
+			event:    serializer.PageCreatedEvent,
+			urlSpaceKeyCombinationSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageCreatedEvent},
+			},
+			urlPageIDCombinationSubscriptions: serializer.StringArrayMap{},
+			channelSubscriptions: serializer.StringSubscription{
+				"test": serializer.SpaceSubscription{
+					SpaceKey:      "TEST",
+					IncludeLabels: []string{"release"},
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:     "test",
+						BaseURL:   "https://test.com",
+						ChannelID: "testtesttesttest",
+						Events:    []string{serializer.PageCreatedEvent},
+					},
+				},
+			},
+			expected: 0,
+		},
+		"exclude label filter skipped for events without labels": {
+			baseURL:  "https://test.com",
+			spaceKey: "TEST",
+			event:    serializer.PageCreatedEvent,
+			urlSpaceKeyCombinationSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageCreatedEvent},
+			},
+			urlPageIDCombinationSubscriptions: serializer.StringArrayMap{},
+			channelSubscriptions: serializer.StringSubscription{
+				"test": serializer.SpaceSubscription{
+					SpaceKey:      "TEST",
+					ExcludeLabels: []string{"draft"},
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:     "test",
+						BaseURL:   "https://test.com",
+						ChannelID: "testtesttesttest",
+						Events:    []string{serializer.PageCreatedEvent},
+					},
+				},
+			},
+			expected: 1,
+		},
+		"label filter excludes content without labels": {
+			baseURL:  "https://test.com",
+			spaceKey: "TEST",
+			event:    serializer.PageCreatedEvent,
+			labels:   []string{},
+			urlSpaceKeyCombinationSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageCreatedEvent},
+			},
+			urlPageIDCombinationSubscriptions: serializer.StringArrayMap{},
+			channelSubscriptions: serializer.StringSubscription{
+				"test": serializer.SpaceSubscription{
+					SpaceKey:      "TEST",
+					IncludeLabels: []string{"release"},
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:     "test",
+						BaseURL:   "https://test.com",
+						ChannelID: "testtesttesttest",
+						Events:    []string{serializer.PageCreatedEvent},
+					},
+				},
+			},
+			expected: 0,
+		},
+		"paused subscription": {
+			baseURL:  "https://test.com",
+			spaceKey: "TEST",
+			pageID:   "1234",
+			event:    serializer.PageUpdatedEvent,
+			urlPageIDCombinationSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageUpdatedEvent},
+			},
+			channelSubscriptions: serializer.StringSubscription{
+				"test": serializer.PageSubscription{
+					PageID: "1234",
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:      "test",
+						BaseURL:    "https://test.com",
+						ChannelID:  "testtesttesttest",
+						Events:     []string{serializer.PageUpdatedEvent},
+						PauseState: serializer.PauseState{Paused: true},
+					},
+				},
+			},
+			expected:   0,
+			suppressed: 1,
+		},
+		"expired pause": {
+			baseURL:  "https://test.com",
+			spaceKey: "TEST",
+			pageID:   "1234",
+			event:    serializer.PageUpdatedEvent,
+			urlPageIDCombinationSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageUpdatedEvent},
+			},
+			channelSubscriptions: serializer.StringSubscription{
+				"test": serializer.PageSubscription{
+					PageID: "1234",
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:      "test",
+						BaseURL:    "https://test.com",
+						ChannelID:  "testtesttesttest",
+						Events:     []string{serializer.PageUpdatedEvent},
+						PauseState: serializer.PauseState{Paused: true, PausedUntil: time.Now().Add(-time.Hour).UnixMilli()},
+					},
+				},
+			},
+			expected: 1,
+		},
+		"paused subscription with an active one in the channel": {
+			baseURL:  "https://test.com",
+			spaceKey: "TEST",
+			pageID:   "1234",
+			event:    serializer.PageUpdatedEvent,
+			urlSpaceKeyCombinationSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageUpdatedEvent},
+			},
+			urlPageIDCombinationSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageUpdatedEvent},
+			},
+			channelSubscriptions: serializer.StringSubscription{
+				"page": serializer.PageSubscription{
+					PageID: "1234",
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:      "page",
+						BaseURL:    "https://test.com",
+						ChannelID:  "testtesttesttest",
+						Events:     []string{serializer.PageUpdatedEvent},
+						PauseState: serializer.PauseState{Paused: true},
+					},
+				},
+				"space": serializer.SpaceSubscription{
+					SpaceKey: "TEST",
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:     "space",
+						BaseURL:   "https://test.com",
+						ChannelID: "testtesttesttest",
+						Events:    []string{serializer.PageUpdatedEvent},
+					},
+				},
+			},
+			expected: 1,
+		},
+		"all spaces subscription": {
+			baseURL:  "https://test.com",
+			spaceKey: "TEST",
+			pageID:   "1234",
+			event:    serializer.PageCreatedEvent,
+			urlAllSpacesSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageCreatedEvent},
+			},
+			channelSubscriptions: serializer.StringSubscription{
+				"everything": serializer.AllSpacesSubscription{
+					ExcludeSpaces:         []string{"SANDBOX"},
+					ExcludePersonalSpaces: true,
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:     "everything",
+						BaseURL:   "https://test.com",
+						ChannelID: "testtesttesttest",
+						Events:    []string{serializer.PageCreatedEvent},
+					},
+				},
+			},
+			expected: 1,
+		},
+		"all spaces subscription excluded space": {
+			baseURL:  "https://test.com",
+			spaceKey: "sandbox",
+			pageID:   "1234",
+			event:    serializer.PageCreatedEvent,
+			urlAllSpacesSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageCreatedEvent},
+			},
+			channelSubscriptions: serializer.StringSubscription{
+				"everything": serializer.AllSpacesSubscription{
+					ExcludeSpaces:         []string{"SANDBOX"},
+					ExcludePersonalSpaces: true,
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:     "everything",
+						BaseURL:   "https://test.com",
+						ChannelID: "testtesttesttest",
+						Events:    []string{serializer.PageCreatedEvent},
+					},
+				},
+			},
+			expected: 0,
+		},
+		"all spaces subscription personal space": {
+			baseURL:  "https://test.com",
+			spaceKey: "~jsmith",
+			pageID:   "1234",
+			event:    serializer.PageCreatedEvent,
+			urlAllSpacesSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageCreatedEvent},
+			},
+			channelSubscriptions: serializer.StringSubscription{
+				"everything": serializer.AllSpacesSubscription{
+					ExcludeSpaces:         []string{"SANDBOX"},
+					ExcludePersonalSpaces: true,
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:     "everything",
+						BaseURL:   "https://test.com",
+						ChannelID: "testtesttesttest",
+						Events:    []string{serializer.PageCreatedEvent},
+					},
+				},
+			},
+			expected: 0,
+		},
+		"channel quiet hours": {
+			baseURL:  "https://test.com",
+			spaceKey: "TEST",
+			pageID:   "1234",
+			event:    serializer.PageUpdatedEvent,
+			urlPageIDCombinationSubscriptions: serializer.StringArrayMap{
+				"testtesttesttest": {serializer.PageUpdatedEvent},
+			},
+			channelSubscriptions: serializer.StringSubscription{
+				"test": serializer.PageSubscription{
+					PageID: "1234",
+					BaseSubscription: serializer.BaseSubscription{
+						Alias:     "test",
+						BaseURL:   "https://test.com",
+						ChannelID: "testtesttesttest",
+						Events:    []string{serializer.PageUpdatedEvent},
+					},
+				},
+			},
+			channelQuietHours: &types.QuietHours{
+				Start: time.Now().UTC().Add(-time.Hour).Format("15:04"),
+				End:   time.Now().UTC().Add(time.Hour).Format("15:04"),
+			},
+			expected:   0,
+			suppressed: 1,
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			defer monkey.UnpatchAll()
@@ -128,8 +559,39 @@ func TestGetNotificationsChannelIDs(t *testing.T) {
 			monkey.Patch(GetSubscriptionsByURLPageID, func(url, pageID string) (serializer.StringArrayMap, error) {
 				return val.urlPageIDCombinationSubscriptions, nil
 			})
-			channelIDs := getNotificationChannelIDs(val.baseURL, val.spaceKey, val.pageID, val.event)
-			assert.Equal(t, val.expected, len(channelIDs))
+			monkey.Patch(GetSubscriptionsByChannelID, func(channelID string) (serializer.StringSubscription, error) {
+				return val.channelSubscriptions, nil
+			})
+			monkey.Patch(GetSubscriptionsByURLPageTreeID, func(url, pageID string) (serializer.StringArrayMap, error) {
+				return val.urlPageTreeIDSubscriptions[pageID], nil
+			})
+			monkey.Patch(GetSubscriptionsByURLAllSpaces, func(url string) (serializer.StringArrayMap, error) {
+				return val.urlAllSpacesSubscriptions, nil
+			})
+			monkey.Patch(GetSubscriptionsByURLCQL, func(url string) (serializer.StringArrayMap, error) {
+				return nil, nil
+			})
+			monkey.Patch(store.LoadChannelQuietHours, func(channelID string) (*types.QuietHours, error) {
+				return val.channelQuietHours, nil
+			})
+			suppressed := 0
+			monkey.Patch(countSuppressedEvent, func(subscription serializer.Subscription) error {
+				suppressed++
+				return nil
+			})
+			channels := getNotifiedChannels(serializer.NotificationEvent{
+				BaseURL:     val.baseURL,
+				SpaceKey:    val.spaceKey,
+				PageID:      val.pageID,
+				EventType:   val.event,
+				ContentType: val.contentType,
+				Labels:      val.labels,
+				AncestorIDs: val.ancestorIDs,
+				Authors:     val.authors,
+				MinorEdit:   val.minorEdit,
+			})
+			assert.Equal(t, val.expected, len(channels))
+			assert.Equal(t, val.suppressed, suppressed)
 		})
 	}
 }
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+const generalPauseError = "error occurred while updating subscription with name **%s**"
+
+// PauseSubscription silences the subscription until the given time in milliseconds since the epoch,
+// or until it is resumed when the time is zero. The count of suppressed events restarts.
+func PauseSubscription(channelID, alias string, until int64) error {
+	if err := FlushSuppressedEvents(); err != nil {
+		config.Mattermost.LogError("Unable to store the counts of suppressed events.", "Error", err.Error())
+	}
+
+	_, err := modifyPauseState(channelID, alias, func(pauseState *serializer.PauseState) {
+		pauseState.Paused = true
+		pauseState.PausedUntil = until
+		pauseState.SuppressedEvents = 0
+	})
+	return err
+}
+
+// ResumeSubscription ends the pause of the subscription and returns how many events were suppressed meanwhile.
+// Events counted by other servers of a cluster since they last stored their counts are not included.
+func ResumeSubscription(channelID, alias string) (int, error) {
+	if err := FlushSuppressedEvents(); err != nil {
+		config.Mattermost.LogError("Unable to store the counts of suppressed events.", "Error", err.Error())
+	}
+
+	previous, err := modifyPauseState(channelID, alias, func(pauseState *serializer.PauseState) {
+		pauseState.Paused = false
+		pauseState.PausedUntil = 0
+		pauseState.SuppressedEvents = 0
+	})
+	return previous.SuppressedEvents, err
+}
+
+// SetSubscriptionQuietHours sets the quiet hours of the subscription, nil quiet hours remove them.
+func SetSubscriptionQuietHours(channelID, alias string, quietHours *types.QuietHours) error {
+	_, err := modifyPauseState(channelID, alias, func(pauseState *serializer.PauseState) {
+		pauseState.QuietHours = quietHours
+	})
+	return err
+}
+
+// suppressedEventsFlushInterval is how often the counts of suppressed events are added to the stored subscriptions,
+// so a silenced subscription of a busy space does not rewrite its records for every event.
+const suppressedEventsFlushInterval = time.Minute
+
+type suppressedEventKey struct {
+	channelID string
+	alias     string
+}
+
+// suppressedEventCounts holds the events suppressed since the counts were last stored, by subscription.
+type suppressedEventCounts struct {
+	sync.Mutex
+	counts    map[suppressedEventKey]int
+	lastFlush time.Time
+}
+
+var suppressedEvents = &suppressedEventCounts{counts: map[suppressedEventKey]int{}}
+
+// add counts an event of the subscription and returns the counts to store once the flush interval has passed.
+func (c *suppressedEventCounts) add(subscription serializer.Subscription, now time.Time) map[suppressedEventKey]int {
+	c.Lock()
+	defer c.Unlock()
+	c.counts[suppressedEventKey{subscription.GetChannelID(), subscription.GetAlias()}]++
+	if now.Sub(c.lastFlush) < suppressedEventsFlushInterval {
+		return nil
+	}
+	return c.takeAll(now)
+}
+
+func (c *suppressedEventCounts) takeAll(now time.Time) map[suppressedEventKey]int {
+	counts := c.counts
+	c.counts = map[suppressedEventKey]int{}
+	c.lastFlush = now
+	return counts
+}
+
+// countSuppressedEvent records that an event of the subscription was dropped because it is silenced.
+// The counts are kept in memory and stored at most once per flush interval.
+func countSuppressedEvent(subscription serializer.Subscription) error {
+	return storeSuppressedEvents(suppressedEvents.add(subscription, time.Now()))
+}
+
+// FlushSuppressedEvents stores the counts of suppressed events not stored yet, so they are not lost when the plugin stops.
+func FlushSuppressedEvents() error {
+	suppressedEvents.Lock()
+	counts := suppressedEvents.takeAll(time.Now())
+	suppressedEvents.Unlock()
+	return storeSuppressedEvents(counts)
+}
+
+func storeSuppressedEvents(counts map[suppressedEventKey]int) error {
+	var firstErr error
+	for key, count := range counts {
+		_, err := modifyPauseState(key.channelID, key.alias, func(pauseState *serializer.PauseState) {
+			pauseState.SuppressedEvents += count
+		})
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// modifyPauseState applies modify to the pause state of the stored subscription and returns the state it had before.
+func modifyPauseState(channelID, alias string, modify func(pauseState *serializer.PauseState)) (serializer.PauseState, error) {
+	channelSubscriptions, err := GetSubscriptionsByChannelID(channelID)
+	if err != nil {
+		return serializer.PauseState{}, fmt.Errorf(generalPauseError, alias)
+	}
+	subscription, ok := channelSubscriptions.GetInsensitiveCase(alias)
+	if !ok {
+		return serializer.PauseState{}, fmt.Errorf(subscriptionNotFound, alias)
+	}
+
+	var previous serializer.PauseState
+	err = modifySubscriptionRecords(func(s *serializer.Subscriptions) {
+		// The stored subscription is modified rather than the loaded one, so concurrent changes are not lost.
+		current, found := s.ByChannelID[channelID][subscription.GetAlias()]
+		if !found {
+			return
+		}
+		previous = current.GetPauseState()
+		pauseState := previous
+		modify(&pauseState)
+		s.ByChannelID[channelID][subscription.GetAlias()] = current.WithPauseState(pauseState)
+	}, subscription)
+	return previous, err
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+)
+
+func TestPauseAndResumeSubscription(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	kv := map[string][]byte{}
+	monkey.Patch(store.AtomicModify, func(key string, modify func(initialValue []byte) ([]byte, error)) error {
+		modified, err := modify(kv[key])
+		if err != nil {
+			return err
+		}
+		kv[key] = modified
+		return nil
+	})
+	monkey.Patch(GetSubscriptionsByChannelID, func(channelID string) (serializer.StringSubscription, error) {
+		subscriptions := serializer.NewSubscriptions()
+		record := subscriptionRecord{kind: channelRecord, id: channelID}
+		if err := record.unmarshal(kv[record.key()], subscriptions); err != nil {
+			return nil, err
+		}
+		return subscriptions.ByChannelID[channelID], nil
+	})
+
+	subscription := serializer.SpaceSubscription{
+		SpaceKey: "TS",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "Docs",
+			BaseURL:   "https://test.com",
+			ChannelID: "testtesttesttest",
+			Events:    []string{serializer.PageCreatedEvent},
+			Type:      serializer.SubscriptionTypeSpace,
+		},
+	}
+	assert.Nil(t, modifySubscriptionRecords(subscription.Add, subscription))
+
+	assert.Nil(t, PauseSubscription("testtesttesttest", "docs", 0))
+	assert.Nil(t, countSuppressedEvent(subscription))
+	assert.Nil(t, countSuppressedEvent(subscription))
+	assert.Nil(t, FlushSuppressedEvents())
+
+	channelSubscriptions, err := GetSubscriptionsByChannelID("testtesttesttest")
+	assert.Nil(t, err)
+	assert.Equal(t, serializer.PauseState{Paused: true, SuppressedEvents: 2}, channelSubscriptions["Docs"].GetPauseState())
+
+	// The count of the last event is only kept in memory until the subscription is resumed.
+	assert.Nil(t, countSuppressedEvent(subscription))
+	channelSubscriptions, err = GetSubscriptionsByChannelID("testtesttesttest")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, channelSubscriptions["Docs"].GetPauseState().SuppressedEvents)
+
+	suppressed, err := ResumeSubscription("testtesttesttest", "Docs")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, suppressed)
+
+	channelSubscriptions, err = GetSubscriptionsByChannelID("testtesttesttest")
+	assert.Nil(t, err)
+	assert.Equal(t, serializer.PauseState{}, channelSubscriptions["Docs"].GetPauseState())
+
+	assert.NotNil(t, PauseSubscription("testtesttesttest", "missing", 0))
+}
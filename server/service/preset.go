@@ -0,0 +1,36 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+// ApplyPresetToSubscriptions gives the events and filters of the preset to every subscription created from it,
+// and returns how many subscriptions were updated. A subscription that can not be updated is logged and skipped.
+func ApplyPresetToSubscriptions(preset *types.Preset, userID string) (int, error) {
+	subscriptions, err := GetSubscriptions()
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, channelSubscriptions := range subscriptions.ByChannelID {
+		for _, subscription := range channelSubscriptions {
+			if !strings.EqualFold(subscription.GetPreset(), preset.Name) {
+				continue
+			}
+
+			applied := serializer.ApplyPreset(subscription, preset)
+			applied = applied.WithAuditInfo(subscription.GetAuditInfo().Updated(userID))
+			if mErr := modifySubscriptionRecords(applied.Add, applied); mErr != nil {
+				config.Mattermost.LogError("Unable to apply the preset to a subscription.", "Preset", preset.Name, "ChannelID", subscription.GetChannelID(), "Subscription", subscription.GetAlias(), "Error", mErr.Error())
+				continue
+			}
+			updated++
+		}
+	}
+	return updated, nil
+}
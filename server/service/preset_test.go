@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+func TestApplyPresetToSubscriptions(t *testing.T) {
+	defer monkey.UnpatchAll()
+	spaceSubscription := serializer.SpaceSubscription{
+		SpaceKey: "TS",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "space",
+			BaseURL:   "https://test.com",
+			ChannelID: "channel",
+			Events:    []string{serializer.PageCreatedEvent},
+			Preset:    "Docs Watch",
+			AuditInfo: serializer.AuditInfo{CreatedBy: "creator"},
+		},
+	}
+	pageSubscription := serializer.PageSubscription{
+		PageID: "1234",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "page",
+			BaseURL:   "https://test.com",
+			ChannelID: "channel",
+			Events:    []string{serializer.PageCreatedEvent},
+			Preset:    "docs watch",
+		},
+	}
+	otherSubscription := serializer.PageSubscription{
+		PageID: "5678",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "other",
+			BaseURL:   "https://test.com",
+			ChannelID: "channel",
+			Events:    []string{serializer.PageCreatedEvent},
+		},
+	}
+
+	monkey.Patch(GetSubscriptions, func() (serializer.Subscriptions, error) {
+		subscriptions := serializer.NewSubscriptions()
+		spaceSubscription.Add(subscriptions)
+		pageSubscription.Add(subscriptions)
+		otherSubscription.Add(subscriptions)
+		return *subscriptions, nil
+	})
+	updated := map[string]serializer.Subscription{}
+	monkey.Patch(modifySubscriptionRecords, func(modify func(*serializer.Subscriptions), subscriptions ...serializer.Subscription) error {
+		for _, subscription := range subscriptions {
+			updated[subscription.GetAlias()] = subscription
+		}
+		return nil
+	})
+
+	preset := &types.Preset{
+		Name:          "Docs Watch",
+		Events:        []string{serializer.PageUpdatedEvent},
+		ContentType:   serializer.ContentTypeFilterPages,
+		IncludeLabels: []string{"docs"},
+	}
+	count, err := ApplyPresetToSubscriptions(preset, "admin")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Len(t, updated, 2)
+
+	space := updated["space"].(serializer.SpaceSubscription)
+	assert.Equal(t, []string{serializer.PageUpdatedEvent}, space.Events)
+	assert.Equal(t, serializer.ContentTypeFilterPages, space.ContentType)
+	assert.Equal(t, []string{"docs"}, space.IncludeLabels)
+	assert.Equal(t, "creator", space.CreatedBy)
+	assert.Equal(t, "admin", space.UpdatedBy)
+
+	page := updated["page"].(serializer.PageSubscription)
+	assert.Equal(t, []string{serializer.PageUpdatedEvent}, page.Events)
+	assert.Equal(t, "Docs Watch", page.Preset)
+}
@@ -1,12 +1,10 @@
 package service
 
 import (
-	"encoding/json"
 	"errors"
 	"net/http"
 
 	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
-	"github.com/mattermost/mattermost-plugin-confluence/server/store"
 )
 
 const (
@@ -17,26 +15,17 @@ const (
 )
 
 func SaveSubscription(subscription serializer.Subscription) (int, error) {
-	subs, gErr := GetSubscriptions()
+	subs, gErr := loadSubscriptionRecords(subscriptionRecords(subscription)...)
 	if gErr != nil {
 		return http.StatusInternalServerError, errors.New(generalSaveError)
 	}
 	if vErr := subscription.ValidateSubscription(&subs); vErr != nil {
 		return http.StatusBadRequest, vErr
 	}
-	key := store.GetSubscriptionKey()
-	if err := store.AtomicModify(key, func(initialBytes []byte) ([]byte, error) {
-		subscriptions, err := serializer.SubscriptionsFromJSON(initialBytes)
-		if err != nil {
-			return nil, err
-		}
-		subscription.Add(subscriptions)
-		modifiedBytes, marshalErr := json.Marshal(subscriptions)
-		if marshalErr != nil {
-			return nil, marshalErr
-		}
-		return modifiedBytes, nil
-	}); err != nil {
+	if statusCode, cErr := CheckCQLSubscription(subscription); cErr != nil {
+		return statusCode, cErr
+	}
+	if err := modifySubscriptionRecords(subscription.Add, subscription); err != nil {
 		return http.StatusInternalServerError, err
 	}
 	return http.StatusOK, nil
@@ -98,17 +98,17 @@ func TestSaveSpaceSubscription(t *testing.T) {
 					},
 				},
 				ByURLSpaceKey: map[string]serializer.StringArrayMap{
-					"confluence_subs/test.com/TS": {
+					"confluence_subs/https://test.com/TS": {
 						"testtesttesttest": {serializer.CommentRemovedEvent, serializer.CommentUpdatedEvent},
 					},
 				},
 				ByURLPageID: map[string]serializer.StringArrayMap{
-					"confluence_subs/test.com/1234": {
+					"confluence_subs/https://test.com/1234": {
 						"testtesttesttes1": {serializer.CommentCreatedEvent, serializer.CommentUpdatedEvent},
 					},
 				},
 			}
-			monkey.Patch(GetSubscriptions, func() (serializer.Subscriptions, error) {
+			monkey.Patch(loadSubscriptionRecords, func(...subscriptionRecord) (serializer.Subscriptions, error) {
 				return subscriptions, nil
 			})
 			monkey.Patch(store.AtomicModify, func(key string, modify func(initialValue []byte) ([]byte, error)) error {
@@ -210,17 +210,17 @@ func TestSavePageSubscription(t *testing.T) {
 					},
 				},
 				ByURLSpaceKey: map[string]serializer.StringArrayMap{
-					"confluence_subs/test.com/TS": {
+					"confluence_subs/https://test.com/TS": {
 						"testtesttesttest": {serializer.CommentRemovedEvent, serializer.CommentUpdatedEvent},
 					},
 				},
 				ByURLPageID: map[string]serializer.StringArrayMap{
-					"confluence_subs/test.com/1234": {
+					"confluence_subs/https://test.com/1234": {
 						"testtesttesttes1": {serializer.CommentCreatedEvent, serializer.CommentUpdatedEvent},
 					},
 				},
 			}
-			monkey.Patch(GetSubscriptions, func() (serializer.Subscriptions, error) {
+			monkey.Patch(loadSubscriptionRecords, func(...subscriptionRecord) (serializer.Subscriptions, error) {
 				return subscriptions, nil
 			})
 			monkey.Patch(store.AtomicModify, func(key string, modify func(initialValue []byte) ([]byte, error)) error {
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+)
+
+const kvListPerPage = 100
+
+type recordKind int
+
+const (
+	channelRecord recordKind = iota
+	urlPageIDRecord
+	urlSpaceKeyRecord
+	urlPageTreeIDRecord
+	urlCQLRecord
+	urlAllSpacesRecord
+)
+
+// subscriptionRecord identifies one KV record backing a part of serializer.Subscriptions:
+// the subscriptions of a single channel, or a single entry of one of the indexes.
+type subscriptionRecord struct {
+	kind recordKind
+	id   string
+}
+
+func (r subscriptionRecord) key() string {
+	switch r.kind {
+	case urlPageIDRecord:
+		return store.GetURLPageIDSubscriptionsKey(r.id)
+	case urlSpaceKeyRecord:
+		return store.GetURLSpaceKeySubscriptionsKey(r.id)
+	case urlPageTreeIDRecord:
+		return store.GetURLPageTreeIDSubscriptionsKey(r.id)
+	case urlCQLRecord:
+		return store.GetURLCQLSubscriptionsKey(r.id)
+	case urlAllSpacesRecord:
+		return store.GetURLAllSpacesSubscriptionsKey(r.id)
+	default:
+		return store.GetChannelSubscriptionsKey(r.id)
+	}
+}
+
+// index returns the index of subscriptions an index record belongs to.
+func (r subscriptionRecord) index(subscriptions *serializer.Subscriptions) map[string]serializer.StringArrayMap {
+	switch r.kind {
+	case urlPageIDRecord:
+		return subscriptions.ByURLPageID
+	case urlPageTreeIDRecord:
+		return subscriptions.ByURLPageTreeID
+	case urlCQLRecord:
+		return subscriptions.ByURLCQL
+	case urlAllSpacesRecord:
+		return subscriptions.ByURLAllSpaces
+	default:
+		return subscriptions.ByURLSpaceKey
+	}
+}
+
+// unmarshal reads the record value into its place in subscriptions.
+func (r subscriptionRecord) unmarshal(data []byte, subscriptions *serializer.Subscriptions) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	switch r.kind {
+	case urlPageIDRecord, urlSpaceKeyRecord, urlPageTreeIDRecord, urlCQLRecord, urlAllSpacesRecord:
+		var value serializer.StringArrayMap
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		r.index(subscriptions)[r.id] = value
+	default:
+		var value serializer.StringSubscription
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		subscriptions.ByChannelID[r.id] = value
+	}
+	return nil
+}
+
+// marshal returns the record value from subscriptions. An empty record marshals to nil so it gets deleted.
+func (r subscriptionRecord) marshal(subscriptions *serializer.Subscriptions) ([]byte, error) {
+	var value interface{}
+	switch r.kind {
+	case urlPageIDRecord, urlSpaceKeyRecord, urlPageTreeIDRecord, urlCQLRecord, urlAllSpacesRecord:
+		if len(r.index(subscriptions)[r.id]) == 0 {
+			return nil, nil
+		}
+		value = r.index(subscriptions)[r.id]
+	default:
+		if len(subscriptions.ByChannelID[r.id]) == 0 {
+			return nil, nil
+		}
+		value = subscriptions.ByChannelID[r.id]
+	}
+	return json.Marshal(value)
+}
+
+// subscriptionRecords returns the records the given subscriptions are stored in.
+// They are found by adding the subscriptions to an empty set, so every index a subscription type writes to is covered.
+func subscriptionRecords(subscriptions ...serializer.Subscription) []subscriptionRecord {
+	touched := serializer.NewSubscriptions()
+	for _, subscription := range subscriptions {
+		subscription.Add(touched)
+	}
+
+	var records []subscriptionRecord
+	for channelID := range touched.ByChannelID {
+		records = append(records, subscriptionRecord{kind: channelRecord, id: channelID})
+	}
+	for key := range touched.ByURLPageID {
+		records = append(records, subscriptionRecord{kind: urlPageIDRecord, id: key})
+	}
+	for key := range touched.ByURLSpaceKey {
+		records = append(records, subscriptionRecord{kind: urlSpaceKeyRecord, id: key})
+	}
+	for key := range touched.ByURLPageTreeID {
+		records = append(records, subscriptionRecord{kind: urlPageTreeIDRecord, id: key})
+	}
+	for key := range touched.ByURLCQL {
+		records = append(records, subscriptionRecord{kind: urlCQLRecord, id: key})
+	}
+	for key := range touched.ByURLAllSpaces {
+		records = append(records, subscriptionRecord{kind: urlAllSpacesRecord, id: key})
+	}
+	return records
+}
+
+// loadSubscriptionRecords returns a partial set of subscriptions holding only the given records.
+func loadSubscriptionRecords(records ...subscriptionRecord) (serializer.Subscriptions, error) {
+	subscriptions := serializer.NewSubscriptions()
+	for _, record := range records {
+		data, appErr := config.Mattermost.KVGet(record.key())
+		if appErr != nil {
+			return serializer.Subscriptions{}, errors.Wrap(appErr, "failed to load subscriptions")
+		}
+		if err := record.unmarshal(data, subscriptions); err != nil {
+			return serializer.Subscriptions{}, errors.Wrap(err, "failed to unmarshal subscriptions")
+		}
+	}
+	return *subscriptions, nil
+}
+
+// modifySubscriptionRecords applies modify to every record the given subscriptions are stored in.
+// Each record is updated in its own atomic transaction, so writers only contend on the records they share.
+// If a record fails to update, the records already updated are restored, so the channel records and the indexes
+// keep agreeing. A restored record that was written again meanwhile is left as is, and the failure is logged.
+func modifySubscriptionRecords(modify func(*serializer.Subscriptions), subscriptions ...serializer.Subscription) error {
+	var written []writtenRecord
+	for _, record := range subscriptionRecords(subscriptions...) {
+		var initial, modified []byte
+		err := store.AtomicModify(record.key(), func(initialBytes []byte) ([]byte, error) {
+			partial := serializer.NewSubscriptions()
+			if err := record.unmarshal(initialBytes, partial); err != nil {
+				return nil, err
+			}
+			modify(partial)
+			data, err := record.marshal(partial)
+			initial, modified = initialBytes, data
+			return data, err
+		})
+		if err != nil {
+			restoreSubscriptionRecords(written)
+			return err
+		}
+		if !bytes.Equal(initial, modified) {
+			written = append(written, writtenRecord{key: record.key(), initial: initial, written: modified})
+		}
+	}
+	return nil
+}
+
+// writtenRecord is a record updated by modifySubscriptionRecords, with its value before and after the update.
+type writtenRecord struct {
+	key              string
+	initial, written []byte
+}
+
+// restoreSubscriptionRecords restores the records to their value before they were written, in the reverse order of the writes.
+func restoreSubscriptionRecords(written []writtenRecord) {
+	for i := len(written) - 1; i >= 0; i-- {
+		record := written[i]
+		err := store.AtomicModify(record.key, func(currentBytes []byte) ([]byte, error) {
+			if !bytes.Equal(currentBytes, record.written) {
+				return nil, errors.New("the record was written again meanwhile")
+			}
+			return record.initial, nil
+		})
+		if err != nil {
+			config.Mattermost.LogError("Unable to restore a subscription record after a failed update.", "Key", record.key, "Error", err.Error())
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+)
+
+func TestModifySubscriptionRecords(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	kv := map[string][]byte{}
+	monkey.Patch(store.AtomicModify, func(key string, modify func(initialValue []byte) ([]byte, error)) error {
+		modified, err := modify(kv[key])
+		if err != nil {
+			return err
+		}
+		if modified == nil {
+			delete(kv, key)
+			return nil
+		}
+		kv[key] = modified
+		return nil
+	})
+
+	spaceSubscription := serializer.SpaceSubscription{
+		SpaceKey: "TS",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "space",
+			BaseURL:   "https://test.com",
+			ChannelID: "testtesttesttest",
+			Events:    []string{serializer.CommentCreatedEvent},
+			Type:      serializer.SubscriptionTypeSpace,
+		},
+	}
+	pageSubscription := serializer.PageSubscription{
+		PageID: "1234",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "page",
+			BaseURL:   "https://test.com",
+			ChannelID: "testtesttesttest",
+			Events:    []string{serializer.PageUpdatedEvent},
+			Type:      serializer.SubscriptionTypePage,
+		},
+	}
+
+	assert.Nil(t, modifySubscriptionRecords(spaceSubscription.Add, spaceSubscription))
+	assert.Nil(t, modifySubscriptionRecords(pageSubscription.Add, pageSubscription))
+	assert.Len(t, kv, 3)
+
+	channelKey := store.GetChannelSubscriptionsKey("testtesttesttest")
+	pageKey := store.GetURLPageIDSubscriptionsKey(store.GetURLPageIDCombinationKey("https://test.com", "1234"))
+	spaceKey := store.GetURLSpaceKeySubscriptionsKey(store.GetURLSpaceKeyCombinationKey("https://test.com", "TS"))
+	for _, key := range []string{channelKey, pageKey, spaceKey} {
+		assert.Contains(t, kv, key)
+	}
+
+	partial := serializer.NewSubscriptions()
+	record := subscriptionRecord{kind: channelRecord, id: "testtesttesttest"}
+	assert.Nil(t, record.unmarshal(kv[channelKey], partial))
+	assert.Equal(t, serializer.StringSubscription{"space": spaceSubscription, "page": pageSubscription}, partial.ByChannelID["testtesttesttest"])
+
+	assert.Nil(t, modifySubscriptionRecords(pageSubscription.Remove, pageSubscription))
+	assert.NotContains(t, kv, pageKey)
+	assert.Contains(t, kv, channelKey)
+
+	assert.Nil(t, modifySubscriptionRecords(spaceSubscription.Remove, spaceSubscription))
+	assert.Empty(t, kv)
+}
+
+func TestModifySubscriptionRecordsRestoresOnFailure(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	pageKey := store.GetURLPageIDSubscriptionsKey(store.GetURLPageIDCombinationKey("https://test.com", "1234"))
+	kv := map[string][]byte{}
+	failing := true
+	monkey.Patch(store.AtomicModify, func(key string, modify func(initialValue []byte) ([]byte, error)) error {
+		if key == pageKey && failing {
+			return errors.New("reached write attempt limit")
+		}
+		modified, err := modify(kv[key])
+		if err != nil {
+			return err
+		}
+		if modified == nil {
+			delete(kv, key)
+			return nil
+		}
+		kv[key] = modified
+		return nil
+	})
+
+	pageSubscription := serializer.PageSubscription{
+		PageID: "1234",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "page",
+			BaseURL:   "https://test.com",
+			ChannelID: "testtesttesttest",
+			Events:    []string{serializer.PageUpdatedEvent},
+			Type:      serializer.SubscriptionTypePage,
+		},
+	}
+
+	assert.Error(t, modifySubscriptionRecords(pageSubscription.Add, pageSubscription))
+	assert.Empty(t, kv, "the records written before the failure are restored")
+
+	failing = false
+	assert.NoError(t, modifySubscriptionRecords(pageSubscription.Add, pageSubscription))
+	assert.Len(t, kv, 2)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+)
+
+const generalTemplateError = "error occurred while updating the templates of subscription with name **%s**"
+
+// SetSubscriptionTemplate sets the notification template of the subscription for the event type,
+// an empty template removes it so the default one is used again.
+func SetSubscriptionTemplate(channelID, alias, eventType, text string) error {
+	channelSubscriptions, err := GetSubscriptionsByChannelID(channelID)
+	if err != nil {
+		return fmt.Errorf(generalTemplateError, alias)
+	}
+	subscription, ok := channelSubscriptions.GetInsensitiveCase(alias)
+	if !ok {
+		return fmt.Errorf(subscriptionNotFound, alias)
+	}
+
+	return modifySubscriptionRecords(func(s *serializer.Subscriptions) {
+		// The stored subscription is modified rather than the loaded one, so concurrent changes are not lost.
+		current, found := s.ByChannelID[channelID][subscription.GetAlias()]
+		if !found {
+			return
+		}
+		templates := maps.Clone(current.GetTemplates())
+		if templates == nil {
+			templates = map[string]string{}
+		}
+		templates[eventType] = text
+		if text == "" {
+			delete(templates, eventType)
+		}
+		if len(templates) == 0 {
+			templates = nil
+		}
+		s.ByChannelID[channelID][subscription.GetAlias()] = current.WithTemplates(templates)
+	}, subscription)
+}
+
+// defaultTemplatesCacheTTL is how long the default notification templates are reused, so events do not load them each time.
+// Other servers of a cluster pick up a change within this time.
+const defaultTemplatesCacheTTL = time.Minute
+
+var defaultTemplatesCache struct {
+	sync.Mutex
+	templates map[string]string
+	expiry    time.Time
+}
+
+// LoadDefaultTemplates returns the default notification templates, or none when they can not be loaded
+// so the notifications are still sent.
+func LoadDefaultTemplates() map[string]string {
+	defaultTemplatesCache.Lock()
+	defer defaultTemplatesCache.Unlock()
+	now := time.Now()
+	if now.Before(defaultTemplatesCache.expiry) {
+		return defaultTemplatesCache.templates
+	}
+
+	templates, err := store.LoadTemplates()
+	if err != nil {
+		config.Mattermost.LogError("Unable to get the notification templates.", "Error", err.Error())
+		return nil
+	}
+	defaultTemplatesCache.templates, defaultTemplatesCache.expiry = templates, now.Add(defaultTemplatesCacheTTL)
+	return templates
+}
+
+// ResetDefaultTemplates drops the cached default notification templates, for a change to apply to the next event.
+func ResetDefaultTemplates() {
+	defaultTemplatesCache.Lock()
+	defer defaultTemplatesCache.Unlock()
+	defaultTemplatesCache.templates, defaultTemplatesCache.expiry = nil, time.Time{}
+}
+
+// ChannelNotificationPost returns the notification of the event for the channel of the built-in post.
+// The template of the first of the given subscriptions of the channel matching the event, by name, with a template
+// for its type is used, otherwise the default template of the event type.
+// Without a template, or when it fails to render, the built-in post is kept.
+func ChannelNotificationPost(post *model.Post, event serializer.NotificationEvent, data serializer.TemplateData, defaults map[string]string, subscriptions []serializer.Subscription) *model.Post {
+	text := defaults[event.EventType]
+	var override serializer.Subscription
+	for _, subscription := range subscriptions {
+		if _, ok := subscription.GetTemplates()[event.EventType]; !ok {
+			continue
+		}
+		if override == nil || subscription.GetAlias() < override.GetAlias() {
+			override = subscription
+		}
+	}
+	if override != nil {
+		text = override.GetTemplates()[event.EventType]
+	}
+	if text == "" {
+		return post
+	}
+
+	templatePost, err := serializer.TemplatePost(post, text, data)
+	if err != nil {
+		config.Mattermost.LogWarn("Unable to render the notification template, the built-in notification is sent.", "ChannelID", post.ChannelId, "EventType", event.EventType, "Error", err.Error())
+		return post
+	}
+	return templatePost
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+)
+
+func TestChannelNotificationPost(t *testing.T) {
+	defer monkey.UnpatchAll()
+	mockAPI := baseMock()
+	mockAPI.On("LogWarn", mock.AnythingOfType("string"), mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	docs := serializer.SpaceSubscription{
+		SpaceKey: "DOCS",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "docs",
+			BaseURL:   "https://test.com",
+			ChannelID: "testtesttesttest",
+			Events:    []string{serializer.PageUpdatedEvent},
+			Templates: map[string]string{serializer.PageUpdatedEvent: "{{.Title}} changed in {{.SpaceKey}}"},
+		},
+	}
+	broken := serializer.PageSubscription{
+		PageID: "1234",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "broken",
+			BaseURL:   "https://test.com",
+			ChannelID: "testtesttest1234",
+			Events:    []string{serializer.PageUpdatedEvent},
+			Templates: map[string]string{serializer.PageUpdatedEvent: "{{.Missing}}"},
+		},
+	}
+	plain := serializer.PageSubscription{
+		PageID: "1234",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "plain",
+			BaseURL:   "https://test.com",
+			ChannelID: "testtesttest5678",
+			Events:    []string{serializer.PageUpdatedEvent},
+		},
+	}
+	event := serializer.NotificationEvent{
+		BaseURL:   "https://test.com",
+		SpaceKey:  "DOCS",
+		PageID:    "1234",
+		EventType: serializer.PageUpdatedEvent,
+	}
+	data := serializer.TemplateData{Event: serializer.PageUpdatedEvent, Author: "jsmith", Title: "Release Notes", SpaceKey: "DOCS"}
+	defaults := map[string]string{serializer.PageUpdatedEvent: "{{.Author}} updated {{.Title}}"}
+
+	for name, val := range map[string]struct {
+		channelID     string
+		defaults      map[string]string
+		subscriptions []serializer.Subscription
+		expected      string
+	}{
+		"subscription template": {
+			channelID:     "testtesttesttest",
+			defaults:      defaults,
+			subscriptions: []serializer.Subscription{plain, docs},
+			expected:      "Release Notes changed in DOCS",
+		},
+		"first subscription template by name": {
+			channelID:     "testtesttesttest",
+			defaults:      defaults,
+			subscriptions: []serializer.Subscription{docs, broken},
+			expected:      "built-in",
+		},
+		"default template": {
+			channelID:     "testtesttest5678",
+			defaults:      defaults,
+			subscriptions: []serializer.Subscription{plain},
+			expected:      "jsmith updated Release Notes",
+		},
+		"invalid template falls back to the built-in notification": {
+			channelID:     "testtesttest1234",
+			defaults:      defaults,
+			subscriptions: []serializer.Subscription{broken},
+			expected:      "built-in",
+		},
+		"no template": {
+			channelID:     "testtesttest5678",
+			subscriptions: []serializer.Subscription{plain},
+			expected:      "built-in",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			post := &model.Post{UserId: "bot", ChannelId: val.channelID, Message: "built-in"}
+			channelPost := ChannelNotificationPost(post, event, data, val.defaults, val.subscriptions)
+			assert.Equal(t, val.expected, channelPost.Message)
+			assert.Equal(t, val.channelID, channelPost.ChannelId)
+			assert.Equal(t, "bot", channelPost.UserId)
+		})
+	}
+}
+
+func TestLoadDefaultTemplates(t *testing.T) {
+	defer monkey.UnpatchAll()
+	defer ResetDefaultTemplates()
+	loads := 0
+	monkey.Patch(store.LoadTemplates, func() (map[string]string, error) {
+		loads++
+		return map[string]string{serializer.PageUpdatedEvent: "{{.Title}}"}, nil
+	})
+
+	ResetDefaultTemplates()
+	assert.Equal(t, "{{.Title}}", LoadDefaultTemplates()[serializer.PageUpdatedEvent])
+	assert.Equal(t, "{{.Title}}", LoadDefaultTemplates()[serializer.PageUpdatedEvent])
+	assert.Equal(t, 1, loads, "the templates are reused between events")
+
+	ResetDefaultTemplates()
+	LoadDefaultTemplates()
+	assert.Equal(t, 2, loads)
+}
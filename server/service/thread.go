@@ -0,0 +1,73 @@
+package service
+
+import (
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+)
+
+// CreateNotificationPost creates the notification of the event in the channel of the post.
+// When notifications are grouped in threads, it replies to the thread of the comment the event replies to,
+// or else of the page or blog post of the event, and starts the thread of the page when there is none.
+// Mattermost threads have a single level, so replies to a comment go to the thread its notification is in.
+// It returns the created post.
+func CreateNotificationPost(post *model.Post, event serializer.NotificationEvent) (*model.Post, error) {
+	pluginConfig := config.GetConfig()
+	if !pluginConfig.ThreadNotifications || event.PageID == "" {
+		created, appErr := config.Mattermost.CreatePost(post)
+		if appErr != nil {
+			return nil, appErr
+		}
+		return created, nil
+	}
+
+	// The post is shared by the channels notified of the event, so the thread is only set on a copy.
+	post = post.Clone()
+	post.RootId = loadNotificationThread(post.ChannelId, event)
+	created, appErr := config.Mattermost.CreatePost(post)
+	if appErr != nil && post.RootId != "" {
+		// The root post may have been deleted, so the notification starts a new thread.
+		post.RootId = ""
+		created, appErr = config.Mattermost.CreatePost(post)
+	}
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	rootID := created.RootId
+	if rootID == "" {
+		rootID = created.Id
+	}
+	// The threads are stored again on each notification, so they expire after the last one.
+	expiry := pluginConfig.GetNotificationThreadExpiry()
+	for _, contentID := range []string{event.PageID, event.CommentID} {
+		if contentID == "" {
+			continue
+		}
+		if err := store.StoreNotificationThread(post.ChannelId, event.BaseURL, contentID, rootID, expiry); err != nil {
+			config.Mattermost.LogError("Unable to store the notification thread.", "ChannelID", post.ChannelId, "ContentID", contentID, "Error", err.Error())
+		}
+	}
+	return created, nil
+}
+
+// loadNotificationThread returns the root post of the thread the notification of the event replies to in the channel,
+// or an empty string when it starts a new thread.
+func loadNotificationThread(channelID string, event serializer.NotificationEvent) string {
+	for _, contentID := range []string{event.ParentCommentID, event.PageID} {
+		if contentID == "" {
+			continue
+		}
+		rootID, err := store.LoadNotificationThread(channelID, event.BaseURL, contentID)
+		if err != nil {
+			config.Mattermost.LogError("Unable to get the notification thread.", "ChannelID", channelID, "ContentID", contentID, "Error", err.Error())
+			continue
+		}
+		if rootID != "" {
+			return rootID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+)
+
+func TestCreateNotificationPost(t *testing.T) {
+	for name, val := range map[string]struct {
+		threads        map[string]string
+		event          serializer.NotificationEvent
+		deletedRoot    bool
+		expectedRootID string
+		expectedStored map[string]string
+	}{
+		"first notification starts the thread": {
+			threads:        map[string]string{},
+			event:          serializer.NotificationEvent{PageID: "1234", EventType: serializer.PageCreatedEvent},
+			expectedStored: map[string]string{"1234": "newpost"},
+		},
+		"later notification replies to the thread of the page": {
+			threads:        map[string]string{"1234": "rootpost"},
+			event:          serializer.NotificationEvent{PageID: "1234", CommentID: "5678", EventType: serializer.CommentCreatedEvent},
+			expectedRootID: "rootpost",
+			expectedStored: map[string]string{"1234": "rootpost", "5678": "rootpost"},
+		},
+		"comment reply goes to the thread of the comment": {
+			threads:        map[string]string{"5678": "commentpost"},
+			event:          serializer.NotificationEvent{PageID: "1234", CommentID: "9012", ParentCommentID: "5678", EventType: serializer.CommentCreatedEvent},
+			expectedRootID: "commentpost",
+			expectedStored: map[string]string{"1234": "commentpost", "9012": "commentpost"},
+		},
+		"deleted root post starts a new thread": {
+			threads:        map[string]string{"1234": "rootpost"},
+			event:          serializer.NotificationEvent{PageID: "1234", EventType: serializer.PageUpdatedEvent},
+			deletedRoot:    true,
+			expectedStored: map[string]string{"1234": "newpost"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer monkey.UnpatchAll()
+			config.SetConfig(&config.Configuration{ThreadNotifications: true})
+			mockAPI := baseMock()
+			mockAPI.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool { return post.RootId == "" })).Return(&model.Post{Id: "newpost"}, nil)
+			if val.deletedRoot {
+				mockAPI.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool { return post.RootId != "" })).Return(nil, &model.AppError{StatusCode: http.StatusBadRequest})
+			} else {
+				mockAPI.On("CreatePost", mock.MatchedBy(func(post *model.Post) bool { return post.RootId != "" })).Return(&model.Post{Id: "newpost", RootId: val.expectedRootID}, nil)
+			}
+
+			monkey.Patch(store.LoadNotificationThread, func(channelID, url, contentID string) (string, error) {
+				return val.threads[contentID], nil
+			})
+			stored := map[string]string{}
+			monkey.Patch(store.StoreNotificationThread, func(channelID, url, contentID, rootID string, expiry time.Duration) error {
+				assert.Equal(t, 24*time.Hour, expiry)
+				stored[contentID] = rootID
+				return nil
+			})
+
+			post := &model.Post{ChannelId: "testtesttesttest", Message: "notification"}
+			created, err := CreateNotificationPost(post, val.event)
+			assert.NoError(t, err)
+			assert.Equal(t, "newpost", created.Id)
+			assert.Empty(t, post.RootId, "the shared post is left unchanged")
+			assert.Equal(t, val.expectedStored, stored)
+			if val.expectedRootID != "" {
+				mockAPI.AssertCalled(t, "CreatePost", mock.MatchedBy(func(post *model.Post) bool { return post.RootId == val.expectedRootID }))
+			}
+		})
+	}
+}
+
+func TestCreateNotificationPostWithoutThreads(t *testing.T) {
+	defer monkey.UnpatchAll()
+	config.SetConfig(&config.Configuration{})
+	mockAPI := baseMock()
+	mockAPI.On("CreatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{Id: "newpost"}, nil)
+	monkey.Patch(store.LoadNotificationThread, func(channelID, url, contentID string) (string, error) {
+		t.Fatal("threads are not used when disabled")
+		return "", nil
+	})
+
+	_, err := CreateNotificationPost(&model.Post{ChannelId: "testtesttesttest"}, serializer.NotificationEvent{PageID: "1234"})
+	assert.NoError(t, err)
+	mockAPI.AssertNumberOfCalls(t, "CreatePost", 1)
+}
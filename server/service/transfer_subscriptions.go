@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+)
+
+const (
+	generalTransferError = "an error occurred while transferring the subscriptions"
+	sameTransferChannel  = "the subscriptions are already in this channel"
+)
+
+// SubscriptionTransferResult is the outcome of copying or moving one subscription, Error is empty when it was transferred.
+type SubscriptionTransferResult struct {
+	Alias string
+	Error string
+}
+
+// TransferSubscriptions copies the subscriptions of the given names, or every subscription when aliases is empty,
+// from the source channel to the target channel, and removes them from the source channel when move is set.
+// Each subscription is checked with ValidateSubscription against the target channel, the conflicting ones are
+// reported and left in place. The others are removed and added in a single modification of every record they
+// are stored in, so the channel and the indexes never disagree about where a subscription is, and the records
+// already written are restored when one fails, so a failed move leaves every subscription in its channel.
+// Copies are recorded as created by the user, moved subscriptions keep their creator and pause state.
+func TransferSubscriptions(sourceChannelID, targetChannelID string, aliases []string, move bool, userID string) ([]SubscriptionTransferResult, error) {
+	if sourceChannelID == targetChannelID {
+		return nil, errors.New(sameTransferChannel)
+	}
+
+	channelSubscriptions, err := GetSubscriptionsByChannelID(sourceChannelID)
+	if err != nil {
+		return nil, errors.New(generalTransferError)
+	}
+
+	var sources []serializer.Subscription
+	if len(aliases) == 0 {
+		for _, subscription := range channelSubscriptions {
+			sources = append(sources, subscription)
+		}
+		sort.Slice(sources, func(i, j int) bool { return sources[i].GetAlias() < sources[j].GetAlias() })
+	} else {
+		for _, alias := range aliases {
+			subscription, ok := channelSubscriptions.GetInsensitiveCase(alias)
+			if !ok {
+				return nil, fmt.Errorf(subscriptionNotFound, alias)
+			}
+			sources = append(sources, subscription)
+		}
+	}
+
+	var targets []serializer.Subscription
+	for _, source := range sources {
+		target := source.WithChannelID(targetChannelID)
+		if move {
+			target = target.WithAuditInfo(source.GetAuditInfo().Updated(userID))
+		} else {
+			pauseState := source.GetPauseState()
+			pauseState.SuppressedEvents = 0
+			target = target.WithAuditInfo(serializer.NewAuditInfo(userID)).WithPauseState(pauseState)
+		}
+		targets = append(targets, target)
+	}
+
+	existing, err := loadSubscriptionRecords(subscriptionRecords(targets...)...)
+	if err != nil {
+		return nil, errors.New(generalTransferError)
+	}
+
+	results := make([]SubscriptionTransferResult, 0, len(sources))
+	var transferredSources, transferredTargets []serializer.Subscription
+	for i, target := range targets {
+		result := SubscriptionTransferResult{Alias: target.GetAlias()}
+		// The accepted subscriptions are added to the loaded records, so two of them can not conflict with each other either.
+		if vErr := target.ValidateSubscription(&existing); vErr != nil {
+			result.Error = vErr.Error()
+		} else {
+			target.Add(&existing)
+			transferredSources = append(transferredSources, sources[i])
+			transferredTargets = append(transferredTargets, target)
+		}
+		results = append(results, result)
+	}
+	if len(transferredTargets) == 0 {
+		return results, nil
+	}
+
+	modify := func(subscriptions *serializer.Subscriptions) {
+		if move {
+			for _, source := range transferredSources {
+				source.Remove(subscriptions)
+			}
+		}
+		for _, target := range transferredTargets {
+			target.Add(subscriptions)
+		}
+	}
+	if err = modifySubscriptionRecords(modify, append(transferredSources, transferredTargets...)...); err != nil {
+		config.Mattermost.LogError("Unable to transfer the subscriptions.", "SourceChannelID", sourceChannelID, "TargetChannelID", targetChannelID, "Error", err.Error())
+		return nil, errors.New(generalTransferError)
+	}
+	return results, nil
+}
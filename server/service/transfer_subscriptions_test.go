@@ -0,0 +1,168 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+)
+
+func TestTransferSubscriptions(t *testing.T) {
+	spaceSubscription := serializer.SpaceSubscription{
+		SpaceKey: "TS",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "space",
+			BaseURL:   "https://test.com",
+			ChannelID: "sourcechannel",
+			Events:    []string{serializer.PageCreatedEvent},
+			Type:      serializer.SubscriptionTypeSpace,
+			AuditInfo: serializer.AuditInfo{CreatedBy: "creator"},
+		},
+	}
+	pageSubscription := serializer.PageSubscription{
+		PageID: "1234",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "page",
+			BaseURL:   "https://test.com",
+			ChannelID: "sourcechannel",
+			Events:    []string{serializer.PageUpdatedEvent},
+			Type:      serializer.SubscriptionTypePage,
+		},
+	}
+	conflictingSubscription := serializer.PageSubscription{
+		PageID: "5678",
+		BaseSubscription: serializer.BaseSubscription{
+			Alias:     "page",
+			BaseURL:   "https://test.com",
+			ChannelID: "targetchannel",
+			Events:    []string{serializer.PageUpdatedEvent},
+			Type:      serializer.SubscriptionTypePage,
+		},
+	}
+
+	for name, val := range map[string]struct {
+		aliases          []string
+		move             bool
+		expectedResults  []SubscriptionTransferResult
+		expectedSource   []string
+		expectedTarget   []string
+		expectedSpaceIDs []string
+		failSource       bool
+		expectError      bool
+	}{
+		"copy one subscription": {
+			aliases:          []string{"SPACE"},
+			expectedResults:  []SubscriptionTransferResult{{Alias: "space"}},
+			expectedSource:   []string{"page", "space"},
+			expectedTarget:   []string{"page", "space"},
+			expectedSpaceIDs: []string{"sourcechannel", "targetchannel"},
+		},
+		"move every subscription": {
+			move: true,
+			expectedResults: []SubscriptionTransferResult{
+				{Alias: "page", Error: aliasAlreadyExist},
+				{Alias: "space"},
+			},
+			expectedSource:   []string{"page"},
+			expectedTarget:   []string{"page", "space"},
+			expectedSpaceIDs: []string{"targetchannel"},
+		},
+		"failed move keeps the subscription in its channel": {
+			aliases:          []string{"space"},
+			move:             true,
+			failSource:       true,
+			expectError:      true,
+			expectedSource:   []string{"page", "space"},
+			expectedTarget:   []string{"page"},
+			expectedSpaceIDs: []string{"sourcechannel"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer monkey.UnpatchAll()
+			kv := map[string][]byte{}
+			mockAPI := baseMock()
+			mockAPI.On("LogError", mock.AnythingOfType("string"), mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+			failSource := false
+			monkey.Patch(store.AtomicModify, func(key string, modify func(initialValue []byte) ([]byte, error)) error {
+				if failSource && key == store.GetChannelSubscriptionsKey("sourcechannel") {
+					return errors.New("failed to write the record")
+				}
+				modified, err := modify(kv[key])
+				if err != nil {
+					return err
+				}
+				if modified == nil {
+					delete(kv, key)
+					return nil
+				}
+				kv[key] = modified
+				return nil
+			})
+			monkey.Patch(loadSubscriptionRecords, func(records ...subscriptionRecord) (serializer.Subscriptions, error) {
+				subscriptions := serializer.NewSubscriptions()
+				for _, record := range records {
+					assert.NoError(t, record.unmarshal(kv[record.key()], subscriptions))
+				}
+				return *subscriptions, nil
+			})
+			channelSubscriptions := func(channelID string) serializer.StringSubscription {
+				subscriptions, _ := loadSubscriptionRecords(subscriptionRecord{kind: channelRecord, id: channelID})
+				return subscriptions.ByChannelID[channelID]
+			}
+			monkey.Patch(GetSubscriptionsByChannelID, func(channelID string) (serializer.StringSubscription, error) {
+				return channelSubscriptions(channelID), nil
+			})
+
+			for _, subscription := range []serializer.Subscription{spaceSubscription, pageSubscription, conflictingSubscription} {
+				assert.NoError(t, modifySubscriptionRecords(subscription.Add, subscription))
+			}
+
+			failSource = val.failSource
+			results, err := TransferSubscriptions("sourcechannel", "targetchannel", val.aliases, val.move, "user")
+			failSource = false
+			if val.expectError {
+				assert.EqualError(t, err, generalTransferError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, val.expectedResults, results)
+			}
+
+			var source, target []string
+			for alias := range channelSubscriptions("sourcechannel") {
+				source = append(source, alias)
+			}
+			for alias := range channelSubscriptions("targetchannel") {
+				target = append(target, alias)
+			}
+			assert.ElementsMatch(t, val.expectedSource, source)
+			assert.ElementsMatch(t, val.expectedTarget, target)
+
+			spaceRecord := subscriptionRecord{kind: urlSpaceKeyRecord, id: store.GetURLSpaceKeyCombinationKey("https://test.com", "TS")}
+			index, _ := loadSubscriptionRecords(spaceRecord)
+			var spaceChannelIDs []string
+			for channelID := range index.ByURLSpaceKey[spaceRecord.id] {
+				spaceChannelIDs = append(spaceChannelIDs, channelID)
+			}
+			assert.ElementsMatch(t, val.expectedSpaceIDs, spaceChannelIDs)
+
+			if val.expectError {
+				return
+			}
+			transferred := channelSubscriptions("targetchannel")["space"]
+			if val.move {
+				assert.Equal(t, "creator", transferred.GetAuditInfo().CreatedBy)
+			} else {
+				assert.Equal(t, "user", transferred.GetAuditInfo().CreatedBy)
+			}
+			assert.Equal(t, "user", transferred.GetAuditInfo().UpdatedBy)
+		})
+	}
+
+	_, err := TransferSubscriptions("sourcechannel", "sourcechannel", nil, false, "user")
+	assert.EqualError(t, err, sameTransferChannel)
+}
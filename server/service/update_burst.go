@@ -0,0 +1,101 @@
+package service
+
+import (
+	"slices"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+// createPageUpdatePost notifies the channel of the post of an update of a page.
+// Within the debounce window of the first update notification of the page, it edits that notification
+// with the count and authors of the updates instead of creating a new post.
+// The window starts with the first update, so a page edited for hours still gets a new notification once in a while.
+// The burst is stored with an atomic modification before its notification is posted, so of concurrent first updates
+// only the one that started the burst posts it, and the others are counted in it.
+func createPageUpdatePost(post *model.Post, event serializer.NotificationEvent, data serializer.TemplateData, window time.Duration) error {
+	now := time.Now()
+	staleBurstPostID := ""
+	// The second attempt starts a new burst when the notification of the burst could not be edited.
+	for attempt := 0; attempt < 2; attempt++ {
+		burst, started, err := countPageUpdate(post.ChannelId, event, data.Author, now, window, staleBurstPostID)
+		if err != nil {
+			config.Mattermost.LogError("Unable to count the update in the update burst.", "ChannelID", post.ChannelId, "PageID", event.PageID, "Error", err.Error())
+			break
+		}
+		if started {
+			return postUpdateBurst(post, event, data, burst, window)
+		}
+		if burst.PostID == "" {
+			// The update that started the burst is posting its notification, which shows the count once posted.
+			return nil
+		}
+		if _, appErr := config.Mattermost.UpdatePost(serializer.UpdateBurstPost(post, data, burst)); appErr == nil {
+			return nil
+		}
+		// The notification may have been deleted, so the update starts a new burst.
+		staleBurstPostID = burst.PostID
+	}
+
+	_, err := CreateNotificationPost(post, event)
+	return err
+}
+
+// countPageUpdate counts the update of the page in its update burst, and returns the burst and whether the update started it.
+// A burst that is over, or whose notification is the stale post, is replaced by a new one without a post yet.
+func countPageUpdate(channelID string, event serializer.NotificationEvent, author string, now time.Time, window time.Duration, stalePostID string) (*types.UpdateBurst, bool, error) {
+	var burst *types.UpdateBurst
+	started := false
+	err := store.ModifyUpdateBurst(channelID, event.BaseURL, event.PageID, window, func(current *types.UpdateBurst) *types.UpdateBurst {
+		started = current == nil || time.UnixMilli(current.StartedAt).Add(window).Sub(now) < time.Second ||
+			(stalePostID != "" && current.PostID == stalePostID)
+		if started {
+			current = &types.UpdateBurst{StartedAt: now.UnixMilli()}
+		}
+		current.Count++
+		if author != "" && !slices.Contains(current.Authors, author) {
+			current.Authors = append(current.Authors, author)
+		}
+		burst = current
+		return current
+	})
+	return burst, started, err
+}
+
+// postUpdateBurst posts the notification of the burst started by the update, and stores its post in the burst.
+// The updates counted while it was posted are shown by editing it.
+func postUpdateBurst(post *model.Post, event serializer.NotificationEvent, data serializer.TemplateData, burst *types.UpdateBurst, window time.Duration) error {
+	created, err := CreateNotificationPost(post, event)
+	var stored *types.UpdateBurst
+	if sErr := store.ModifyUpdateBurst(post.ChannelId, event.BaseURL, event.PageID, window, func(current *types.UpdateBurst) *types.UpdateBurst {
+		stored = nil
+		if current == nil || current.PostID != "" || current.StartedAt != burst.StartedAt {
+			return nil
+		}
+		if err != nil {
+			// The burst is ended, so the next update is posted rather than counted in a burst without a notification.
+			current.StartedAt = 0
+		} else {
+			current.PostID = created.Id
+		}
+		stored = current
+		return current
+	}); sErr != nil {
+		config.Mattermost.LogError("Unable to store the update burst.", "ChannelID", post.ChannelId, "PageID", event.PageID, "Error", sErr.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	if stored != nil && stored.Count > 1 {
+		if _, appErr := config.Mattermost.UpdatePost(serializer.UpdateBurstPost(post, data, stored)); appErr != nil {
+			config.Mattermost.LogWarn("Unable to update the notification of the update burst.", "PostID", created.Id, "Error", appErr.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+func TestNotifyChannelPageUpdates(t *testing.T) {
+	now := time.Now()
+	for name, val := range map[string]struct {
+		burst           *types.UpdateBurst
+		deletedPost     bool
+		expectedCreated bool
+		expectedMessage string
+		expectedStored  *types.UpdateBurst
+	}{
+		"first update is posted": {
+			expectedCreated: true,
+			expectedStored:  &types.UpdateBurst{PostID: "newpost", Count: 1, Authors: []string{"Bob"}},
+		},
+		"later update edits the notification": {
+			burst:           &types.UpdateBurst{PostID: "burstpost", Count: 4, Authors: []string{"Alice"}, StartedAt: now.Add(-time.Minute).UnixMilli()},
+			expectedMessage: "[Release Notes](https://test/1234) was updated 5 times by Alice, Bob.\nBob updated Release Notes",
+			expectedStored:  &types.UpdateBurst{PostID: "burstpost", Count: 5, Authors: []string{"Alice", "Bob"}},
+		},
+		"update after the window is posted": {
+			burst:           &types.UpdateBurst{PostID: "burstpost", Count: 4, Authors: []string{"Alice"}, StartedAt: now.Add(-time.Hour).UnixMilli()},
+			expectedCreated: true,
+			expectedStored:  &types.UpdateBurst{PostID: "newpost", Count: 1, Authors: []string{"Bob"}},
+		},
+		"update of a deleted notification is posted": {
+			burst:           &types.UpdateBurst{PostID: "burstpost", Count: 2, Authors: []string{"Bob"}, StartedAt: now.Add(-time.Minute).UnixMilli()},
+			deletedPost:     true,
+			expectedCreated: true,
+			expectedStored:  &types.UpdateBurst{PostID: "newpost", Count: 1, Authors: []string{"Bob"}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer monkey.UnpatchAll()
+			config.SetConfig(&config.Configuration{UpdateDebounceMinutes: 10})
+			mockAPI := baseMock()
+			mockAPI.On("CreatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{Id: "newpost"}, nil)
+			if val.deletedPost {
+				mockAPI.On("UpdatePost", mock.AnythingOfType("*model.Post")).Return(nil, &model.AppError{StatusCode: http.StatusNotFound})
+			} else {
+				mockAPI.On("UpdatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{Id: "burstpost"}, nil)
+			}
+
+			monkey.Patch(store.LoadChannelDigest, func(channelID string) (*types.Digest, error) {
+				return nil, nil
+			})
+			stored := val.burst
+			monkey.Patch(store.ModifyUpdateBurst, func(channelID, url, pageID string, expiry time.Duration, modify func(*types.UpdateBurst) *types.UpdateBurst) error {
+				assert.Equal(t, 10*time.Minute, expiry)
+				var current *types.UpdateBurst
+				if stored != nil {
+					copied := *stored
+					copied.Authors = slices.Clone(stored.Authors)
+					current = &copied
+				}
+				if modified := modify(current); modified != nil {
+					stored = modified
+				}
+				return nil
+			})
+
+			post := &model.Post{ChannelId: "testtesttesttest", Message: "Bob updated Release Notes"}
+			event := serializer.NotificationEvent{SpaceKey: "DOC", PageID: "1234", EventType: serializer.PageUpdatedEvent}
+			data := serializer.TemplateData{Author: "Bob", Title: "Release Notes", URL: "https://test/1234"}
+			assert.NoError(t, NotifyChannel(post, event, data))
+
+			if val.expectedCreated {
+				mockAPI.AssertNumberOfCalls(t, "CreatePost", 1)
+			} else {
+				mockAPI.AssertNotCalled(t, "CreatePost", mock.Anything)
+				mockAPI.AssertCalled(t, "UpdatePost", mock.MatchedBy(func(updated *model.Post) bool {
+					return updated.Id == "burstpost" && updated.Message == val.expectedMessage
+				}))
+			}
+			stored.StartedAt = 0
+			assert.Equal(t, val.expectedStored, stored)
+		})
+	}
+}
+
+func TestNotifyChannelConcurrentFirstPageUpdates(t *testing.T) {
+	defer monkey.UnpatchAll()
+	config.SetConfig(&config.Configuration{UpdateDebounceMinutes: 10})
+	mockAPI := baseMock()
+
+	// The first update is held while it posts, so the second one finds the burst it started.
+	posting := make(chan struct{})
+	release := make(chan struct{})
+	mockAPI.On("CreatePost", mock.AnythingOfType("*model.Post")).Run(func(mock.Arguments) {
+		close(posting)
+		<-release
+	}).Return(&model.Post{Id: "newpost"}, nil)
+	mockAPI.On("UpdatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{Id: "newpost"}, nil)
+
+	monkey.Patch(store.LoadChannelDigest, func(channelID string) (*types.Digest, error) {
+		return nil, nil
+	})
+	var lock sync.Mutex
+	var stored *types.UpdateBurst
+	monkey.Patch(store.ModifyUpdateBurst, func(channelID, url, pageID string, expiry time.Duration, modify func(*types.UpdateBurst) *types.UpdateBurst) error {
+		lock.Lock()
+		defer lock.Unlock()
+		var current *types.UpdateBurst
+		if stored != nil {
+			copied := *stored
+			copied.Authors = slices.Clone(stored.Authors)
+			current = &copied
+		}
+		if modified := modify(current); modified != nil {
+			stored = modified
+		}
+		return nil
+	})
+
+	event := serializer.NotificationEvent{SpaceKey: "DOC", PageID: "1234", EventType: serializer.PageUpdatedEvent}
+	first := make(chan error)
+	go func() {
+		post := &model.Post{ChannelId: "testtesttesttest", Message: "Alice updated Release Notes"}
+		first <- NotifyChannel(post, event, serializer.TemplateData{Author: "Alice", Title: "Release Notes", URL: "https://test/1234"})
+	}()
+	<-posting
+	post := &model.Post{ChannelId: "testtesttesttest", Message: "Bob updated Release Notes"}
+	assert.NoError(t, NotifyChannel(post, event, serializer.TemplateData{Author: "Bob", Title: "Release Notes", URL: "https://test/1234"}))
+	close(release)
+	assert.NoError(t, <-first)
+
+	mockAPI.AssertNumberOfCalls(t, "CreatePost", 1)
+	mockAPI.AssertCalled(t, "UpdatePost", mock.MatchedBy(func(updated *model.Post) bool {
+		return updated.Id == "newpost" && updated.Message == "[Release Notes](https://test/1234) was updated 2 times by Alice, Bob.\nAlice updated Release Notes"
+	}))
+	stored.StartedAt = 0
+	assert.Equal(t, &types.UpdateBurst{PostID: "newpost", Count: 2, Authors: []string{"Alice", "Bob"}}, stored)
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+const (
+	prefixChannelDigest = "confluence_digest"
+	prefixDigestQueue   = "confluence_digest_queue"
+	// keyPendingDigests indexes the channels with notifications waiting for their digest,
+	// with when the first of them was received, so the digests due are found without reading every queue.
+	keyPendingDigests = "confluence_digest_pending"
+)
+
+// LoadChannelDigest returns the digest the channel receives its notifications in, or nil if they are posted right away.
+func LoadChannelDigest(channelID string) (*types.Digest, error) {
+	digest := &types.Digest{}
+	if err := get(hashkey(prefixChannelDigest, channelID), digest); err != nil {
+		if err == ErrNotFound {
+			return nil, nil
+		}
+		return nil, errors.WithMessage(err, "failed to load the channel digest")
+	}
+	return digest, nil
+}
+
+// StoreChannelDigest sets the digest of the channel, a nil digest posts its notifications right away again.
+func StoreChannelDigest(channelID string, digest *types.Digest) error {
+	key := hashkey(prefixChannelDigest, channelID)
+	if digest == nil {
+		if appErr := config.Mattermost.KVDelete(key); appErr != nil {
+			return errors.WithMessage(appErr, "failed to remove the channel digest")
+		}
+		return nil
+	}
+	return set(key, digest)
+}
+
+// QueueDigestEvent adds the event to the digest queue of the channel. Once the queue holds maxEvents events,
+// further events are only counted.
+func QueueDigestEvent(channelID string, event types.DigestEvent, maxEvents int) error {
+	err := AtomicModify(hashkey(prefixDigestQueue, channelID), func(initialBytes []byte) ([]byte, error) {
+		queue, err := digestQueueFromJSON(initialBytes)
+		if err != nil {
+			return nil, err
+		}
+		if len(queue.Events) >= maxEvents {
+			queue.Dropped++
+		} else {
+			queue.Events = append(queue.Events, event)
+		}
+		return json.Marshal(queue)
+	})
+	if err != nil {
+		return err
+	}
+
+	return modifyPendingDigests(func(pending map[string]int64) {
+		if _, ok := pending[channelID]; !ok {
+			pending[channelID] = event.CreatedAt
+		}
+	})
+}
+
+// LoadPendingDigests returns the channels with notifications waiting for their digest,
+// with when the first of them was received in milliseconds since the epoch.
+func LoadPendingDigests() (map[string]int64, error) {
+	data, appErr := config.Mattermost.KVGet(keyPendingDigests)
+	if appErr != nil {
+		return nil, errors.WithMessage(appErr, "failed to load the pending digests")
+	}
+	return pendingDigestsFromJSON(data)
+}
+
+// LoadDigestQueue returns the digest queue of the channel, empty if no notification is waiting.
+func LoadDigestQueue(channelID string) (*types.DigestQueue, error) {
+	data, appErr := config.Mattermost.KVGet(hashkey(prefixDigestQueue, channelID))
+	if appErr != nil {
+		return nil, errors.WithMessage(appErr, "failed to load the digest queue")
+	}
+	return digestQueueFromJSON(data)
+}
+
+// RemoveDigestEvents removes the delivered events, loaded with LoadDigestQueue, from the digest queue of the channel.
+// Events are only appended to the queue, so the ones queued since it was loaded are kept.
+// The channel is removed from the pending digests first, and added again when events are left,
+// so an event queued meanwhile is never left out of them.
+func RemoveDigestEvents(channelID string, delivered *types.DigestQueue) error {
+	if err := modifyPendingDigests(func(pending map[string]int64) {
+		delete(pending, channelID)
+	}); err != nil {
+		return err
+	}
+
+	var remaining *types.DigestQueue
+	err := AtomicModify(hashkey(prefixDigestQueue, channelID), func(initialBytes []byte) ([]byte, error) {
+		queue, err := digestQueueFromJSON(initialBytes)
+		if err != nil {
+			return nil, err
+		}
+		if len(delivered.Events) < len(queue.Events) {
+			queue.Events = queue.Events[len(delivered.Events):]
+		} else {
+			queue.Events = nil
+		}
+		queue.Dropped = max(queue.Dropped-delivered.Dropped, 0)
+		remaining = queue
+		if len(queue.Events) == 0 && queue.Dropped == 0 {
+			return nil, nil
+		}
+		return json.Marshal(queue)
+	})
+	if err != nil {
+		return err
+	}
+	if len(remaining.Events) == 0 {
+		return nil
+	}
+
+	return modifyPendingDigests(func(pending map[string]int64) {
+		if _, ok := pending[channelID]; !ok {
+			pending[channelID] = remaining.Events[0].CreatedAt
+		}
+	})
+}
+
+func digestQueueFromJSON(data []byte) (*types.DigestQueue, error) {
+	queue := &types.DigestQueue{}
+	if len(data) == 0 {
+		return queue, nil
+	}
+	if err := json.Unmarshal(data, queue); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the digest queue")
+	}
+	return queue, nil
+}
+
+func pendingDigestsFromJSON(data []byte) (map[string]int64, error) {
+	pending := map[string]int64{}
+	if len(data) == 0 {
+		return pending, nil
+	}
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the pending digests")
+	}
+	return pending, nil
+}
+
+func modifyPendingDigests(modify func(pending map[string]int64)) error {
+	return AtomicModify(keyPendingDigests, func(initialBytes []byte) ([]byte, error) {
+		pending, err := pendingDigestsFromJSON(initialBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		modify(pending)
+
+		if len(pending) == 0 {
+			return nil, nil
+		}
+		return json.Marshal(pending)
+	})
+}
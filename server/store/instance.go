@@ -0,0 +1,275 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+// keyInstances is the key of the registry of the installed Confluence Server instances.
+// The registry is small, so it is kept in a single record keyed by the instance URL.
+const keyInstances = "confluence_instances"
+
+// encryptedSecretPrefix marks the secrets of an instance encrypted with the encryption key,
+// telling them apart from the plain text secrets stored before they were encrypted.
+const encryptedSecretPrefix = "encrypted:"
+
+const kvListPerPage = 100
+
+type instanceRegistry map[string]*types.Instance
+
+// loadInstanceRegistry returns the instances of the registry whose secrets can be decrypted.
+// The other ones are logged and left out, so one unreadable instance, like after the encryption key changed
+// while the plugin was disabled, does not prevent using the others.
+func loadInstanceRegistry() (instanceRegistry, error) {
+	data, appErr := config.Mattermost.KVGet(keyInstances)
+	if appErr != nil {
+		return nil, errors.WithMessage(appErr, "failed to load the Confluence instances")
+	}
+	registry, unreadable, err := instanceRegistryFromJSON(data, config.GetConfig().EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	for instanceURL, uErr := range unreadable {
+		config.Mattermost.LogError("Unable to decrypt the secrets of a Confluence instance, it is skipped until it is installed again.", "URL", instanceURL, "Error", uErr.Error())
+		delete(registry, instanceURL)
+	}
+	return registry, nil
+}
+
+// instanceRegistryFromJSON unmarshals the registry and decrypts the secrets of its instances with the encryption key.
+// The instances whose secrets can not be decrypted are returned with the error, and kept in the registry with their secrets
+// as they are stored, so writing the registry back leaves them unchanged.
+func instanceRegistryFromJSON(data []byte, encryptionKey string) (instanceRegistry, map[string]error, error) {
+	registry := instanceRegistry{}
+	unreadable := map[string]error{}
+	if len(data) == 0 {
+		return registry, unreadable, nil
+	}
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to unmarshal the Confluence instances")
+	}
+	for instanceURL, instance := range registry {
+		opened := *instance
+		if err := openInstanceSecrets(&opened, encryptionKey); err != nil {
+			unreadable[instanceURL] = errors.Wrapf(err, "failed to decrypt the secrets of the Confluence instance %q", instance.URL)
+			continue
+		}
+		registry[instanceURL] = &opened
+	}
+	return registry, unreadable, nil
+}
+
+// instanceRegistryToJSON marshals the registry with the secrets of its instances encrypted with the encryption key.
+// The instances of the registry are left as they are, since callers may still use them.
+func instanceRegistryToJSON(registry instanceRegistry, encryptionKey string) ([]byte, error) {
+	sealed := make(instanceRegistry, len(registry))
+	for instanceURL, instance := range registry {
+		sealedInstance := *instance
+		if err := sealInstanceSecrets(&sealedInstance, encryptionKey); err != nil {
+			return nil, errors.Wrapf(err, "failed to encrypt the secrets of the Confluence instance %q", instance.URL)
+		}
+		sealed[instanceURL] = &sealedInstance
+	}
+	return json.Marshal(sealed)
+}
+
+func sealInstanceSecrets(instance *types.Instance, encryptionKey string) error {
+	for _, secret := range []*string{&instance.OAuthClientSecret, &instance.AdminAPIToken, &instance.WebhookSecret} {
+		sealed, err := sealSecret(*secret, encryptionKey)
+		if err != nil {
+			return err
+		}
+		*secret = sealed
+	}
+	return nil
+}
+
+func openInstanceSecrets(instance *types.Instance, encryptionKey string) error {
+	for _, secret := range []*string{&instance.OAuthClientSecret, &instance.AdminAPIToken, &instance.WebhookSecret} {
+		opened, err := openSecret(*secret, encryptionKey)
+		if err != nil {
+			return err
+		}
+		*secret = opened
+	}
+	return nil
+}
+
+// sealSecret encrypts the secret with the encryption key. Secrets that are still sealed, like the ones of
+// an instance that could not be decrypted, are returned as they are.
+func sealSecret(secret, encryptionKey string) (string, error) {
+	if secret == "" || encryptionKey == "" || strings.HasPrefix(secret, encryptedSecretPrefix) {
+		return secret, nil
+	}
+
+	encrypted, err := util.Encrypt([]byte(secret), []byte(encryptionKey))
+	if err != nil {
+		return "", err
+	}
+	return encryptedSecretPrefix + util.Encode(encrypted), nil
+}
+
+// openSecret decrypts a secret sealed by sealSecret. Secrets stored in plain text are returned as they are,
+// and get encrypted the next time the registry is written.
+func openSecret(stored, encryptionKey string) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, encryptedSecretPrefix)
+	if !ok {
+		return stored, nil
+	}
+
+	decoded, err := util.Decode(encoded)
+	if err != nil {
+		return "", err
+	}
+	secret, err := util.Decrypt(decoded, []byte(encryptionKey))
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// ResealInstances encrypts the secrets of the instances with the new encryption key, after it replaced the previous one.
+// Secrets that can not be decrypted with the previous key are left as they are, as another server of the cluster
+// may already have encrypted them with the new key.
+func ResealInstances(previousKey, encryptionKey string) error {
+	return AtomicModify(keyInstances, func(initialBytes []byte) ([]byte, error) {
+		if initialBytes == nil {
+			return nil, nil
+		}
+		registry, unreadable, err := instanceRegistryFromJSON(initialBytes, previousKey)
+		if err != nil {
+			return nil, err
+		}
+		for instanceURL, uErr := range unreadable {
+			config.Mattermost.LogWarn("Unable to decrypt the secrets of a Confluence instance with the previous encryption key, they are left as they are.", "URL", instanceURL, "Error", uErr.Error())
+		}
+		return instanceRegistryToJSON(registry, encryptionKey)
+	})
+}
+
+// LoadInstances returns the installed Confluence Server instances sorted by URL.
+func LoadInstances() ([]*types.Instance, error) {
+	registry, err := loadInstanceRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]*types.Instance, 0, len(registry))
+	for _, instance := range registry {
+		instances = append(instances, instance)
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].URL < instances[j].URL
+	})
+	return instances, nil
+}
+
+func LoadInstance(instanceURL string) (*types.Instance, error) {
+	registry, err := loadInstanceRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	instance, ok := registry[instanceURL]
+	if !ok {
+		return nil, errors.Wrapf(ErrNotFound, "Confluence instance %q", instanceURL)
+	}
+	return instance, nil
+}
+
+// StoreInstance adds the instance to the registry, or replaces the instance with the same URL.
+func StoreInstance(instance *types.Instance) error {
+	return modifyInstanceRegistry(func(registry instanceRegistry) error {
+		registry[instance.URL] = instance
+		return nil
+	})
+}
+
+// DeleteInstance removes the instance from the registry.
+func DeleteInstance(instanceURL string) error {
+	return modifyInstanceRegistry(func(registry instanceRegistry) error {
+		if _, ok := registry[instanceURL]; !ok {
+			return errors.Wrapf(ErrNotFound, "Confluence instance %q", instanceURL)
+		}
+		delete(registry, instanceURL)
+		return nil
+	})
+}
+
+// MigrateInstance creates the registry with the given instance, unless the registry already exists.
+// An existing registry, even an empty one, means the instance was already migrated and may have been removed since.
+func MigrateInstance(instance *types.Instance) error {
+	return AtomicModify(keyInstances, func(initialBytes []byte) ([]byte, error) {
+		if initialBytes != nil {
+			return initialBytes, nil
+		}
+		return instanceRegistryToJSON(instanceRegistry{instance.URL: instance}, config.GetConfig().EncryptionKey)
+	})
+}
+
+// modifyInstanceRegistry applies modify to the registry. The instances whose secrets can not be decrypted are part of it,
+// so they can be replaced or removed, and are otherwise written back unchanged.
+func modifyInstanceRegistry(modify func(registry instanceRegistry) error) error {
+	encryptionKey := config.GetConfig().EncryptionKey
+	return AtomicModify(keyInstances, func(initialBytes []byte) ([]byte, error) {
+		registry, _, err := instanceRegistryFromJSON(initialBytes, encryptionKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = modify(registry); err != nil {
+			return nil, err
+		}
+
+		return instanceRegistryToJSON(registry, encryptionKey)
+	})
+}
+
+// DeleteInstanceConnections disconnects every user from the instance and returns how many users were connected to it.
+// Connections are keyed by the instance URL, so every key is listed to find them.
+func DeleteInstanceConnections(instanceURL string) (int, error) {
+	var keys []string
+	for page := 0; ; page++ {
+		pageKeys, appErr := config.Mattermost.KVList(page, kvListPerPage)
+		if appErr != nil {
+			return 0, errors.WithMessage(appErr, "failed to list the keys")
+		}
+		// Every key is listed before any is deleted, so deleting them does not shift the pages.
+		keys = append(keys, pageKeys...)
+		if len(pageKeys) < kvListPerPage {
+			break
+		}
+	}
+
+	disconnected := 0
+	userPrefix := hashkey(prefixUser, "")
+	connectionPrefix := keyWithInstanceID(instanceURL, "")
+	for _, key := range keys {
+		if mattermostUserID, ok := strings.CutPrefix(key, userPrefix); ok {
+			user, err := LoadUser(mattermostUserID)
+			if err != nil || !user.IsConnected(instanceURL) {
+				continue
+			}
+			user.RemoveInstance(instanceURL)
+			if err = StoreUser(user); err != nil {
+				return disconnected, err
+			}
+			disconnected++
+			continue
+		}
+
+		if strings.HasPrefix(key, connectionPrefix) {
+			if appErr := config.Mattermost.KVDelete(key); appErr != nil {
+				return disconnected, errors.WithMessage(appErr, "failed to delete the connection "+key)
+			}
+		}
+	}
+	return disconnected, nil
+}
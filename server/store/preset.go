@@ -0,0 +1,113 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+// keyPresets is the key of the registry of subscription presets.
+// The registry is small, so it is kept in a single record keyed by the lower case preset name.
+const keyPresets = "confluence_presets"
+
+type presetRegistry map[string]*types.Preset
+
+func loadPresetRegistry() (presetRegistry, error) {
+	data, appErr := config.Mattermost.KVGet(keyPresets)
+	if appErr != nil {
+		return nil, errors.WithMessage(appErr, "failed to load the subscription presets")
+	}
+	return presetRegistryFromJSON(data)
+}
+
+func presetRegistryFromJSON(data []byte) (presetRegistry, error) {
+	registry := presetRegistry{}
+	if len(data) == 0 {
+		return registry, nil
+	}
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the subscription presets")
+	}
+	return registry, nil
+}
+
+// LoadPresets returns the subscription presets sorted by name.
+func LoadPresets() ([]*types.Preset, error) {
+	registry, err := loadPresetRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	presets := make([]*types.Preset, 0, len(registry))
+	for _, preset := range registry {
+		presets = append(presets, preset)
+	}
+	sort.Slice(presets, func(i, j int) bool {
+		return presets[i].Name < presets[j].Name
+	})
+	return presets, nil
+}
+
+// LoadPreset returns the subscription preset of the name, regardless of its case.
+func LoadPreset(name string) (*types.Preset, error) {
+	registry, err := loadPresetRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	preset, ok := registry[strings.ToLower(name)]
+	if !ok {
+		return nil, errors.Wrapf(ErrNotFound, "subscription preset %q", name)
+	}
+	return preset, nil
+}
+
+// StorePreset adds the preset to the registry, or replaces the preset with the same name when replace is set.
+func StorePreset(preset *types.Preset, replace bool) error {
+	return modifyPresetRegistry(func(registry presetRegistry) error {
+		key := strings.ToLower(preset.Name)
+		if _, ok := registry[key]; ok != replace {
+			if replace {
+				return errors.Wrapf(ErrNotFound, "subscription preset %q", preset.Name)
+			}
+			return errors.Wrapf(ErrAlreadyExists, "subscription preset %q", preset.Name)
+		}
+		registry[key] = preset
+		return nil
+	})
+}
+
+// DeletePreset removes the preset of the name from the registry.
+func DeletePreset(name string) error {
+	return modifyPresetRegistry(func(registry presetRegistry) error {
+		key := strings.ToLower(name)
+		if _, ok := registry[key]; !ok {
+			return errors.Wrapf(ErrNotFound, "subscription preset %q", name)
+		}
+		delete(registry, key)
+		return nil
+	})
+}
+
+func modifyPresetRegistry(modify func(registry presetRegistry) error) error {
+	return AtomicModify(keyPresets, func(initialBytes []byte) ([]byte, error) {
+		registry, err := presetRegistryFromJSON(initialBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = modify(registry); err != nil {
+			return nil, err
+		}
+
+		if len(registry) == 0 {
+			return nil, nil
+		}
+		return json.Marshal(registry)
+	})
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+const prefixChannelQuietHours = "confluence_quiet_hours"
+
+// LoadChannelQuietHours returns the quiet hours of every subscription of the channel, or nil if it has none.
+func LoadChannelQuietHours(channelID string) (*types.QuietHours, error) {
+	quietHours := &types.QuietHours{}
+	if err := get(hashkey(prefixChannelQuietHours, channelID), quietHours); err != nil {
+		if err == ErrNotFound {
+			return nil, nil
+		}
+		return nil, errors.WithMessage(err, "failed to load the channel quiet hours")
+	}
+	return quietHours, nil
+}
+
+// StoreChannelQuietHours sets the quiet hours of the channel, nil quiet hours remove them.
+func StoreChannelQuietHours(channelID string, quietHours *types.QuietHours) error {
+	key := hashkey(prefixChannelQuietHours, channelID)
+	if quietHours == nil {
+		if appErr := config.Mattermost.KVDelete(key); appErr != nil {
+			return errors.WithMessage(appErr, "failed to remove the channel quiet hours")
+		}
+		return nil
+	}
+	return set(key, quietHours)
+}
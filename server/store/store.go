@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	url2 "net/url"
+	"strings"
 	"time"
 
 	"github.com/mattermost/mattermost/server/public/model"
@@ -18,14 +19,27 @@ import (
 const (
 	prefixOneTimeSecret             = "ots_" // + unique key that will be deleted after the first verification
 	ConfluenceSubscriptionKeyPrefix = "confluence_subs"
+	prefixChannelSubscriptions      = "confluence_subs_channel"
+	prefixURLPageIDSubscriptions    = "confluence_subs_page"
+	prefixURLSpaceKeySubscriptions  = "confluence_subs_space"
+	prefixURLPageTreeSubscriptions  = "confluence_subs_tree"
+	prefixURLCQLSubscriptions       = "confluence_subs_cql"
+	prefixURLAllSpacesSubscriptions = "confluence_subs_all"
 	expiryStoreTimeoutSeconds       = 15 * 60
 	keyTokenSecret                  = "token_secret"
 	keyRSAKey                       = "rsa_key"
 	prefixUser                      = "user_"
 	AdminMattermostUserID           = "admin"
+	confluenceCloudDomain           = ".atlassian.net"
+
+	// SubscriptionIndexMigrationKey is set once the subscription indexes are re-keyed from the legacy combination keys.
+	SubscriptionIndexMigrationKey = "confluence_subs_index_migration"
 )
 
-var ErrNotFound = errors.New("not found")
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+)
 
 // lint is suggesting to rename the function names from `storeConnection` to `Connection` so that when the function is accessed from any other package
 // it looks like `store.Connnection, but this reduces the readibility within the function`
@@ -33,21 +47,146 @@ var ErrNotFound = errors.New("not found")
 // revive:disable:exported
 
 func GetURLSpaceKeyCombinationKey(url, spaceKey string) string {
-	u, _ := url2.Parse(url)
-	return fmt.Sprintf("%s/%s/%s", ConfluenceSubscriptionKeyPrefix, u.Hostname(), spaceKey)
+	return fmt.Sprintf("%s/%s/%s", ConfluenceSubscriptionKeyPrefix, getInstanceKey(url), spaceKey)
 }
 
 func GetURLPageIDCombinationKey(url, pageID string) string {
+	return fmt.Sprintf("%s/%s/%s", ConfluenceSubscriptionKeyPrefix, getInstanceKey(url), pageID)
+}
+
+// GetURLCombinationKey returns the combination key of the instance of the URL, for indexes of a whole instance.
+func GetURLCombinationKey(url string) string {
+	return fmt.Sprintf("%s/%s", ConfluenceSubscriptionKeyPrefix, getInstanceKey(url))
+}
+
+// GetLegacyURLCombinationKey returns the combination key used before index keys held the whole instance URL.
+// It only held the hostname, so instances sharing a host collided. It is only used to migrate the indexes.
+func GetLegacyURLCombinationKey(url, id string) string {
 	u, _ := url2.Parse(url)
-	return fmt.Sprintf("%s/%s/%s", ConfluenceSubscriptionKeyPrefix, u.Hostname(), pageID)
+	return fmt.Sprintf("%s/%s/%s", ConfluenceSubscriptionKeyPrefix, u.Hostname(), id)
+}
+
+// MatchInstanceURL returns the URL of the instance the URL belongs to, among the given instance URLs,
+// for URLs saved before index keys held the whole instance URL, when they were only matched by hostname.
+// The instance with the same key is preferred, then the only instance on the same host, or else the one
+// whose context path starts the path of the URL. Confluence Cloud URLs are keyed by their host, so they match themselves.
+func MatchInstanceURL(url string, instanceURLs []string) (string, bool) {
+	key := getInstanceKey(url)
+	u, err := url2.Parse(key)
+	if err != nil || u.Hostname() == "" {
+		return "", false
+	}
+	if strings.HasSuffix(u.Hostname(), confluenceCloudDomain) {
+		return url, true
+	}
+
+	var sameHost []*url2.URL
+	var sameHostURLs []string
+	for _, instanceURL := range instanceURLs {
+		instanceKey := getInstanceKey(instanceURL)
+		if instanceKey == key {
+			return instanceURL, true
+		}
+		if instance, pErr := url2.Parse(instanceKey); pErr == nil && strings.EqualFold(instance.Hostname(), u.Hostname()) {
+			sameHost = append(sameHost, instance)
+			sameHostURLs = append(sameHostURLs, instanceURL)
+		}
+	}
+	if len(sameHost) == 1 {
+		return sameHostURLs[0], true
+	}
+
+	match, matchPath := "", ""
+	for i, instance := range sameHost {
+		if instance.Path != "" && strings.HasPrefix(u.Path+"/", instance.Path+"/") && len(instance.Path) > len(matchPath) {
+			match, matchPath = sameHostURLs[i], instance.Path
+		}
+	}
+	return match, match != ""
+}
+
+// getInstanceKey returns the part of the index keys identifying the Confluence instance of a URL.
+// It is the normalized URL with its scheme, port and context path, so instances sharing a host get distinct keys.
+// Confluence Cloud sites are only identified by their host, since Cloud events carry the URL of the page.
+func getInstanceKey(url string) string {
+	normalizedURL, err := util.NormalizeConfluenceURL(url)
+	if err != nil {
+		return url
+	}
+
+	u, err := url2.Parse(normalizedURL)
+	if err != nil {
+		return normalizedURL
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.RawQuery, u.Fragment = "", ""
+	if strings.HasSuffix(u.Hostname(), confluenceCloudDomain) {
+		u.Path, u.RawPath = "", ""
+	}
+	return strings.TrimSuffix(u.String(), "/")
+}
+
+// IsConfluenceCloudURL reports whether the URL belongs to a Confluence Cloud site.
+func IsConfluenceCloudURL(url string) bool {
+	u, err := url2.Parse(getInstanceKey(url))
+	return err == nil && strings.HasSuffix(u.Hostname(), confluenceCloudDomain)
 }
 
+// GetSubscriptionKey returns the key of the legacy KV value that held every subscription.
+// It is only read to migrate subscriptions into per-channel and per-index records.
 func GetSubscriptionKey() string {
 	return util.GetKeyHash(ConfluenceSubscriptionKeyPrefix)
 }
 
+// GetChannelSubscriptionsKey returns the key of the record holding the subscriptions of a channel.
+func GetChannelSubscriptionsKey(channelID string) string {
+	return hashkey(prefixChannelSubscriptions, channelID)
+}
+
+// ParseChannelSubscriptionsKey returns the channel ID of a key built by GetChannelSubscriptionsKey.
+func ParseChannelSubscriptionsKey(key string) (string, bool) {
+	return strings.CutPrefix(key, prefixChannelSubscriptions+"_")
+}
+
+// GetURLPageIDSubscriptionsKey returns the key of the record indexing the channels subscribed to a page.
+// The combination key is hashed to stay within the KV key length limit.
+func GetURLPageIDSubscriptionsKey(combinationKey string) string {
+	return hashkey(prefixURLPageIDSubscriptions, util.GetKeyHash(combinationKey))
+}
+
+// GetURLSpaceKeySubscriptionsKey returns the key of the record indexing the channels subscribed to a space.
+func GetURLSpaceKeySubscriptionsKey(combinationKey string) string {
+	return hashkey(prefixURLSpaceKeySubscriptions, util.GetKeyHash(combinationKey))
+}
+
+// GetURLPageTreeIDSubscriptionsKey returns the key of the record indexing the channels subscribed to the tree of a page.
+func GetURLPageTreeIDSubscriptionsKey(combinationKey string) string {
+	return hashkey(prefixURLPageTreeSubscriptions, util.GetKeyHash(combinationKey))
+}
+
+// GetURLCQLSubscriptionsKey returns the key of the record indexing the CQL subscriptions of an instance.
+func GetURLCQLSubscriptionsKey(combinationKey string) string {
+	return hashkey(prefixURLCQLSubscriptions, util.GetKeyHash(combinationKey))
+}
+
+// GetURLAllSpacesSubscriptionsKey returns the key of the record indexing the channels subscribed to every space of an instance.
+func GetURLAllSpacesSubscriptionsKey(combinationKey string) string {
+	return hashkey(prefixURLAllSpacesSubscriptions, util.GetKeyHash(combinationKey))
+}
+
+// AtomicModify writes the value returned by modify for the current value of the key, if the key was not changed meanwhile,
+// retrying a few times otherwise. Returning nil removes the key.
+// It is kept out of line as tests patch it.
 // from https://github.com/mattermost/mattermost-plugin-jira/blob/master/server/subscribe.go#L625
+//
+//go:noinline
 func AtomicModify(key string, modify func(initialValue []byte) ([]byte, error)) error {
+	return AtomicModifyWithExpiry(key, 0, modify)
+}
+
+// AtomicModifyWithExpiry is AtomicModify for records that expire, the written value is kept until the expiry.
+// A zero expiry keeps it until it is removed.
+func AtomicModifyWithExpiry(key string, expiry time.Duration, modify func(initialValue []byte) ([]byte, error)) error {
 	readModify := func() ([]byte, []byte, error) {
 		initialBytes, appErr := config.Mattermost.KVGet(key)
 		if appErr != nil {
@@ -75,13 +214,25 @@ func AtomicModify(key string, modify func(initialValue []byte) ([]byte, error))
 			return err
 		}
 
+		// Nothing to write. This also avoids a compare and delete of a missing key, which never succeeds.
+		if bytes.Equal(initialBytes, newValue) {
+			return nil
+		}
+
 		var setError *model.AppError
-		success, setError = config.Mattermost.KVCompareAndSet(key, initialBytes, newValue)
+		if expiry > 0 {
+			success, setError = config.Mattermost.KVSetWithOptions(key, newValue, model.PluginKVSetOptions{
+				Atomic:          true,
+				OldValue:        initialBytes,
+				ExpireInSeconds: int64(expiry / time.Second),
+			})
+		} else {
+			success, setError = config.Mattermost.KVCompareAndSet(key, initialBytes, newValue)
+		}
 		if setError != nil {
 			return errors.Wrap(setError, "problem writing value")
 		}
-
-		if currentAttempt == 0 && bytes.Equal(initialBytes, newValue) {
+		if success {
 			return nil
 		}
 
@@ -143,24 +294,28 @@ func Load(key string) ([]byte, error) {
 	return data, nil
 }
 
-func StoreOAuth2State(state string) error {
-	if appErr := config.Mattermost.KVSetWithExpiry(hashkey(prefixOneTimeSecret, state), []byte(state), expiryStoreTimeoutSeconds); appErr != nil {
+// StoreOAuth2State stores the OAuth2 state along with the instance the user is connecting to.
+func StoreOAuth2State(state, instanceID string) error {
+	if appErr := config.Mattermost.KVSetWithExpiry(hashkey(prefixOneTimeSecret, state), []byte(instanceID), expiryStoreTimeoutSeconds); appErr != nil {
 		return errors.WithMessage(appErr, "failed to store state "+state)
 	}
 	return nil
 }
 
-func VerifyOAuth2State(state string) error {
-	data, appErr := config.Mattermost.KVGet(hashkey(prefixOneTimeSecret, state))
+// VerifyOAuth2State verifies the OAuth2 state and returns the instance the user is connecting to.
+func VerifyOAuth2State(state string) (string, error) {
+	key := hashkey(prefixOneTimeSecret, state)
+	data, appErr := config.Mattermost.KVGet(key)
 	if appErr != nil {
-		return errors.WithMessage(appErr, "failed to load state "+state)
+		return "", errors.WithMessage(appErr, "failed to load state "+state)
 	}
 
-	if string(data) != state {
-		return errors.New("invalid oauth state, please try again")
+	if len(data) == 0 {
+		return "", errors.New("invalid oauth state, please try again")
 	}
+	_ = config.Mattermost.KVDelete(key)
 
-	return nil
+	return string(data), nil
 }
 
 func StoreConnection(instanceID, mattermostUserID string, connection *types.Connection) (returnErr error) {
@@ -238,6 +393,7 @@ func LoadUser(mattermostUserID string) (*types.User, error) {
 	if err := get(key, user); err != nil {
 		return nil, errors.WithMessage(err, fmt.Sprintf("failed to load confluence user for mattermostUserId:%s", mattermostUserID))
 	}
+	user.MigrateInstanceURL()
 	return user, nil
 }
 
@@ -247,6 +403,6 @@ func StoreUser(user *types.User) (returnErr error) {
 		return err
 	}
 
-	config.Mattermost.LogDebug("Stored: user %s key:%s: connected to:%q", user.MattermostUserID, key, user.InstanceURL)
+	config.Mattermost.LogDebug("Stored: user %s key:%s: connected to:%q", user.MattermostUserID, key, user.ConnectedInstances)
 	return nil
 }
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+)
+
+// keyTemplates is the key of the default notification templates, set by system administrators for the whole plugin.
+// They are kept in a single record keyed by event type.
+const keyTemplates = "confluence_templates"
+
+// LoadTemplates returns the default notification templates by event type.
+func LoadTemplates() (map[string]string, error) {
+	data, appErr := config.Mattermost.KVGet(keyTemplates)
+	if appErr != nil {
+		return nil, errors.WithMessage(appErr, "failed to load the notification templates")
+	}
+	return templatesFromJSON(data)
+}
+
+func templatesFromJSON(data []byte) (map[string]string, error) {
+	templates := map[string]string{}
+	if len(data) == 0 {
+		return templates, nil
+	}
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the notification templates")
+	}
+	return templates, nil
+}
+
+// StoreTemplate sets the default notification template of the event type.
+func StoreTemplate(eventType, text string) error {
+	return modifyTemplates(func(templates map[string]string) error {
+		templates[eventType] = text
+		return nil
+	})
+}
+
+// DeleteTemplate removes the default notification template of the event type.
+func DeleteTemplate(eventType string) error {
+	return modifyTemplates(func(templates map[string]string) error {
+		if _, ok := templates[eventType]; !ok {
+			return errors.Wrapf(ErrNotFound, "notification template %q", eventType)
+		}
+		delete(templates, eventType)
+		return nil
+	})
+}
+
+func modifyTemplates(modify func(templates map[string]string) error) error {
+	return AtomicModify(keyTemplates, func(initialBytes []byte) ([]byte, error) {
+		templates, err := templatesFromJSON(initialBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = modify(templates); err != nil {
+			return nil, err
+		}
+
+		if len(templates) == 0 {
+			return nil, nil
+		}
+		return json.Marshal(templates)
+	})
+}
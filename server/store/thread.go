@@ -0,0 +1,37 @@
+package store
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util"
+)
+
+const prefixNotificationThread = "confluence_thread"
+
+// getNotificationThreadKey returns the key of the root post of the notification thread of a content in a channel.
+// Pages, blog posts and comments share the content IDs of an instance, so the thread of a comment is keyed like the one of a page.
+// The combination key is hashed to stay within the KV key length limit.
+func getNotificationThreadKey(channelID, url, contentID string) string {
+	return hashkey(prefixNotificationThread, util.GetKeyHash(channelID+"/"+GetURLPageIDCombinationKey(url, contentID)))
+}
+
+// LoadNotificationThread returns the root post of the notification thread of the content in the channel,
+// or an empty string when there is none or it expired.
+func LoadNotificationThread(channelID, url, contentID string) (string, error) {
+	data, appErr := config.Mattermost.KVGet(getNotificationThreadKey(channelID, url, contentID))
+	if appErr != nil {
+		return "", errors.WithMessage(appErr, "failed to load the notification thread")
+	}
+	return string(data), nil
+}
+
+// StoreNotificationThread sets the root post of the notification thread of the content in the channel, until the expiry.
+func StoreNotificationThread(channelID, url, contentID, rootID string, expiry time.Duration) error {
+	if appErr := config.Mattermost.KVSetWithExpiry(getNotificationThreadKey(channelID, url, contentID), []byte(rootID), int64(expiry/time.Second)); appErr != nil {
+		return errors.WithMessage(appErr, "failed to store the notification thread")
+	}
+	return nil
+}
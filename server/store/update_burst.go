@@ -0,0 +1,43 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/util"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
+)
+
+const prefixUpdateBurst = "confluence_update_burst"
+
+// getUpdateBurstKey returns the key of the update burst of a page in a channel, hashed to stay within the KV key length limit.
+func getUpdateBurstKey(channelID, url, pageID string) string {
+	return hashkey(prefixUpdateBurst, util.GetKeyHash(channelID+"/"+GetURLPageIDCombinationKey(url, pageID)))
+}
+
+// ModifyUpdateBurst atomically modifies the update burst of the page in the channel, which is kept until the expiry.
+// The modification gets nil when there is no burst, and returns the burst to store, or nil to leave the record as it is.
+// It may run several times when the burst is modified concurrently.
+func ModifyUpdateBurst(channelID, url, pageID string, expiry time.Duration, modify func(burst *types.UpdateBurst) *types.UpdateBurst) error {
+	return AtomicModifyWithExpiry(getUpdateBurstKey(channelID, url, pageID), expiry, func(initialBytes []byte) ([]byte, error) {
+		var burst *types.UpdateBurst
+		if len(initialBytes) > 0 {
+			burst = &types.UpdateBurst{}
+			if err := json.Unmarshal(initialBytes, burst); err != nil {
+				return nil, errors.Wrap(err, "failed to unmarshal the update burst")
+			}
+		}
+
+		modified := modify(burst)
+		if modified == nil {
+			return initialBytes, nil
+		}
+		data, err := json.Marshal(modified)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal the update burst")
+		}
+		return data, nil
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/service"
+)
+
+const (
+	subscriptionCleanUpJobKey   = "subscription_clean_up"
+	subscriptionCleanUpInterval = 24 * time.Hour
+	systemAdminsPerPage         = 100
+
+	subscriptionCleanUpHeader        = "Some Confluence subscriptions you created were cleaned up:"
+	subscriptionCleanUpUnownedHeader = "Some Confluence subscriptions whose creator is unknown were cleaned up:"
+	subscriptionCleanUpRemoved       = "\n* **%s** was removed, as its channel `%s` was deleted."
+	subscriptionCleanUpDisabled      = "\n* **%s** in ~%s was disabled, as the channel is archived. It is enabled again if the channel is restored."
+)
+
+// scheduleSubscriptionCleanUp starts the periodic clean up of the subscriptions of deleted and archived channels.
+// The job holds a cluster mutex while it runs, so only one server of a cluster cleans up at a time.
+func (p *Plugin) scheduleSubscriptionCleanUp() error {
+	job, err := cluster.Schedule(p.API, subscriptionCleanUpJobKey, cluster.MakeWaitForRoundedInterval(subscriptionCleanUpInterval), p.cleanUpSubscriptions)
+	if err != nil {
+		return err
+	}
+	p.subscriptionCleanUpJob = job
+	return nil
+}
+
+// cleanUpSubscriptions cleans up the subscriptions of deleted and archived channels
+// and tells the users who created them what was done. The system admins are told about the subscriptions
+// whose creator is unknown, which are the ones created before the creator was recorded.
+func (p *Plugin) cleanUpSubscriptions() {
+	cleanUps, err := service.CleanUpSubscriptions()
+	if err != nil {
+		config.Mattermost.LogError("Unable to clean up the subscriptions of deleted and archived channels.", "Error", err.Error())
+		return
+	}
+
+	summaries := map[string]string{}
+	unowned := ""
+	for _, cleanUp := range cleanUps {
+		alias := cleanUp.Subscription.GetAlias()
+		owner := cleanUp.Subscription.GetAuditInfo().CreatedBy
+		config.Mattermost.LogInfo("Cleaned up the subscription of a deleted or archived channel.", "Subscription", alias, "ChannelID", cleanUp.Subscription.GetChannelID(), "Removed", cleanUp.Removed)
+
+		summary := fmt.Sprintf(subscriptionCleanUpDisabled, alias, cleanUp.ChannelName)
+		if cleanUp.Removed {
+			summary = fmt.Sprintf(subscriptionCleanUpRemoved, alias, cleanUp.ChannelName)
+		}
+		if owner == "" {
+			unowned += summary
+			continue
+		}
+		summaries[owner] += summary
+	}
+
+	for owner, summary := range summaries {
+		p.sendSubscriptionCleanUpSummary(owner, subscriptionCleanUpHeader+summary)
+	}
+	if unowned == "" {
+		return
+	}
+
+	admins, err := getSystemAdminIDs()
+	if err != nil {
+		config.Mattermost.LogError("Unable to get the system admins to send the subscription clean up summary.", "Error", err.Error())
+		return
+	}
+	for _, admin := range admins {
+		p.sendSubscriptionCleanUpSummary(admin, subscriptionCleanUpUnownedHeader+unowned)
+	}
+}
+
+func (p *Plugin) sendSubscriptionCleanUpSummary(userID, message string) {
+	if dmErr := p.client.Post.DM(p.BotUserID, userID, &model.Post{Message: message}); dmErr != nil {
+		config.Mattermost.LogWarn("Unable to send the subscription clean up summary.", "UserID", userID, "Error", dmErr.Error())
+	}
+}
+
+// getSystemAdminIDs returns the IDs of the active system admins.
+func getSystemAdminIDs() ([]string, error) {
+	var adminIDs []string
+	for page := 0; ; page++ {
+		admins, appErr := config.Mattermost.GetUsers(&model.UserGetOptions{
+			Role:    model.SystemAdminRoleId,
+			Page:    page,
+			PerPage: systemAdminsPerPage,
+		})
+		if appErr != nil {
+			return nil, appErr
+		}
+		for _, admin := range admins {
+			if !admin.IsBot {
+				adminIDs = append(adminIDs, admin.Id)
+			}
+		}
+		if len(admins) < systemAdminsPerPage {
+			return adminIDs, nil
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/service"
+)
+
+const (
+	// maxImportSize is the largest export file that is imported.
+	maxImportSize = 5 * 1024 * 1024
+	// importPostsToSearch is how many recent posts of the channel are searched for the file to import.
+	importPostsToSearch = 30
+
+	exportSuccess     = "The subscriptions of this channel were exported, the file was sent to you in a direct message."
+	exportMessage     = "Here are the Confluence subscriptions of ~%s. Upload this file to another channel and run `/confluence import` there to import them."
+	noImportFile      = "Please upload the JSON file of a subscription export to this channel, then run `/confluence import`, or `/confluence import <file-id>` for another file you uploaded."
+	importFileTooBig  = "The file **%s** is too big to be a subscription export."
+	importFileInvalid = "Unable to read the file **%s**: %s"
+	importSummary     = "Imported %d of the %d subscriptions of **%s**."
+	importEntryFailed = "\n* Entry %d%s: %s"
+)
+
+func executeExport(p *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	if !checkSubscriptionPermissionCommand(context) {
+		return &model.CommandResponse{}
+	}
+
+	data, err := service.ExportSubscriptions(context.ChannelId)
+	if err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+
+	if err = p.sendExport(context.UserId, context.ChannelId, data); err != nil {
+		config.Mattermost.LogError("Unable to send the subscription export.", "UserID", context.UserId, "Error", err.Error())
+		postCommandResponse(context, errorExecutingCommand)
+		return &model.CommandResponse{}
+	}
+	postCommandResponse(context, exportSuccess)
+	return &model.CommandResponse{}
+}
+
+// sendExport sends the export of the subscriptions of the channel to the user as a file in a direct message from the bot.
+func (p *Plugin) sendExport(userID, channelID string, data []byte) error {
+	channel, appErr := config.Mattermost.GetChannel(channelID)
+	if appErr != nil {
+		return appErr
+	}
+	directChannel, appErr := config.Mattermost.GetDirectChannel(userID, p.BotUserID)
+	if appErr != nil {
+		return appErr
+	}
+
+	fileName := fmt.Sprintf("confluence-subscriptions-%s-%s.json", channel.Name, time.Now().UTC().Format("2006-01-02"))
+	fileInfo, appErr := config.Mattermost.UploadFile(data, directChannel.Id, fileName)
+	if appErr != nil {
+		return appErr
+	}
+
+	_, appErr = config.Mattermost.CreatePost(&model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: directChannel.Id,
+		Message:   fmt.Sprintf(exportMessage, channel.Name),
+		FileIds:   []string{fileInfo.Id},
+	})
+	if appErr != nil {
+		return appErr
+	}
+	return nil
+}
+
+func executeImport(p *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	if !checkSubscriptionPermissionCommand(context) {
+		return &model.CommandResponse{}
+	}
+
+	var fileInfo *model.FileInfo
+	if len(args) > 0 {
+		info, appErr := config.Mattermost.GetFileInfo(args[0])
+		if appErr == nil && info.CreatorId == context.UserId {
+			fileInfo = info
+		}
+	} else {
+		fileInfo = findImportFile(context.UserId, context.ChannelId)
+	}
+	if fileInfo == nil {
+		postCommandResponse(context, noImportFile)
+		return &model.CommandResponse{}
+	}
+	if fileInfo.Size > maxImportSize {
+		postCommandResponse(context, fmt.Sprintf(importFileTooBig, fileInfo.Name))
+		return &model.CommandResponse{}
+	}
+
+	data, appErr := config.Mattermost.GetFile(fileInfo.Id)
+	if appErr != nil {
+		postCommandResponse(context, fmt.Sprintf(importFileInvalid, fileInfo.Name, appErr.Error()))
+		return &model.CommandResponse{}
+	}
+
+	results, err := service.ImportSubscriptions(data, context.ChannelId, context.UserId, p.importSubscriptionResolver(context.UserId))
+	if err != nil {
+		postCommandResponse(context, fmt.Sprintf(importFileInvalid, fileInfo.Name, err.Error()))
+		return &model.CommandResponse{}
+	}
+	postCommandResponse(context, formatImportResults(fileInfo.Name, results))
+	return &model.CommandResponse{}
+}
+
+// importSubscriptionResolver returns the checks of the subscriptions imported by the user,
+// the same as the ones of the subscriptions the user creates.
+func (p *Plugin) importSubscriptionResolver(userID string) service.SubscriptionResolver {
+	return func(subscription serializer.Subscription) (serializer.Subscription, int, error) {
+		subscription, statusCode, err := p.resolveSubscriptionTarget(userID, subscription)
+		if err != nil {
+			return subscription, statusCode, err
+		}
+		return applySubscriptionPreset(subscription)
+	}
+}
+
+// findImportFile returns the latest JSON file the user uploaded to the channel, among its recent posts.
+func findImportFile(userID, channelID string) *model.FileInfo {
+	postList, appErr := config.Mattermost.GetPostsForChannel(channelID, 0, importPostsToSearch)
+	if appErr != nil {
+		config.Mattermost.LogWarn("Unable to get the posts of the channel to import subscriptions.", "ChannelID", channelID, "Error", appErr.Error())
+		return nil
+	}
+
+	for _, postID := range postList.Order {
+		post := postList.Posts[postID]
+		if post == nil || post.UserId != userID {
+			continue
+		}
+		for _, fileID := range post.FileIds {
+			fileInfo, fErr := config.Mattermost.GetFileInfo(fileID)
+			if fErr == nil && strings.EqualFold(fileInfo.Extension, "json") {
+				return fileInfo
+			}
+		}
+	}
+	return nil
+}
+
+func formatImportResults(fileName string, results []service.SubscriptionImportResult) string {
+	imported := 0
+	var failures string
+	for _, result := range results {
+		if result.Error == "" {
+			imported++
+			continue
+		}
+		alias := ""
+		if result.Alias != "" {
+			alias = fmt.Sprintf(" (**%s**)", result.Alias)
+		}
+		failures += fmt.Sprintf(importEntryFailed, result.Entry, alias, result.Error)
+	}
+	return fmt.Sprintf(importSummary, imported, len(results), fileName) + failures
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util"
+)
+
+const (
+	subscriptionsOnlyTeamAdmin     = "Subscriptions of this channel can only be managed by a team administrator."
+	subscriptionsOnlyChannelAdmin  = "Subscriptions of this channel can only be managed by a channel administrator."
+	subscriptionsOnlyChannelMember = "Subscriptions of this channel can only be managed by its members."
+)
+
+// checkSubscriptionPermission returns why the user is not allowed to manage the subscriptions of the channel,
+// or an empty string if they are. The policy is set by the SubscriptionPermission plugin setting.
+func checkSubscriptionPermission(userID, channelID string) (string, error) {
+	connectionRequired := isUserConnectionRequired()
+	policy := config.GetConfig().GetSubscriptionPermission(connectionRequired)
+	isSystemAdmin := util.IsSystemAdmin(userID)
+
+	if connectionRequired || (policy == config.SubscriptionPermissionChannelMember && !isSystemAdmin) {
+		connected, err := hasUserConnection(userID)
+		if err != nil {
+			return "", err
+		}
+		if !connected {
+			return disconnectedUser, nil
+		}
+	}
+
+	if isSystemAdmin {
+		return "", nil
+	}
+	if policy == config.SubscriptionPermissionSystemAdmin {
+		return commandsOnlySystemAdmin, nil
+	}
+
+	// Team admins manage the channels of their team whether or not they are members of them.
+	if policy == config.SubscriptionPermissionTeamAdmin || policy == config.SubscriptionPermissionChannelAdmin {
+		teamAdmin, err := isTeamAdmin(userID, channelID)
+		if err != nil {
+			return "", err
+		}
+		if teamAdmin {
+			return "", nil
+		}
+		if policy == config.SubscriptionPermissionTeamAdmin {
+			return subscriptionsOnlyTeamAdmin, nil
+		}
+	}
+
+	member, appErr := config.Mattermost.GetChannelMember(channelID, userID)
+	if appErr != nil {
+		if appErr.StatusCode == http.StatusNotFound {
+			return subscriptionsOnlyChannelMember, nil
+		}
+		return "", appErr
+	}
+
+	if policy == config.SubscriptionPermissionChannelAdmin && !member.SchemeAdmin {
+		return subscriptionsOnlyChannelAdmin, nil
+	}
+	return "", nil
+}
+
+// isTeamAdmin reports whether the user is an admin of the team of the channel.
+// Direct and group messages belong to no team, so nobody is a team admin there.
+func isTeamAdmin(userID, channelID string) (bool, error) {
+	channel, appErr := config.Mattermost.GetChannel(channelID)
+	if appErr != nil {
+		return false, appErr
+	}
+	if channel.TeamId == "" {
+		return false, nil
+	}
+
+	member, appErr := config.Mattermost.GetTeamMember(channel.TeamId, userID)
+	if appErr != nil {
+		if appErr.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, appErr
+	}
+	return member.SchemeAdmin, nil
+}
+
+// checkSubscriptionPermissionHTTP writes an error response and returns false
+// if the user of the request is not allowed to manage the subscriptions of the channel.
+func checkSubscriptionPermissionHTTP(w http.ResponseWriter, userID, channelID string) bool {
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+
+	denied, err := checkSubscriptionPermission(userID, channelID)
+	if err != nil {
+		config.Mattermost.LogError("Unable to check the subscription permission.", "UserID", userID, "ChannelID", channelID, "Error", err.Error())
+		http.Error(w, "Could not check the permissions of the user", http.StatusInternalServerError)
+		return false
+	}
+	if denied != "" {
+		http.Error(w, denied, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// checkSubscriptionPermissionCommand tells the user of the command why they are not allowed
+// to manage the subscriptions of the channel and returns false, if they are not.
+func checkSubscriptionPermissionCommand(context *model.CommandArgs) bool {
+	denied, err := checkSubscriptionPermission(context.UserId, context.ChannelId)
+	if err != nil {
+		postCommandResponse(context, errorExecutingCommand)
+		return false
+	}
+	if denied != "" {
+		postCommandResponse(context, denied)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/service"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+)
+
+const (
+	spaceNotFound = "space **%s** was not found on %s, or you do not have access to it"
+	pageNotFound  = "page **%s** was not found on %s, or you do not have access to it"
+	invalidPageID = "page id **%s** is not valid, it must be a number"
+
+	urlWithoutSpace   = "**%s** does not point to a Confluence space"
+	urlWithoutPage    = "**%s** does not point to a Confluence page"
+	pageTitleNotFound = "page **%s** was not found in space **%s**, or you do not have access to it"
+	pageLookupFailed  = "unable to find page **%s** in Confluence, please use the page ID"
+
+	invalidCQL = "the query is not valid CQL: %s"
+)
+
+// resolveSubscriptionTarget checks with the Confluence REST API that the subscribed space or page exists
+// and that the user can see it, and returns the subscription with the space name or page title.
+// It is skipped when the user has no Confluence account connected on the instance of the subscription.
+// Only a missing or forbidden space or page is rejected, other failures are logged and the subscription is kept as is.
+// Invalid subscriptions are left for the validation when they are saved.
+// A space or page URL given instead of the space key or page ID is first replaced by what it points to.
+// The space name or page title given with the subscription is dropped, so only names read from Confluence are kept.
+func (p *Plugin) resolveSubscriptionTarget(userID string, subscription serializer.Subscription) (serializer.Subscription, int, error) {
+	subscription, urlStatus, err := p.resolveSubscriptionURL(userID, withoutTargetName(subscription))
+	if err != nil {
+		return subscription, urlStatus, err
+	}
+	if subscription.IsValid() != nil {
+		return subscription, http.StatusOK, nil
+	}
+	if cqlStatus, cErr := service.CheckCQLSubscription(subscription); cErr != nil {
+		return subscription, cqlStatus, cErr
+	}
+
+	client, err := p.getSubscriptionClient(userID, subscription.GetBaseURL())
+	if err != nil {
+		config.Mattermost.LogWarn("Unable to get a Confluence client to check the subscription.", "UserID", userID, "Error", err.Error())
+		return subscription, http.StatusOK, nil
+	}
+	if client == nil {
+		return subscription, http.StatusOK, nil
+	}
+
+	switch s := subscription.(type) {
+	case serializer.SpaceSubscription:
+		space, sErr := client.GetSpaceData(s.SpaceKey)
+		if sErr != nil {
+			statusCode, checkErr := checkTargetError(sErr, fmt.Errorf(spaceNotFound, s.SpaceKey, s.BaseURL))
+			return subscription, statusCode, checkErr
+		}
+		s.SpaceName = space.Name
+		return s, http.StatusOK, nil
+	case serializer.PageSubscription:
+		title, statusCode, pErr := getPageTitle(client, s.PageID, s.BaseURL)
+		if pErr != nil || statusCode != http.StatusOK {
+			return subscription, statusCode, pErr
+		}
+		s.PageTitle = title
+		return s, http.StatusOK, nil
+	case serializer.PageTreeSubscription:
+		title, statusCode, pErr := getPageTitle(client, s.PageID, s.BaseURL)
+		if pErr != nil || statusCode != http.StatusOK {
+			return subscription, statusCode, pErr
+		}
+		s.PageTitle = title
+		return s, http.StatusOK, nil
+	case serializer.CQLSubscription:
+		if vErr := client.ValidateCQL(s.CQL); vErr != nil {
+			var responseErr *service.ResponseError
+			if errors.As(vErr, &responseErr) && responseErr.StatusCode == http.StatusBadRequest {
+				return subscription, http.StatusBadRequest, fmt.Errorf(invalidCQL, responseErr.Message)
+			}
+			config.Mattermost.LogWarn("Unable to check the query of the subscription with Confluence.", "Error", vErr.Error())
+		}
+		return subscription, http.StatusOK, nil
+	default:
+		return subscription, http.StatusOK, nil
+	}
+}
+
+// withoutTargetName returns the subscription without the space name or page title it holds.
+func withoutTargetName(subscription serializer.Subscription) serializer.Subscription {
+	switch s := subscription.(type) {
+	case serializer.SpaceSubscription:
+		s.SpaceName = ""
+		return s
+	case serializer.PageSubscription:
+		s.PageTitle = ""
+		return s
+	case serializer.PageTreeSubscription:
+		s.PageTitle = ""
+		return s
+	default:
+		return subscription
+	}
+}
+
+// resolveSubscriptionURL replaces a space or page URL given instead of the space key or page ID of the subscription
+// by the key or ID it points to, and takes the base URL of the subscription from it when it has none.
+func (p *Plugin) resolveSubscriptionURL(userID string, subscription serializer.Subscription) (serializer.Subscription, int, error) {
+	switch s := subscription.(type) {
+	case serializer.SpaceSubscription:
+		if !service.IsConfluenceURL(s.SpaceKey) {
+			return subscription, http.StatusOK, nil
+		}
+		confluenceURL, err := service.ParseConfluenceURL(s.SpaceKey)
+		if err != nil {
+			return subscription, http.StatusBadRequest, err
+		}
+		if confluenceURL.SpaceKey == "" {
+			return subscription, http.StatusBadRequest, fmt.Errorf(urlWithoutSpace, s.SpaceKey)
+		}
+		s.SpaceKey = confluenceURL.SpaceKey
+		if s.BaseURL == "" {
+			s.BaseURL = confluenceURL.BaseURL
+		}
+		return s, http.StatusOK, nil
+	case serializer.PageSubscription:
+		pageID, baseURL, statusCode, err := p.resolvePageURL(userID, s.PageID, s.BaseURL)
+		if err != nil {
+			return subscription, statusCode, err
+		}
+		s.PageID, s.BaseURL = pageID, baseURL
+		return s, http.StatusOK, nil
+	case serializer.PageTreeSubscription:
+		pageID, baseURL, statusCode, err := p.resolvePageURL(userID, s.PageID, s.BaseURL)
+		if err != nil {
+			return subscription, statusCode, err
+		}
+		s.PageID, s.BaseURL = pageID, baseURL
+		return s, http.StatusOK, nil
+	default:
+		return subscription, http.StatusOK, nil
+	}
+}
+
+// resolvePageURL returns the page ID and base URL for a page given by its ID or URL.
+// Anything else is rejected, as page IDs are numbers.
+// A page URL holding only the page title is looked up with the Confluence account of the user.
+func (p *Plugin) resolvePageURL(userID, page, baseURL string) (string, string, int, error) {
+	if !service.IsConfluenceURL(page) {
+		if _, err := strconv.ParseUint(page, 10, 64); err != nil && page != "" {
+			return "", "", http.StatusBadRequest, fmt.Errorf(invalidPageID, page)
+		}
+		return page, baseURL, http.StatusOK, nil
+	}
+
+	confluenceURL, err := service.ParseConfluenceURL(page)
+	if err != nil {
+		return "", "", http.StatusBadRequest, err
+	}
+	if !confluenceURL.IsPage() {
+		return "", "", http.StatusBadRequest, fmt.Errorf(urlWithoutPage, page)
+	}
+	if baseURL == "" {
+		baseURL = confluenceURL.BaseURL
+	}
+
+	var finder service.PageFinder
+	if confluenceURL.PageID == "" {
+		client, cErr := p.getSubscriptionClient(userID, confluenceURL.BaseURL)
+		if cErr != nil {
+			config.Mattermost.LogWarn("Unable to get a Confluence client to find the page.", "UserID", userID, "Error", cErr.Error())
+			return "", "", http.StatusInternalServerError, fmt.Errorf(pageLookupFailed, confluenceURL.PageTitle)
+		}
+		if client != nil {
+			finder = client
+		}
+	}
+
+	pageID, err := confluenceURL.ResolvePageID(finder)
+	if err != nil {
+		if finder == nil {
+			return "", "", http.StatusBadRequest, err
+		}
+		var responseErr *service.ResponseError
+		if errors.As(err, &responseErr) && (responseErr.StatusCode == http.StatusNotFound || responseErr.StatusCode == http.StatusForbidden) {
+			return "", "", http.StatusBadRequest, fmt.Errorf(pageTitleNotFound, confluenceURL.PageTitle, confluenceURL.SpaceKey)
+		}
+		config.Mattermost.LogWarn("Unable to find the page by its title.", "Title", confluenceURL.PageTitle, "Error", err.Error())
+		return "", "", http.StatusInternalServerError, fmt.Errorf(pageLookupFailed, confluenceURL.PageTitle)
+	}
+	return pageID, baseURL, http.StatusOK, nil
+}
+
+// getSubscriptionClient returns a client for the instance acting as the user,
+// or nil if the user has no connection there.
+func (p *Plugin) getSubscriptionClient(userID, baseURL string) (Client, error) {
+	instanceURL, err := service.NormalizeConfluenceURL(baseURL)
+	if err != nil {
+		return nil, nil
+	}
+
+	connection, err := store.LoadConnection(instanceURL, userID)
+	if err != nil {
+		if errors.Cause(err) == store.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(connection.ConfluenceAccountID()) == 0 {
+		return nil, nil
+	}
+
+	if _, err = store.LoadInstance(instanceURL); err != nil {
+		if errors.Cause(err) == store.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return p.GetServerClient(instanceURL, connection)
+}
+
+func getPageTitle(client Client, pageID, baseURL string) (string, int, error) {
+	id, err := strconv.Atoi(pageID)
+	if err != nil {
+		return "", http.StatusBadRequest, fmt.Errorf(invalidPageID, pageID)
+	}
+
+	page, err := client.GetPageData(id)
+	if err != nil {
+		statusCode, checkErr := checkTargetError(err, fmt.Errorf(pageNotFound, pageID, baseURL))
+		return "", statusCode, checkErr
+	}
+	return page.Title, http.StatusOK, nil
+}
+
+// checkTargetError returns notFoundErr when Confluence responded that the space or page is missing or forbidden.
+// Other errors are only logged, so a Confluence outage does not prevent saving subscriptions.
+func checkTargetError(err, notFoundErr error) (int, error) {
+	var responseErr *service.ResponseError
+	if errors.As(err, &responseErr) && (responseErr.StatusCode == http.StatusNotFound || responseErr.StatusCode == http.StatusForbidden) {
+		return http.StatusBadRequest, notFoundErr
+	}
+
+	config.Mattermost.LogWarn("Unable to check the subscription with Confluence.", "Error", err.Error())
+	return http.StatusOK, nil
+}
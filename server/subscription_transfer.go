@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/config"
+	"github.com/mattermost/mattermost-plugin-confluence/server/service"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util"
+)
+
+const (
+	flagAll = "all"
+
+	transferUsage          = "Please specify a subscription name, or `--all`, and the target channel, like `/confluence subscription %s \"<name>\" ~target-channel`."
+	transferChannelUnknown = "Channel **%s** was not found in this team."
+	transferTargetDenied   = "You can not manage the subscriptions of ~%s: %s"
+	transferSuccess        = "Subscription **%s** has been %s to ~%s."
+	transferSummary        = "%d of the %d subscriptions of this channel have been %s to ~%s."
+	transferEntryFailed    = "\n* **%s**: %s"
+
+	transferCopy = "copy"
+	transferMove = "move"
+)
+
+func copySubscription(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	return transferSubscriptions(context, transferCopy, args...)
+}
+
+func moveSubscription(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	return transferSubscriptions(context, transferMove, args...)
+}
+
+// transferSubscriptions copies or moves one subscription, or every subscription with `--all`,
+// of the current channel to the channel given last, in the same team.
+func transferSubscriptions(context *model.CommandArgs, action string, args ...string) *model.CommandResponse {
+	if !checkSubscriptionPermissionCommand(context) {
+		return &model.CommandResponse{}
+	}
+
+	positional, flags, err := util.ParseFlags(args, flagAll)
+	if err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+	for flag := range flags {
+		if flag != flagAll {
+			postCommandResponse(context, fmt.Sprintf("flag --%s is not supported", flag))
+			return &model.CommandResponse{}
+		}
+	}
+	all := flags[flagAll] != ""
+	if len(positional) == 0 || (all && len(positional) != 1) || (!all && len(positional) < 2) {
+		postCommandResponse(context, fmt.Sprintf(transferUsage, action))
+		return &model.CommandResponse{}
+	}
+
+	channelName := strings.TrimPrefix(positional[len(positional)-1], "~")
+	target, appErr := config.Mattermost.GetChannelByName(context.TeamId, channelName, false)
+	if appErr != nil {
+		if appErr.StatusCode == http.StatusNotFound {
+			postCommandResponse(context, fmt.Sprintf(transferChannelUnknown, channelName))
+			return &model.CommandResponse{}
+		}
+		postCommandResponse(context, errorExecutingCommand)
+		return &model.CommandResponse{}
+	}
+	denied, err := checkSubscriptionPermission(context.UserId, target.Id)
+	if err != nil {
+		postCommandResponse(context, errorExecutingCommand)
+		return &model.CommandResponse{}
+	}
+	if denied != "" {
+		postCommandResponse(context, fmt.Sprintf(transferTargetDenied, target.Name, denied))
+		return &model.CommandResponse{}
+	}
+
+	var aliases []string
+	if !all {
+		aliases = []string{strings.Join(positional[:len(positional)-1], " ")}
+	}
+	results, err := service.TransferSubscriptions(context.ChannelId, target.Id, aliases, action == transferMove, context.UserId)
+	if err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+	postCommandResponse(context, formatTransferResults(results, action, target.Name, all))
+	return &model.CommandResponse{}
+}
+
+func formatTransferResults(results []service.SubscriptionTransferResult, action, channelName string, all bool) string {
+	done := "copied"
+	if action == transferMove {
+		done = "moved"
+	}
+	if len(results) == 0 {
+		return noChannelSubscription
+	}
+	if !all {
+		if results[0].Error != "" {
+			return results[0].Error
+		}
+		return fmt.Sprintf(transferSuccess, results[0].Alias, done, channelName)
+	}
+
+	transferred := 0
+	var failures string
+	for _, result := range results {
+		if result.Error == "" {
+			transferred++
+			continue
+		}
+		failures += fmt.Sprintf(transferEntryFailed, result.Alias, result.Error)
+	}
+	return fmt.Sprintf(transferSummary, transferred, len(results), done, channelName) + failures
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-confluence/server/serializer"
+	"github.com/mattermost/mattermost-plugin-confluence/server/service"
+	"github.com/mattermost/mattermost-plugin-confluence/server/store"
+	"github.com/mattermost/mattermost-plugin-confluence/server/util"
+)
+
+const (
+	flagSubscription = "subscription"
+
+	templateSetUsage            = "Please specify an event and a template in quotes, like `/confluence template set page_updated \"{{.Author}} updated [{{.Title}}]({{.URL}})\"`."
+	specifyTemplateEvent        = "Please specify an event, the supported events are %s."
+	templateSetSuccess          = "The default template of **%s** has been set:\n```\n%s\n```"
+	templateSubscriptionSuccess = "The template of **%s** for subscription **%s** has been set:\n```\n%s\n```"
+	templateResetSuccess        = "The default template of **%s** has been removed, the built-in notification is sent again."
+	templateSubscriptionReset   = "The template of **%s** for subscription **%s** has been removed, the default one is used again."
+	templateNotFound            = "no default template is set for **%s**"
+	subscriptionTemplateMissing = "no template is set for **%s** on subscription **%s**"
+	noTemplate                  = "No template is set for **%s**, the built-in notification is sent."
+	noTemplates                 = "No notification templates are set, the built-in notifications are sent."
+	invalidTemplate             = "invalid template: %s"
+	templatePreview             = "Preview of the **%s** template with a sample event:\n\n%s"
+)
+
+func listTemplates(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	if !checkSubscriptionPermissionCommand(context) {
+		return &model.CommandResponse{}
+	}
+
+	defaults, err := store.LoadTemplates()
+	if err != nil {
+		postCommandResponse(context, errorExecutingCommand)
+		return &model.CommandResponse{}
+	}
+	channelSubscriptions, err := service.GetSubscriptionsByChannelID(context.ChannelId)
+	if err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+
+	hasOverride := false
+	for _, subscription := range channelSubscriptions {
+		hasOverride = hasOverride || len(subscription.GetTemplates()) > 0
+	}
+	if len(defaults) == 0 && !hasOverride {
+		postCommandResponse(context, noTemplates)
+		return &model.CommandResponse{}
+	}
+	postCommandResponse(context, serializer.FormattedTemplateList(defaults, channelSubscriptions))
+	return &model.CommandResponse{}
+}
+
+// setTemplate sets the default template of an event, or the one of a subscription of the channel with `--subscription`.
+// Only valid templates are saved, so a template only fails to render for the data of an actual event.
+func setTemplate(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	eventType, text, alias, ok := parseTemplateArgs(context, args)
+	if !ok || !checkTemplatePermission(context, alias) {
+		return &model.CommandResponse{}
+	}
+	if eventType == "" || text == "" {
+		postCommandResponse(context, templateSetUsage)
+		return &model.CommandResponse{}
+	}
+	if err := serializer.ValidateTemplate(eventType, text); err != nil {
+		postCommandResponse(context, err.Error())
+		return &model.CommandResponse{}
+	}
+
+	if alias != "" {
+		if err := service.SetSubscriptionTemplate(context.ChannelId, alias, eventType, text); err != nil {
+			postCommandResponse(context, err.Error())
+			return &model.CommandResponse{}
+		}
+		postCommandResponse(context, fmt.Sprintf(templateSubscriptionSuccess, eventType, alias, text))
+		return &model.CommandResponse{}
+	}
+
+	if err := store.StoreTemplate(eventType, text); err != nil {
+		postCommandResponse(context, errorExecutingCommand)
+		return &model.CommandResponse{}
+	}
+	service.ResetDefaultTemplates()
+	postCommandResponse(context, fmt.Sprintf(templateSetSuccess, eventType, text))
+	return &model.CommandResponse{}
+}
+
+func resetTemplate(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	eventType, _, alias, ok := parseTemplateArgs(context, args)
+	if !ok || !checkTemplatePermission(context, alias) {
+		return &model.CommandResponse{}
+	}
+	if eventType == "" {
+		postCommandResponse(context, fmt.Sprintf(specifyTemplateEvent, strings.Join(serializer.SupportedEvents(), ", ")))
+		return &model.CommandResponse{}
+	}
+
+	if alias != "" {
+		text, err := subscriptionTemplate(context.ChannelId, alias, eventType)
+		if err != nil {
+			postCommandResponse(context, err.Error())
+			return &model.CommandResponse{}
+		}
+		if text == "" {
+			postCommandResponse(context, fmt.Sprintf(subscriptionTemplateMissing, eventType, alias))
+			return &model.CommandResponse{}
+		}
+		if err = service.SetSubscriptionTemplate(context.ChannelId, alias, eventType, ""); err != nil {
+			postCommandResponse(context, err.Error())
+			return &model.CommandResponse{}
+		}
+		postCommandResponse(context, fmt.Sprintf(templateSubscriptionReset, eventType, alias))
+		return &model.CommandResponse{}
+	}
+
+	if err := store.DeleteTemplate(eventType); err != nil {
+		if errors.Cause(err) == store.ErrNotFound {
+			postCommandResponse(context, fmt.Sprintf(templateNotFound, eventType))
+			return &model.CommandResponse{}
+		}
+		postCommandResponse(context, errorExecutingCommand)
+		return &model.CommandResponse{}
+	}
+	service.ResetDefaultTemplates()
+	postCommandResponse(context, fmt.Sprintf(templateResetSuccess, eventType))
+	return &model.CommandResponse{}
+}
+
+// previewTemplate renders the given template, or the one notifications of the event use, with a sample event.
+func previewTemplate(_ *Plugin, context *model.CommandArgs, args ...string) *model.CommandResponse {
+	eventType, text, alias, ok := parseTemplateArgs(context, args)
+	if !ok {
+		return &model.CommandResponse{}
+	}
+	if eventType == "" {
+		postCommandResponse(context, fmt.Sprintf(specifyTemplateEvent, strings.Join(serializer.SupportedEvents(), ", ")))
+		return &model.CommandResponse{}
+	}
+	if alias != "" && !checkSubscriptionPermissionCommand(context) {
+		return &model.CommandResponse{}
+	}
+
+	if text == "" && alias != "" {
+		var err error
+		if text, err = subscriptionTemplate(context.ChannelId, alias, eventType); err != nil {
+			postCommandResponse(context, err.Error())
+			return &model.CommandResponse{}
+		}
+	}
+	if text == "" {
+		defaults, err := store.LoadTemplates()
+		if err != nil {
+			postCommandResponse(context, errorExecutingCommand)
+			return &model.CommandResponse{}
+		}
+		text = defaults[eventType]
+	}
+	if text == "" {
+		postCommandResponse(context, fmt.Sprintf(noTemplate, eventType))
+		return &model.CommandResponse{}
+	}
+
+	message, err := serializer.RenderTemplate(text, serializer.SampleTemplateData(eventType))
+	if err != nil {
+		postCommandResponse(context, fmt.Sprintf(invalidTemplate, err.Error()))
+		return &model.CommandResponse{}
+	}
+	postCommandResponse(context, fmt.Sprintf(templatePreview, eventType, message))
+	return &model.CommandResponse{}
+}
+
+// parseTemplateArgs returns the event, template and subscription name of `/confluence template` commands,
+// like `page_updated "<template>" --subscription "<name>"`. The event is checked when given.
+func parseTemplateArgs(context *model.CommandArgs, args []string) (eventType, text, alias string, ok bool) {
+	positional, flags, err := util.ParseFlags(args)
+	if err != nil {
+		postCommandResponse(context, err.Error())
+		return "", "", "", false
+	}
+	for flag := range flags {
+		if flag != flagSubscription {
+			postCommandResponse(context, fmt.Sprintf("flag --%s is not supported", flag))
+			return "", "", "", false
+		}
+	}
+	if len(positional) == 0 {
+		return "", "", flags[flagSubscription], true
+	}
+	if !serializer.IsSupportedEvent(positional[0]) {
+		postCommandResponse(context, fmt.Sprintf(unsupportedEvent, positional[0], strings.Join(serializer.SupportedEvents(), ", ")))
+		return "", "", "", false
+	}
+	return positional[0], strings.Join(positional[1:], " "), flags[flagSubscription], true
+}
+
+// checkTemplatePermission checks the user can change the templates of a subscription of the channel,
+// or the default templates when no subscription is given, which only system administrators can.
+func checkTemplatePermission(context *model.CommandArgs, alias string) bool {
+	if alias != "" {
+		return checkSubscriptionPermissionCommand(context)
+	}
+	if !util.IsSystemAdmin(context.UserId) {
+		postCommandResponse(context, commandsOnlySystemAdmin)
+		return false
+	}
+	return true
+}
+
+// subscriptionTemplate returns the template of the subscription of the channel for the event, empty when it has none.
+func subscriptionTemplate(channelID, alias, eventType string) (string, error) {
+	subscription, _, err := service.GetChannelSubscription(channelID, alias)
+	if err != nil {
+		return "", err
+	}
+	return subscription.GetTemplates()[eventType], nil
+}
@@ -15,7 +15,6 @@ import (
 
 	"github.com/mattermost/mattermost-plugin-confluence/server/config"
 	"github.com/mattermost/mattermost-plugin-confluence/server/store"
-	"github.com/mattermost/mattermost-plugin-confluence/server/util"
 	"github.com/mattermost/mattermost-plugin-confluence/server/util/types"
 )
 
@@ -39,11 +38,16 @@ func httpOAuth2Connect(w http.ResponseWriter, r *http.Request, p *Plugin) {
 		return
 	}
 
-	instanceURL := config.GetConfig().GetConfluenceBaseURL()
-	if instanceURL == "" {
-		http.Error(w, "missing Confluence base url. Please run `/confluence install server`", http.StatusInternalServerError)
+	instance, err := resolveInstance(instanceIDFromRequest(r))
+	if err != nil {
+		if errors.Cause(err) == errMultipleInstances {
+			http.Error(w, "several Confluence instances are installed. Please use `/confluence connect <instance-url>`", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "missing Confluence instance. Please run `/confluence install server`", http.StatusInternalServerError)
 		return
 	}
+	instanceURL := instance.URL
 
 	connection, err := store.LoadConnection(instanceURL, mattermostUserID)
 	if err == nil && len(connection.ConfluenceAccountID()) != 0 {
@@ -101,15 +105,9 @@ func httpOAuth2Complete(w http.ResponseWriter, r *http.Request, p *Plugin) {
 		return
 	}
 
-	instanceURL := config.GetConfig().GetConfluenceBaseURL()
-	if instanceURL == "" {
-		http.Error(w, "missing confluence base url", http.StatusInternalServerError)
-		return
-	}
-
 	isAdmin := IsAdmin(w, r)
 
-	cuser, mmuser, err := p.CompleteOAuth2(mattermostUserID, code, state, instanceURL, isAdmin)
+	cuser, mmuser, err := p.CompleteOAuth2(mattermostUserID, code, state, isAdmin)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -124,12 +122,13 @@ func httpOAuth2Complete(w http.ResponseWriter, r *http.Request, p *Plugin) {
 	})
 }
 
-func (p *Plugin) CompleteOAuth2(mattermostUserID, code, state string, instanceID string, isAdmin bool) (*types.ConfluenceUser, *model.User, error) {
+func (p *Plugin) CompleteOAuth2(mattermostUserID, code, state string, isAdmin bool) (*types.ConfluenceUser, *model.User, error) {
 	if mattermostUserID == "" || code == "" || state == "" {
 		return nil, nil, errors.New("missing user, code or state")
 	}
 
-	if err := store.VerifyOAuth2State(state); err != nil {
+	instanceID, err := store.VerifyOAuth2State(state)
+	if err != nil {
 		return nil, nil, errors.WithMessage(err, "missing stored state")
 	}
 
@@ -189,7 +188,7 @@ func (p *Plugin) getUserConnectURL(instanceID string, mattermostUserID string, i
 	if isAdmin {
 		state = fmt.Sprintf("%v_%v", state, AdminMattermostUserID)
 	}
-	if err = store.StoreOAuth2State(state); err != nil {
+	if err = store.StoreOAuth2State(state, instanceID); err != nil {
 		return "", err
 	}
 
@@ -206,7 +205,7 @@ func (p *Plugin) DisconnectUser(instanceURL string, mattermostUserID string) (*t
 }
 
 func (p *Plugin) disconnectUser(instanceID string, user *types.User) (*types.Connection, error) {
-	if user.InstanceURL != instanceID {
+	if !user.IsConnected(instanceID) {
 		return nil, errors.Wrapf(store.ErrNotFound, "user is not connected to %q", instanceID)
 	}
 
@@ -215,9 +214,7 @@ func (p *Plugin) disconnectUser(instanceID string, user *types.User) (*types.Con
 		return nil, err
 	}
 
-	if user.InstanceURL == instanceID {
-		user.InstanceURL = ""
-	}
+	user.RemoveInstance(instanceID)
 
 	if err = store.DeleteConnection(instanceID, user.MattermostUserID); err != nil && errors.Cause(err) != store.ErrNotFound {
 		return nil, err
@@ -238,7 +235,7 @@ func (p *Plugin) connectUser(instanceID, mattermostUserID string, connection *ty
 		}
 		user = types.NewUser(mattermostUserID)
 	}
-	user.InstanceURL = instanceID
+	user.AddInstance(instanceID)
 
 	if err = store.StoreConnection(instanceID, mattermostUserID, connection); err != nil {
 		return err
@@ -256,7 +253,7 @@ func (p *Plugin) connectUser(instanceID, mattermostUserID string, connection *ty
 		return err
 	}
 
-	if err = p.flowManager.StartCompletionWizard(mattermostUserID); err != nil {
+	if err = p.flowManager.StartCompletionWizard(mattermostUserID, instanceID); err != nil {
 		return err
 	}
 
@@ -306,8 +303,9 @@ func (p *Plugin) refreshAndStoreToken(connection *types.Connection, instanceID s
 }
 
 type UserConnectionInfo struct {
-	CanRunSubscribeCommand    bool `json:"can_run_subscribe_command"`
-	ServerVersionGreaterthan9 bool `json:"server_version_greater_than_9"`
+	CanRunSubscribeCommand    bool   `json:"can_run_subscribe_command"`
+	ServerVersionGreaterthan9 bool   `json:"server_version_greater_than_9"`
+	Message                   string `json:"message,omitempty"` // Why the user can not run the subscribe command
 }
 
 func httpGetUserInfo(w http.ResponseWriter, r *http.Request, p *Plugin) {
@@ -324,46 +322,18 @@ func httpGetUserInfo(w http.ResponseWriter, r *http.Request, p *Plugin) {
 		return
 	}
 
-	serverVersionGreaterThan9 := config.GetConfig().ServerVersionGreaterthan9
-
-	if !serverVersionGreaterThan9 {
-		info := &UserConnectionInfo{
-			CanRunSubscribeCommand:    util.IsSystemAdmin(mattermostUserID),
-			ServerVersionGreaterthan9: serverVersionGreaterThan9,
-		}
-		b, _ := json.Marshal(info)
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write(b)
-		return
-	}
-
-	instanceURL := config.GetConfig().GetConfluenceBaseURL()
-	if instanceURL == "" {
-		http.Error(w, "missing Confluence base url. Please run `/confluence install server`", http.StatusInternalServerError)
-		return
-	}
-
-	connection, err := store.LoadConnection(instanceURL, mattermostUserID)
+	channelID := r.URL.Query().Get("channel_id")
+	denied, err := checkSubscriptionPermission(mattermostUserID, channelID)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			info := &UserConnectionInfo{
-				CanRunSubscribeCommand:    false,
-				ServerVersionGreaterthan9: serverVersionGreaterThan9,
-			}
-			b, _ := json.Marshal(info)
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = w.Write(b)
-			return
-		}
-
-		p.client.Log.Error("Error getting client connection", "MattermostUserID", mattermostUserID, "error", err)
-		http.Error(w, "some error occurred checking user connection status", http.StatusInternalServerError)
+		p.client.Log.Error("Error checking the subscription permission", "MattermostUserID", mattermostUserID, "ChannelID", channelID, "error", err)
+		http.Error(w, "some error occurred checking the subscription permission", http.StatusInternalServerError)
 		return
 	}
 
 	info := &UserConnectionInfo{
-		CanRunSubscribeCommand:    len(connection.ConfluenceAccountID()) != 0,
-		ServerVersionGreaterthan9: serverVersionGreaterThan9,
+		CanRunSubscribeCommand:    denied == "",
+		ServerVersionGreaterthan9: isUserConnectionRequired(),
+		Message:                   denied,
 	}
 
 	b, _ := json.Marshal(info)
@@ -0,0 +1,83 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Encode returns the URL safe base64 encoding of the bytes.
+func Encode(encrypted []byte) string {
+	encoded := make([]byte, base64.URLEncoding.EncodedLen(len(encrypted)))
+	base64.URLEncoding.Encode(encoded, encrypted)
+	return string(encoded)
+}
+
+// Encrypt seals the bytes with AES-GCM using the secret, or returns them as they are when there is no secret.
+func Encrypt(plain, secret []byte) ([]byte, error) {
+	if len(secret) == 0 {
+		return plain, nil
+	}
+
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aesgcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := aesgcm.Seal(nil, nonce, plain, nil)
+	return append(nonce, sealed...), nil
+}
+
+// Decode returns the bytes of the URL safe base64 encoding.
+func Decode(encoded string) ([]byte, error) {
+	decoded := make([]byte, base64.URLEncoding.DecodedLen(len(encoded)))
+	n, err := base64.URLEncoding.Decode(decoded, []byte(encoded))
+	if err != nil {
+		return nil, err
+	}
+	return decoded[:n], nil
+}
+
+// Decrypt opens the bytes sealed by Encrypt with the same secret.
+func Decrypt(encrypted, secret []byte) ([]byte, error) {
+	if len(secret) == 0 {
+		return encrypted, nil
+	}
+
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := aesgcm.NonceSize()
+	if len(encrypted) < nonceSize {
+		return nil, errors.New("token too short")
+	}
+
+	nonce, encrypted := encrypted[:nonceSize], encrypted[nonceSize:]
+	plain, err := aesgcm.Open(nil, nonce, encrypted, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return plain, nil
+}
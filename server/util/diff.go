@@ -0,0 +1,60 @@
+package util
+
+import (
+	"slices"
+	"strings"
+)
+
+// LineDiff is the difference between two versions of a text, by line.
+type LineDiff struct {
+	Added   []string
+	Removed []string
+}
+
+// IsEmpty returns whether the two versions have the same lines.
+func (d *LineDiff) IsEmpty() bool {
+	return d == nil || (len(d.Added) == 0 && len(d.Removed) == 0)
+}
+
+// DiffLines returns the lines added to and removed from the previous version of a text, in the order they appear.
+// Blank lines are ignored and lines are compared after trimming their spaces.
+// Lines are matched by count rather than position, so a line that only moved is neither added nor removed,
+// which keeps the diff linear in the size of the text.
+func DiffLines(previous, current string) *LineDiff {
+	previousLines := splitLines(previous)
+	currentLines := splitLines(current)
+
+	remaining := map[string]int{}
+	for _, line := range previousLines {
+		remaining[line]++
+	}
+	diff := &LineDiff{}
+	for _, line := range currentLines {
+		if remaining[line] > 0 {
+			remaining[line]--
+			continue
+		}
+		diff.Added = append(diff.Added, line)
+	}
+
+	// The lines left are the ones no current line matched, the last of their occurrences being the removed ones.
+	for i := len(previousLines) - 1; i >= 0; i-- {
+		line := previousLines[i]
+		if remaining[line] > 0 {
+			remaining[line]--
+			diff.Removed = append(diff.Removed, line)
+		}
+	}
+	slices.Reverse(diff.Removed)
+	return diff
+}
+
+func splitLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -1,8 +1,12 @@
 package types
 
 type User struct {
-	MattermostUserID string `json:"mattermost_user_id"`
-	InstanceURL      string `json:"instance_url,omitempty"`
+	MattermostUserID   string   `json:"mattermost_user_id"`
+	ConnectedInstances []string `json:"connected_instances,omitempty"`
+
+	// InstanceURL is the only instance a user could connect to before several instances were supported.
+	// It is moved to ConnectedInstances when the user is loaded.
+	InstanceURL string `json:"instance_url,omitempty"`
 }
 
 type ConfluenceUser struct {
@@ -43,7 +47,41 @@ func NewUser(mattermostUserID string) *User {
 
 func (user *User) AsConfigMap() map[string]interface{} {
 	return map[string]interface{}{
-		"mattermost_user_id": user.MattermostUserID,
-		"instance_url":       user.InstanceURL,
+		"mattermost_user_id":  user.MattermostUserID,
+		"connected_instances": user.ConnectedInstances,
+	}
+}
+
+func (user *User) IsConnected(instanceURL string) bool {
+	for _, connected := range user.ConnectedInstances {
+		if connected == instanceURL {
+			return true
+		}
+	}
+	return false
+}
+
+func (user *User) AddInstance(instanceURL string) {
+	if !user.IsConnected(instanceURL) {
+		user.ConnectedInstances = append(user.ConnectedInstances, instanceURL)
+	}
+}
+
+func (user *User) RemoveInstance(instanceURL string) {
+	connected := user.ConnectedInstances[:0]
+	for _, url := range user.ConnectedInstances {
+		if url != instanceURL {
+			connected = append(connected, url)
+		}
+	}
+	user.ConnectedInstances = connected
+}
+
+// MigrateInstanceURL moves the legacy single instance of the user into the connected instances.
+func (user *User) MigrateInstanceURL() {
+	if user.InstanceURL == "" {
+		return
 	}
+	user.AddInstance(user.InstanceURL)
+	user.InstanceURL = ""
 }
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DigestHourly = "hourly"
+	DigestDaily  = "daily"
+)
+
+// Digest is how a channel receives its notifications when they are grouped in a digest rather than posted as they come,
+// every hour or every day at a time in a time zone.
+type Digest struct {
+	Mode     string `json:"mode"`
+	Time     string `json:"time,omitempty"`
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// ParseDigest returns the digest of the mode, and for daily digests of a `HH:MM` time in the given IANA time zone, UTC when empty.
+func ParseDigest(mode, clock, timeZone string) (*Digest, error) {
+	digest := &Digest{Mode: mode}
+	if mode == DigestDaily {
+		digest.Time, digest.TimeZone = clock, timeZone
+	}
+	if err := digest.IsValid(); err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
+
+func (d *Digest) IsValid() error {
+	switch d.Mode {
+	case DigestHourly:
+		return nil
+	case DigestDaily:
+		if _, err := time.Parse(quietHoursClockFormat, d.Time); err != nil {
+			return fmt.Errorf("invalid digest time %q, expected HH:MM", d.Time)
+		}
+		if _, err := time.LoadLocation(d.TimeZone); err != nil {
+			return fmt.Errorf("invalid time zone %q", d.TimeZone)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid digest mode %q, expected %s or %s", d.Mode, DigestHourly, DigestDaily)
+	}
+}
+
+// NextDelivery returns when the digest holding events since the given time is delivered,
+// the next full hour for hourly digests and the next time of the day for daily ones.
+// Nil or invalid digests are delivered right away, so notifications are never held back by a bad setting.
+func (d *Digest) NextDelivery(since time.Time) time.Time {
+	if d == nil {
+		return since
+	}
+
+	switch d.Mode {
+	case DigestHourly:
+		return since.Truncate(time.Hour).Add(time.Hour)
+	case DigestDaily:
+		location, err := time.LoadLocation(d.TimeZone)
+		if err != nil {
+			return since
+		}
+		clock, err := time.Parse(quietHoursClockFormat, d.Time)
+		if err != nil {
+			return since
+		}
+		local := since.In(location)
+		next := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, location)
+		if !next.After(local) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	default:
+		return since
+	}
+}
+
+func (d *Digest) String() string {
+	if d.Mode == DigestDaily {
+		timeZone := d.TimeZone
+		if timeZone == "" {
+			timeZone = "UTC"
+		}
+		return fmt.Sprintf("daily at %s %s", d.Time, timeZone)
+	}
+	return d.Mode
+}
+
+// DigestEvent is a notification held for the digest of a channel.
+type DigestEvent struct {
+	EventType string `json:"eventType"`
+	SpaceKey  string `json:"spaceKey"`
+	SpaceName string `json:"spaceName,omitempty"`
+	SpaceURL  string `json:"spaceURL,omitempty"`
+	PageID    string `json:"pageID,omitempty"`
+	Title     string `json:"title,omitempty"`
+	URL       string `json:"url,omitempty"`
+	// Message is the text of the notification that would have been posted, shown for the latest event of each page.
+	Message string `json:"message"`
+	// CreatedAt is when the event was received, in milliseconds since the epoch.
+	CreatedAt int64 `json:"createdAt"`
+}
+
+// DigestQueue holds the notifications of a channel waiting for its digest.
+type DigestQueue struct {
+	Events []DigestEvent `json:"events"`
+	// Dropped counts the events left out once the queue is full.
+	Dropped int `json:"dropped,omitempty"`
+}
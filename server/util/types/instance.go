@@ -0,0 +1,16 @@
+package types
+
+// Instance is a Confluence Server or Data Center instance installed with `/confluence install server`.
+// The URL of the instance is also its ID, so connections and subscriptions are keyed by it.
+type Instance struct {
+	URL                       string `json:"url"`
+	OAuthClientID             string `json:"oauth_client_id,omitempty"`
+	OAuthClientSecret         string `json:"oauth_client_secret,omitempty"`
+	AdminAPIToken             string `json:"admin_api_token,omitempty"`
+	WebhookSecret             string `json:"webhook_secret,omitempty"`
+	ServerVersionGreaterthan9 bool   `json:"server_version_greater_than_9,omitempty"`
+}
+
+func (i *Instance) IsOAuthConfigured() bool {
+	return i.OAuthClientID != "" && i.OAuthClientSecret != ""
+}
@@ -0,0 +1,16 @@
+package types
+
+// Preset is a named set of subscription settings defined by a system administrator with `/confluence preset add`,
+// which subscriptions can start from. The space settings only apply to space subscriptions.
+// Presets hold no notification templates, which are set per subscription with `/confluence template`,
+// so applying a preset never overwrites the templates of its subscriptions.
+type Preset struct {
+	Name             string   `json:"name"`
+	Events           []string `json:"events"`
+	IgnoreMinorEdits bool     `json:"ignoreMinorEdits,omitempty"`
+	ExcludeAuthors   []string `json:"excludeAuthors,omitempty"`
+	IncludeAuthors   []string `json:"includeAuthors,omitempty"`
+	ContentType      string   `json:"contentType,omitempty"`
+	IncludeLabels    []string `json:"includeLabels,omitempty"`
+	ExcludeLabels    []string `json:"excludeLabels,omitempty"`
+}
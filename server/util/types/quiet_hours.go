@@ -0,0 +1,90 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const quietHoursClockFormat = "15:04"
+
+// QuietHours is a daily window during which notifications are not posted, like `22:00-07:00` in a time zone.
+// A window whose end is before its start runs over midnight.
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// ParseQuietHours returns the quiet hours of a `HH:MM-HH:MM` window in the given IANA time zone, UTC when empty.
+func ParseQuietHours(window, timeZone string) (*QuietHours, error) {
+	start, end, found := strings.Cut(window, "-")
+	if !found {
+		return nil, fmt.Errorf("invalid quiet hours %q, expected a window like 22:00-07:00", window)
+	}
+
+	quietHours := &QuietHours{
+		Start:    strings.TrimSpace(start),
+		End:      strings.TrimSpace(end),
+		TimeZone: timeZone,
+	}
+	if err := quietHours.IsValid(); err != nil {
+		return nil, err
+	}
+	return quietHours, nil
+}
+
+func (q *QuietHours) IsValid() error {
+	if _, err := time.Parse(quietHoursClockFormat, q.Start); err != nil {
+		return fmt.Errorf("invalid quiet hours start %q, expected HH:MM", q.Start)
+	}
+	if _, err := time.Parse(quietHoursClockFormat, q.End); err != nil {
+		return fmt.Errorf("invalid quiet hours end %q, expected HH:MM", q.End)
+	}
+	if q.Start == q.End {
+		return errors.New("quiet hours can not start and end at the same time")
+	}
+	if _, err := time.LoadLocation(q.TimeZone); err != nil {
+		return fmt.Errorf("invalid time zone %q", q.TimeZone)
+	}
+	return nil
+}
+
+// Contains reports whether the given time falls within the quiet hours.
+// Nil or invalid quiet hours contain no time, so notifications are never lost to a bad setting.
+func (q *QuietHours) Contains(t time.Time) bool {
+	if q == nil {
+		return false
+	}
+
+	location, err := time.LoadLocation(q.TimeZone)
+	if err != nil {
+		return false
+	}
+	start, err := time.Parse(quietHoursClockFormat, q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(quietHoursClockFormat, q.End)
+	if err != nil {
+		return false
+	}
+
+	local := t.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	if startMinute < endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+	return minute >= startMinute || minute < endMinute
+}
+
+func (q *QuietHours) String() string {
+	timeZone := q.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	return fmt.Sprintf("%s-%s %s", q.Start, q.End, timeZone)
+}
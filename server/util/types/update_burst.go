@@ -0,0 +1,11 @@
+package types
+
+// UpdateBurst is the notification of the updates of a page in a channel within the debounce window,
+// edited on each update rather than followed by new posts.
+type UpdateBurst struct {
+	PostID  string   `json:"postID"`
+	Count   int      `json:"count"`
+	Authors []string `json:"authors,omitempty"`
+	// StartedAt is when the first update of the burst was notified, in milliseconds since the epoch.
+	StartedAt int64 `json:"startedAt"`
+}
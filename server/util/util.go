@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"path"
 	"regexp"
+	"slices"
 	"strings"
 
 	html "github.com/levigross/exp-html"
@@ -64,6 +66,43 @@ func SplitArgs(s string) ([]string, error) {
 	return cleanedArgs[0:count], nil
 }
 
+// ParseFlags splits arguments returned by SplitArgs into positional arguments and `--flag value` or `--flag=value` flags.
+// The given boolean flags take no value and are set to "true" when present.
+func ParseFlags(args []string, boolFlags ...string) ([]string, map[string]string, error) {
+	var positional []string
+	flags := map[string]string{}
+	for i := 0; i < len(args); i++ {
+		name, found := strings.CutPrefix(args[i], "--")
+		if !found {
+			positional = append(positional, args[i])
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(name, "=")
+		if name == "" {
+			return nil, nil, fmt.Errorf("invalid flag %q", args[i])
+		}
+		if slices.Contains(boolFlags, name) {
+			if hasValue {
+				return nil, nil, fmt.Errorf("flag --%s takes no value", name)
+			}
+			flags[name] = "true"
+			continue
+		}
+
+		// A quoted value after `--flag=` is split into the next argument.
+		if !hasValue || value == "" {
+			if i+1 >= len(args) || strings.HasPrefix(args[i+1], "--") {
+				return nil, nil, fmt.Errorf("flag --%s needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		flags[name] = value
+	}
+	return positional, flags, nil
+}
+
 func GetPluginKey() string {
 	var regexpNonAlnum = regexp.MustCompile("[^a-zA-Z0-9]+")
 	return "mattermost_" + regexpNonAlnum.ReplaceAllString(GetSiteURL(), "_")
@@ -89,8 +128,37 @@ func GetAtlassianConnectURLPath() string {
 	return "/atlassian-connect.json?secret=" + url.QueryEscape(config.GetConfig().Secret)
 }
 
-func GetConfluenceServerWebhookURLPath() string {
-	return "/server/webhook?secret=" + url.QueryEscape(config.GetConfig().Secret)
+// NormalizeConfluenceURL returns the URL with a scheme and without a trailing slash.
+// A URL without a scheme, like "confluence.example.com/wiki", defaults to https.
+func NormalizeConfluenceURL(confluenceURL string) (string, error) {
+	u, err := url.Parse(confluenceURL)
+	if err != nil {
+		return "", fmt.Errorf("could not parse confluence url: %w", err)
+	}
+
+	// If the parsed URL does not contain a host, trying to extract the host from the path
+	if u.Host == "" {
+		ss := strings.Split(u.Path, "/")
+		if len(ss) > 0 && ss[0] != "" {
+			u.Host = ss[0]
+			u.Path = path.Join(ss[1:]...)
+		}
+		u, err = url.Parse(u.String())
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// If the URL still lacks a hostname, return an error
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid URL, no hostname: %q", confluenceURL)
+	}
+	if u.Scheme == "" {
+		u.Scheme = "https"
+	}
+
+	confluenceURL = strings.TrimSuffix(u.String(), "/")
+	return confluenceURL, nil
 }
 
 func IsSystemAdmin(userID string) bool {
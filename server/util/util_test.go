@@ -51,3 +51,99 @@ func TestSplitArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFlags(t *testing.T) {
+	for name, val := range map[string]struct {
+		command            string
+		expectedPositional []string
+		expectedFlags      map[string]string
+		errMessage         string
+	}{
+		"flags with values": {
+			command:            "/confluence subscribe space ENG --name \"Eng docs\" --events page_created,comment_created",
+			expectedPositional: []string{"/confluence", "subscribe", "space", "ENG"},
+			expectedFlags:      map[string]string{"name": "Eng docs", "events": "page_created,comment_created"},
+		},
+		"flags with equal signs": {
+			command:            "/confluence subscribe page 1234 --name=\"Release notes\" --events=page_updated",
+			expectedPositional: []string{"/confluence", "subscribe", "page", "1234"},
+			expectedFlags:      map[string]string{"name": "Release notes", "events": "page_updated"},
+		},
+		"boolean flag": {
+			command:            "/confluence subscribe page 1234 --ignore-minor-edits --name docs",
+			expectedPositional: []string{"/confluence", "subscribe", "page", "1234"},
+			expectedFlags:      map[string]string{"ignore-minor-edits": "true", "name": "docs"},
+		},
+		"missing value": {
+			command:    "/confluence subscribe page 1234 --name",
+			errMessage: "flag --name needs a value",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			args, err := SplitArgs(val.command)
+			assert.Nil(t, err)
+
+			positional, flags, err := ParseFlags(args, "ignore-minor-edits")
+			if val.errMessage != "" {
+				assert.EqualError(t, err, val.errMessage)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, val.expectedPositional, positional)
+			assert.Equal(t, val.expectedFlags, flags)
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	for name, val := range map[string]struct {
+		previous        string
+		current         string
+		expectedAdded   []string
+		expectedRemoved []string
+	}{
+		"same text": {
+			previous: "\nIntroduction\nScope",
+			current:  "\nIntroduction\n\n  Scope  ",
+		},
+		"added and removed lines": {
+			previous:        "\nIntroduction\nScope\nOut of scope",
+			current:         "\nIntroduction\nGoals\nScope",
+			expectedAdded:   []string{"Goals"},
+			expectedRemoved: []string{"Out of scope"},
+		},
+		"repeated lines are matched by count": {
+			previous:        "\nTODO\nTODO\nDone",
+			current:         "\nTODO\nDone\nDone",
+			expectedAdded:   []string{"Done"},
+			expectedRemoved: []string{"TODO"},
+		},
+		"moved line": {
+			previous: "\nFirst\nSecond",
+			current:  "\nSecond\nFirst",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			diff := DiffLines(val.previous, val.current)
+			assert.Equal(t, val.expectedAdded, diff.Added)
+			assert.Equal(t, val.expectedRemoved, diff.Removed)
+			assert.Equal(t, len(val.expectedAdded)+len(val.expectedRemoved) == 0, diff.IsEmpty())
+		})
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	encrypted, err := Encrypt([]byte("webhook secret"), secret)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "webhook secret")
+
+	decoded, err := Decode(Encode(encrypted))
+	assert.NoError(t, err)
+	plain, err := Decrypt(decoded, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, "webhook secret", string(plain))
+
+	_, err = Decrypt(decoded, []byte("fedcba9876543210fedcba9876543210"))
+	assert.Error(t, err)
+}
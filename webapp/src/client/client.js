@@ -32,8 +32,13 @@ export default class Client {
         return this.doGet(url);
     };
 
-    getSubscriptionAccess = () => {
-        const url = `${this.pluginApiUrl}/user-connection-info`;
+    getPresets = () => {
+        const url = `${this.pluginApiUrl}/presets`;
+        return this.doGet(url);
+    };
+
+    getSubscriptionAccess = (channelID) => {
+        const url = `${this.pluginApiUrl}/user-connection-info?channel_id=${channelID}`;
         return this.doGet(url);
     };
 
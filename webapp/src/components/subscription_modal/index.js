@@ -2,7 +2,7 @@ import {connect} from 'react-redux';
 import {bindActionCreators} from 'redux';
 import {getCurrentChannelId} from 'mattermost-redux/selectors/entities/common';
 
-import {closeSubscriptionModal, saveChannelSubscription, editChannelSubscription} from '../../actions';
+import {closeSubscriptionModal, saveChannelSubscription, editChannelSubscription, getPresets} from '../../actions';
 import Selectors from '../../selectors';
 
 import SubscriptionModal from './subscription_modal';
@@ -19,6 +19,7 @@ const mapDispatchToProps = (dispatch) => bindActionCreators({
     close: closeSubscriptionModal,
     saveChannelSubscription,
     editChannelSubscription,
+    getPresets,
 }, dispatch);
 
 export default connect(mapStateToProps, mapDispatchToProps)(SubscriptionModal);
@@ -10,13 +10,27 @@ import Constants from '../../constants';
 import ConfluenceField from '../confluence_field';
 import Validator from '../validator';
 
+const CQL_SUBSCRIPTION = 'cql_subscription';
+const ALL_SPACES_SUBSCRIPTION = 'all_spaces_subscription';
+
 const initialState = {
     alias: '',
     baseURL: '',
     spaceKey: '',
     pageID: '',
+    cql: '',
+    excludeSpaces: '',
+    excludePersonalSpaces: false,
+    includeLabels: '',
+    excludeLabels: '',
+    contentType: Constants.CONTENT_TYPE[0],
+    ignoreMinorEdits: false,
+    excludeAuthors: '',
+    includeAuthors: '',
     subscriptionType: Constants.SUBSCRIPTION_TYPE[0],
     events: Constants.CONFLUENCE_EVENTS,
+    preset: null,
+    presets: [],
     error: '',
     saving: false,
 };
@@ -30,6 +44,7 @@ export default class SubscriptionModal extends React.PureComponent {
         saveChannelSubscription: PropTypes.func.isRequired,
         currentChannelID: PropTypes.string.isRequired,
         editChannelSubscription: PropTypes.func.isRequired,
+        getPresets: PropTypes.func,
     };
 
     static defaultProps = {
@@ -47,11 +62,27 @@ export default class SubscriptionModal extends React.PureComponent {
         if (this.props.subscription !== prevProps.subscription) {
             this.setData();
         }
+        if (isVisible(this.props) && !isVisible(prevProps)) {
+            this.loadPresets();
+        }
     }
 
+    loadPresets = async () => {
+        if (!this.props.getPresets) {
+            return;
+        }
+        const {data} = await this.props.getPresets();
+        if (data) {
+            this.setState({
+                presets: data.map((preset) => ({value: preset.name, label: preset.name, preset})),
+            });
+        }
+    };
+
     setData = () => {
         const {
-            alias, baseURL, spaceKey, events, pageID,
+            alias, baseURL, spaceKey, events, pageID, cql, includeLabels, excludeLabels, contentType, subscriptionType,
+            ignoreMinorEdits, excludeAuthors, includeAuthors, preset, excludeSpaces, excludePersonalSpaces,
         } = this.props.subscription;
         if (alias) {
             this.setState({
@@ -59,8 +90,18 @@ export default class SubscriptionModal extends React.PureComponent {
                 baseURL,
                 spaceKey,
                 pageID,
+                cql: cql || '',
+                excludeSpaces: excludeSpaces ? excludeSpaces.join(', ') : '',
+                excludePersonalSpaces: Boolean(excludePersonalSpaces),
+                includeLabels: includeLabels ? includeLabels.join(', ') : '',
+                excludeLabels: excludeLabels ? excludeLabels.join(', ') : '',
+                contentType: Constants.CONTENT_TYPE.find((option) => option.value === contentType) || Constants.CONTENT_TYPE[0],
+                ignoreMinorEdits: Boolean(ignoreMinorEdits),
+                excludeAuthors: excludeAuthors ? excludeAuthors.join(', ') : '',
+                includeAuthors: includeAuthors ? includeAuthors.join(', ') : '',
                 events: Constants.CONFLUENCE_EVENTS.filter((option) => events.includes(option.value)),
-                subscriptionType: pageID ? Constants.SUBSCRIPTION_TYPE[1] : Constants.SUBSCRIPTION_TYPE[0],
+                preset: preset ? {value: preset, label: preset} : null,
+                subscriptionType: Constants.SUBSCRIPTION_TYPE.find((option) => option.value === subscriptionType) || (pageID ? Constants.SUBSCRIPTION_TYPE[1] : Constants.SUBSCRIPTION_TYPE[0]),
             });
         }
     };
@@ -96,9 +137,92 @@ export default class SubscriptionModal extends React.PureComponent {
         });
     };
 
+    handleCQL = (e) => {
+        this.setState({
+            cql: e.target.value,
+        });
+    };
+
+    handleExcludeSpaces = (e) => {
+        this.setState({
+            excludeSpaces: e.target.value,
+        });
+    };
+
+    handleExcludePersonalSpaces = (e) => {
+        this.setState({
+            excludePersonalSpaces: e.target.checked,
+        });
+    };
+
+    handleContentType = (contentType) => {
+        this.setState({
+            contentType,
+            preset: null,
+        });
+    };
+
+    handleIncludeLabels = (e) => {
+        this.setState({
+            includeLabels: e.target.value,
+            preset: null,
+        });
+    };
+
+    handleExcludeLabels = (e) => {
+        this.setState({
+            excludeLabels: e.target.value,
+            preset: null,
+        });
+    };
+
+    handleIgnoreMinorEdits = (e) => {
+        this.setState({
+            ignoreMinorEdits: e.target.checked,
+            preset: null,
+        });
+    };
+
+    handleExcludeAuthors = (e) => {
+        this.setState({
+            excludeAuthors: e.target.value,
+            preset: null,
+        });
+    };
+
+    handleIncludeAuthors = (e) => {
+        this.setState({
+            includeAuthors: e.target.value,
+            preset: null,
+        });
+    };
+
     handleEvents = (events) => {
         this.setState({
             events,
+            preset: null,
+        });
+    };
+
+    // handlePreset fills the events and filters of the chosen preset. Changing one of them afterwards
+    // unlinks the subscription from the preset, so later edits of the preset do not override it.
+    handlePreset = (option) => {
+        if (!option) {
+            this.setState({
+                preset: null,
+            });
+            return;
+        }
+        const {preset} = option;
+        this.setState({
+            preset: {value: preset.name, label: preset.name},
+            events: Constants.CONFLUENCE_EVENTS.filter((event) => preset.events.includes(event.value)),
+            ignoreMinorEdits: Boolean(preset.ignoreMinorEdits),
+            excludeAuthors: preset.excludeAuthors ? preset.excludeAuthors.join(', ') : '',
+            includeAuthors: preset.includeAuthors ? preset.includeAuthors.join(', ') : '',
+            includeLabels: preset.includeLabels ? preset.includeLabels.join(', ') : '',
+            excludeLabels: preset.excludeLabels ? preset.excludeLabels.join(', ') : '',
+            contentType: Constants.CONTENT_TYPE.find((type) => type.value === preset.contentType) || Constants.CONTENT_TYPE[0],
         });
     };
 
@@ -109,7 +233,13 @@ export default class SubscriptionModal extends React.PureComponent {
         this.setState({
             subscriptionType,
             pageID: '',
+            cql: '',
+            excludeSpaces: '',
+            excludePersonalSpaces: false,
             spaceKey: '',
+            includeLabels: '',
+            excludeLabels: '',
+            contentType: Constants.CONTENT_TYPE[0],
         });
     };
 
@@ -118,7 +248,8 @@ export default class SubscriptionModal extends React.PureComponent {
             return;
         }
         const {
-            alias, baseURL, spaceKey, events, pageID, subscriptionType,
+            alias, baseURL, spaceKey, events, pageID, cql, subscriptionType, includeLabels, excludeLabels, contentType,
+            ignoreMinorEdits, excludeAuthors, includeAuthors, preset, excludeSpaces, excludePersonalSpaces,
         } = this.state;
         const {
             currentChannelID, subscription, saveChannelSubscription, editChannelSubscription,
@@ -131,6 +262,15 @@ export default class SubscriptionModal extends React.PureComponent {
             pageID: pageID ? pageID.trim() : '',
             channelID: currentChannelID,
             events: events ? events.map((event) => event.value) : [],
+            includeLabels: splitList(includeLabels),
+            excludeLabels: splitList(excludeLabels),
+            contentType: contentType.value,
+            ignoreMinorEdits,
+            excludeAuthors: splitList(excludeAuthors),
+            includeAuthors: splitList(includeAuthors),
+            ...(preset ? {preset: preset.value} : {}),
+            ...(subscriptionType.value === CQL_SUBSCRIPTION ? {cql: cql.trim()} : {}),
+            ...(subscriptionType.value === ALL_SPACES_SUBSCRIPTION ? {excludeSpaces: splitList(excludeSpaces), excludePersonalSpaces} : {}),
         };
         this.setState({
             saving: true,
@@ -157,7 +297,72 @@ export default class SubscriptionModal extends React.PureComponent {
         const {visibility, subscription} = this.props;
         const editSubscription = Boolean(subscription && subscription.alias);
         const isModalVisible = Boolean(visibility || editSubscription);
-        const {error, saving, subscriptionType} = this.state;
+        const {error, saving, subscriptionType, presets} = this.state;
+        let presetField = null;
+        if (presets.length) {
+            presetField = (
+                <ConfluenceField
+                    isSearchable={false}
+                    isMulti={false}
+                    isClearable={true}
+                    label={'Preset'}
+                    name={'preset'}
+                    fieldType={'dropDown'}
+                    required={false}
+                    theme={this.props.theme}
+                    placeholder={'Fill the events and filters from a preset.'}
+                    options={presets}
+                    value={this.state.preset}
+                    addValidation={this.validator.addValidation}
+                    removeValidation={this.validator.removeValidation}
+                    onChange={this.handlePreset}
+                />
+            );
+        }
+        let contentTypeField = (
+            <ConfluenceField
+                isSearchable={false}
+                isMulti={false}
+                label={'Content Type'}
+                name={'contentType'}
+                fieldType={'dropDown'}
+                required={true}
+                theme={this.props.theme}
+                options={Constants.CONTENT_TYPE}
+                value={this.state.contentType}
+                addValidation={this.validator.addValidation}
+                removeValidation={this.validator.removeValidation}
+                onChange={this.handleContentType}
+            />
+        );
+        let labelFields = (
+            <div style={getStyle.innerFields}>
+                <ConfluenceField
+                    formGroupStyle={getStyle.firstField}
+                    label={'Include Labels'}
+                    type={'text'}
+                    fieldType={'input'}
+                    required={false}
+                    placeholder={'Only notify for content with one of these labels.'}
+                    value={this.state.includeLabels}
+                    addValidation={this.validator.addValidation}
+                    removeValidation={this.validator.removeValidation}
+                    onChange={this.handleIncludeLabels}
+                />
+                <ConfluenceField
+                    formGroupStyle={getStyle.typeValue}
+                    label={'Exclude Labels'}
+                    type={'text'}
+                    fieldType={'input'}
+                    required={false}
+                    placeholder={'Never notify for content with these labels.'}
+                    value={this.state.excludeLabels}
+                    addValidation={this.validator.addValidation}
+                    removeValidation={this.validator.removeValidation}
+                    onChange={this.handleExcludeLabels}
+                />
+            </div>
+        );
         let typeField = (
             <ConfluenceField
                 formGroupStyle={getStyle.typeValue}
@@ -166,14 +371,65 @@ export default class SubscriptionModal extends React.PureComponent {
                 type={'text'}
                 fieldType={'input'}
                 required={true}
-                placeholder={'Enter the Confluence Space Key.'}
+                placeholder={'Enter the Confluence Space Key or a space URL.'}
                 value={this.state.spaceKey}
                 addValidation={this.validator.addValidation}
                 removeValidation={this.validator.removeValidation}
                 onChange={this.handleSpaceKey}
             />
         );
-        if (subscriptionType === Constants.SUBSCRIPTION_TYPE[1]) {
+        let personalSpacesField = null;
+        if (subscriptionType.value === ALL_SPACES_SUBSCRIPTION) {
+            contentTypeField = null;
+            labelFields = null;
+            typeField = (
+                <ConfluenceField
+                    formGroupStyle={getStyle.typeValue}
+                    formControlStyle={getStyle.typeFormControl}
+                    label={'Exclude Spaces'}
+                    type={'text'}
+                    fieldType={'input'}
+                    required={false}
+                    placeholder={'Keys of the spaces to skip, comma separated.'}
+                    value={this.state.excludeSpaces}
+                    addValidation={this.validator.addValidation}
+                    removeValidation={this.validator.removeValidation}
+                    onChange={this.handleExcludeSpaces}
+                />
+            );
+            personalSpacesField = (
+                <div className='checkbox'>
+                    <label>
+                        <input
+                            type='checkbox'
+                            checked={this.state.excludePersonalSpaces}
+                            onChange={this.handleExcludePersonalSpaces}
+                        />
+                        {'Exclude personal spaces'}
+                    </label>
+                </div>
+            );
+        } else if (subscriptionType.value === CQL_SUBSCRIPTION) {
+            contentTypeField = null;
+            labelFields = null;
+            typeField = (
+                <ConfluenceField
+                    formGroupStyle={getStyle.typeValue}
+                    formControlStyle={getStyle.typeFormControl}
+                    label={'CQL Query'}
+                    type={'text'}
+                    fieldType={'input'}
+                    required={true}
+                    placeholder={'Enter a CQL query, like type = page and label = incident.'}
+                    value={this.state.cql}
+                    addValidation={this.validator.addValidation}
+                    removeValidation={this.validator.removeValidation}
+                    onChange={this.handleCQL}
+                />
+            );
+        } else if (subscriptionType !== Constants.SUBSCRIPTION_TYPE[0]) {
+            contentTypeField = null;
+            labelFields = null;
             typeField = (
                 <ConfluenceField
                     formGroupStyle={getStyle.typeValue}
@@ -182,7 +438,7 @@ export default class SubscriptionModal extends React.PureComponent {
                     type={'text'}
                     fieldType={'input'}
                     required={true}
-                    placeholder={'Enter the page id.'}
+                    placeholder={'Enter the page id or a page URL.'}
                     value={this.state.pageID}
                     addValidation={this.validator.addValidation}
                     removeValidation={this.validator.removeValidation}
@@ -259,6 +515,10 @@ export default class SubscriptionModal extends React.PureComponent {
                             onChange={this.handleBaseURLChange}
                         />
                         {innerFields}
+                        {personalSpacesField}
+                        {presetField}
+                        {contentTypeField}
+                        {labelFields}
                         <ConfluenceField
                             isMulti={true}
                             label={'Events'}
@@ -272,6 +532,42 @@ export default class SubscriptionModal extends React.PureComponent {
                             removeValidation={this.validator.removeValidation}
                             onChange={this.handleEvents}
                         />
+                        <div style={getStyle.innerFields}>
+                            <ConfluenceField
+                                formGroupStyle={getStyle.firstField}
+                                label={'Exclude Authors'}
+                                type={'text'}
+                                fieldType={'input'}
+                                required={false}
+                                placeholder={'Usernames or user keys to ignore.'}
+                                value={this.state.excludeAuthors}
+                                addValidation={this.validator.addValidation}
+                                removeValidation={this.validator.removeValidation}
+                                onChange={this.handleExcludeAuthors}
+                            />
+                            <ConfluenceField
+                                formGroupStyle={getStyle.typeValue}
+                                label={'Only These Authors'}
+                                type={'text'}
+                                fieldType={'input'}
+                                required={false}
+                                placeholder={'Usernames or user keys to notify for.'}
+                                value={this.state.includeAuthors}
+                                addValidation={this.validator.addValidation}
+                                removeValidation={this.validator.removeValidation}
+                                onChange={this.handleIncludeAuthors}
+                            />
+                        </div>
+                        <div className='checkbox'>
+                            <label>
+                                <input
+                                    type='checkbox'
+                                    checked={this.state.ignoreMinorEdits}
+                                    onChange={this.handleIgnoreMinorEdits}
+                                />
+                                {'Ignore minor edits'}
+                            </label>
+                        </div>
                         {createError}
                     </div>
                 </Modal.Body>
@@ -298,6 +594,10 @@ export default class SubscriptionModal extends React.PureComponent {
     }
 }
 
+const isVisible = (props) => Boolean(props.visibility || (props.subscription && props.subscription.alias));
+
+const splitList = (list) => (list ? list.split(',').map((item) => item.trim()).filter(Boolean) : []);
+
 const getStyle = {
     innerFields: {
         display: 'flex',
@@ -309,6 +609,10 @@ const getStyle = {
     typeValue: {
         flex: '1',
     },
+    firstField: {
+        flex: '1',
+        marginRight: '20px',
+    },
     typeFormControl: {
         height: '38px',
     },
@@ -57,6 +57,12 @@ describe('components/ChannelSettingsModal', () => {
             events: Constants.CONFLUENCE_EVENTS.map((event) => event.value),
             channelID: 'abcabcabcabcabc',
             pageID: '',
+            includeLabels: [],
+            excludeLabels: [],
+            contentType: 'both',
+            ignoreMinorEdits: false,
+            excludeAuthors: [],
+            includeAuthors: [],
             subscriptionType: 'space_subscription',
         });
 
@@ -98,6 +104,12 @@ describe('components/ChannelSettingsModal', () => {
             events: Constants.CONFLUENCE_EVENTS.map((event) => event.value),
             channelID: 'abcabcabcabcabc',
             pageID: '',
+            includeLabels: [],
+            excludeLabels: [],
+            contentType: 'both',
+            ignoreMinorEdits: false,
+            excludeAuthors: [],
+            includeAuthors: [],
             subscriptionType: 'space_subscription',
         });
         expect(props.saveChannelSubscription).not.toHaveBeenCalled();
@@ -130,6 +142,12 @@ describe('components/ChannelSettingsModal', () => {
             events: Constants.CONFLUENCE_EVENTS.map((event) => event.value),
             channelID: 'abcabcabcabcabc',
             pageID: '1234',
+            includeLabels: [],
+            excludeLabels: [],
+            contentType: 'both',
+            ignoreMinorEdits: false,
+            excludeAuthors: [],
+            includeAuthors: [],
             subscriptionType: 'page_subscription',
         });
 
@@ -171,6 +189,12 @@ describe('components/ChannelSettingsModal', () => {
             events: Constants.CONFLUENCE_EVENTS.map((event) => event.value),
             channelID: 'abcabcabcabcabc',
             pageID: '1234',
+            includeLabels: [],
+            excludeLabels: [],
+            contentType: 'both',
+            ignoreMinorEdits: false,
+            excludeAuthors: [],
+            includeAuthors: [],
             subscriptionType: 'page_subscription',
         });
         expect(props.saveChannelSubscription).not.toHaveBeenCalled();
@@ -203,9 +227,171 @@ describe('components/ChannelSettingsModal', () => {
             events: Constants.CONFLUENCE_EVENTS.map((event) => event.value),
             channelID: 'abcabcabcabcabc',
             pageID: '',
+            includeLabels: [],
+            excludeLabels: [],
+            contentType: 'both',
+            ignoreMinorEdits: false,
+            excludeAuthors: [],
+            includeAuthors: [],
             subscriptionType: 'space_subscription',
         });
 
         expect(props.editChannelSubscription).not.toHaveBeenCalled();
     });
+
+    test('space subscription with label filters', async () => {
+        const props = {
+            ...baseProps,
+            visibility: true,
+        };
+        const wrapper = shallow(
+            <SubscriptionModal {...props}/>,
+        );
+        wrapper.setState({
+            alias: 'Abc',
+            baseURL: 'https://test.com',
+            spaceKey: 'test',
+            events: Constants.CONFLUENCE_EVENTS,
+            error: '',
+            saving: false,
+            pageID: '',
+            includeLabels: 'runbook, release-notes ,',
+            excludeLabels: ' draft',
+            subscriptionType: Constants.SUBSCRIPTION_TYPE[0],
+        });
+        wrapper.instance().handleSubmit({preventDefault: jest.fn()});
+        expect(wrapper.state().error).toBe('');
+        expect(props.saveChannelSubscription).toHaveBeenCalledWith({
+            alias: 'Abc',
+            baseURL: 'https://test.com',
+            spaceKey: 'test',
+            events: Constants.CONFLUENCE_EVENTS.map((event) => event.value),
+            channelID: 'abcabcabcabcabc',
+            pageID: '',
+            includeLabels: ['runbook', 'release-notes'],
+            excludeLabels: ['draft'],
+            contentType: 'both',
+            ignoreMinorEdits: false,
+            excludeAuthors: [],
+            includeAuthors: [],
+            subscriptionType: 'space_subscription',
+        });
+    });
+
+    test('new page tree subscription', async () => {
+        const props = {
+            ...baseProps,
+            visibility: true,
+        };
+        const wrapper = shallow(
+            <SubscriptionModal {...props}/>,
+        );
+        wrapper.setState({
+            alias: 'Abc',
+            baseURL: 'https://test.com',
+            spaceKey: '',
+            events: Constants.CONFLUENCE_EVENTS,
+            error: '',
+            saving: false,
+            pageID: '1234',
+            subscriptionType: Constants.SUBSCRIPTION_TYPE[2],
+        });
+        wrapper.instance().handleSubmit({preventDefault: jest.fn()});
+        expect(wrapper.state().error).toBe('');
+        expect(props.saveChannelSubscription).toHaveBeenCalledWith({
+            alias: 'Abc',
+            baseURL: 'https://test.com',
+            spaceKey: '',
+            events: Constants.CONFLUENCE_EVENTS.map((event) => event.value),
+            channelID: 'abcabcabcabcabc',
+            pageID: '1234',
+            includeLabels: [],
+            excludeLabels: [],
+            contentType: 'both',
+            ignoreMinorEdits: false,
+            excludeAuthors: [],
+            includeAuthors: [],
+            subscriptionType: 'page_tree_subscription',
+        });
+
+        expect(props.editChannelSubscription).not.toHaveBeenCalled();
+    });
+
+    test('space subscription with content type filter', async () => {
+        const props = {
+            ...baseProps,
+            visibility: true,
+        };
+        const wrapper = shallow(
+            <SubscriptionModal {...props}/>,
+        );
+        wrapper.setState({
+            alias: 'Abc',
+            baseURL: 'https://test.com',
+            spaceKey: 'test',
+            events: Constants.CONFLUENCE_EVENTS,
+            error: '',
+            saving: false,
+            pageID: '',
+            contentType: Constants.CONTENT_TYPE[2],
+            subscriptionType: Constants.SUBSCRIPTION_TYPE[0],
+        });
+        wrapper.instance().handleSubmit({preventDefault: jest.fn()});
+        expect(wrapper.state().error).toBe('');
+        expect(props.saveChannelSubscription).toHaveBeenCalledWith({
+            alias: 'Abc',
+            baseURL: 'https://test.com',
+            spaceKey: 'test',
+            events: Constants.CONFLUENCE_EVENTS.map((event) => event.value),
+            channelID: 'abcabcabcabcabc',
+            pageID: '',
+            includeLabels: [],
+            excludeLabels: [],
+            contentType: 'blogs',
+            ignoreMinorEdits: false,
+            excludeAuthors: [],
+            includeAuthors: [],
+            subscriptionType: 'space_subscription',
+        });
+    });
+
+    test('subscription with author filters', async () => {
+        const props = {
+            ...baseProps,
+            visibility: true,
+        };
+        const wrapper = shallow(
+            <SubscriptionModal {...props}/>,
+        );
+        wrapper.setState({
+            alias: 'Abc',
+            baseURL: 'https://test.com',
+            spaceKey: '',
+            events: Constants.CONFLUENCE_EVENTS,
+            error: '',
+            saving: false,
+            pageID: '1234',
+            ignoreMinorEdits: true,
+            excludeAuthors: 'bulk-bot, importer',
+            includeAuthors: '',
+            subscriptionType: Constants.SUBSCRIPTION_TYPE[1],
+        });
+        wrapper.instance().handleSubmit({preventDefault: jest.fn()});
+        expect(wrapper.state().error).toBe('');
+        expect(props.saveChannelSubscription).toHaveBeenCalledWith({
+            alias: 'Abc',
+            baseURL: 'https://test.com',
+            spaceKey: '',
+            events: Constants.CONFLUENCE_EVENTS.map((event) => event.value),
+            channelID: 'abcabcabcabcabc',
+            pageID: '1234',
+            includeLabels: [],
+            excludeLabels: [],
+            contentType: 'both',
+            ignoreMinorEdits: true,
+            excludeAuthors: ['bulk-bot', 'importer'],
+            includeAuthors: [],
+            subscriptionType: 'page_subscription',
+        });
+    });
 });
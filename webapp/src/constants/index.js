@@ -35,6 +35,22 @@ const CONFLUENCE_EVENTS = [
         value: 'page_removed',
         label: 'Page Remove',
     },
+    {
+        value: 'blog_created',
+        label: 'Blog Post Create',
+    },
+    {
+        value: 'blog_updated',
+        label: 'Blog Post Update',
+    },
+    {
+        value: 'blog_trashed',
+        label: 'Blog Post Trash',
+    },
+    {
+        value: 'blog_removed',
+        label: 'Blog Post Remove',
+    },
 ];
 
 const SUBSCRIPTION_TYPE = [
@@ -46,6 +62,33 @@ const SUBSCRIPTION_TYPE = [
         value: 'page_subscription',
         label: 'Page',
     },
+    {
+        value: 'page_tree_subscription',
+        label: 'Page Tree',
+    },
+    {
+        value: 'cql_subscription',
+        label: 'CQL Query',
+    },
+    {
+        value: 'all_spaces_subscription',
+        label: 'All Spaces',
+    },
+];
+
+const CONTENT_TYPE = [
+    {
+        value: 'both',
+        label: 'Pages and Blog Posts',
+    },
+    {
+        value: 'pages',
+        label: 'Pages',
+    },
+    {
+        value: 'blogs',
+        label: 'Blog Posts',
+    },
 ];
 
 const {id} = manifest;
@@ -68,6 +111,7 @@ export default {
     COMMAND_ADMIN_ONLY,
     SYSTEM_ADMIN_ROLE,
     SUBSCRIPTION_TYPE,
+    CONTENT_TYPE,
     DISCONNECTED_USER,
     ERROR_EXECUTING_COMMAND,
 };
@@ -28,7 +28,7 @@ export default class Hooks {
         const user = getCurrentUser(state);
 
         if (commandTrimmed && commandTrimmed === '/confluence subscribe') {
-            const {data: subscriptionAccessData, error} = await this.store.dispatch(getSubscriptionAccess());
+            const {data: subscriptionAccessData, error} = await this.store.dispatch(getSubscriptionAccess(contextArgs.channel_id));
 
             if (error) {
                 this.store.dispatch(sendEphemeralPost(Constants.ERROR_EXECUTING_COMMAND, contextArgs.channel_id, user.id));
@@ -36,7 +36,7 @@ export default class Hooks {
             }
 
             if (!subscriptionAccessData?.can_run_subscribe_command) {
-                const errorMsg = subscriptionAccessData?.server_version_greater_than_9 ? Constants.DISCONNECTED_USER : Constants.COMMAND_ADMIN_ONLY;
+                const errorMsg = subscriptionAccessData?.message || (subscriptionAccessData?.server_version_greater_than_9 ? Constants.DISCONNECTED_USER : Constants.COMMAND_ADMIN_ONLY);
                 this.store.dispatch(sendEphemeralPost(errorMsg, contextArgs.channel_id, user.id));
                 return Promise.resolve({});
             }
@@ -44,7 +44,7 @@ export default class Hooks {
             this.store.dispatch(openSubscriptionModal());
             return Promise.resolve({});
         } else if (commandTrimmed && commandTrimmed.startsWith('/confluence edit')) {
-            const {data: subscriptionAccessData, error} = await this.store.dispatch(getSubscriptionAccess());
+            const {data: subscriptionAccessData, error} = await this.store.dispatch(getSubscriptionAccess(contextArgs.channel_id));
 
             if (error) {
                 this.store.dispatch(sendEphemeralPost(Constants.ERROR_EXECUTING_COMMAND, contextArgs.channel_id, user.id));
@@ -52,7 +52,7 @@ export default class Hooks {
             }
 
             if (!subscriptionAccessData?.can_run_subscribe_command) {
-                const errorMsg = subscriptionAccessData?.server_version_greater_than_9 ? Constants.DISCONNECTED_USER : Constants.COMMAND_ADMIN_ONLY;
+                const errorMsg = subscriptionAccessData?.message || (subscriptionAccessData?.server_version_greater_than_9 ? Constants.DISCONNECTED_USER : Constants.COMMAND_ADMIN_ONLY);
                 this.store.dispatch(sendEphemeralPost(errorMsg, contextArgs.channel_id, user.id));
                 return Promise.resolve({});
             }
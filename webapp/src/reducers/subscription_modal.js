@@ -20,7 +20,17 @@ export const subscriptionEditModal = (state = {}, action) => {
             spaceKey: action.data.spaceKey,
             events: action.data.events,
             pageID: action.data.pageID,
+            cql: action.data.cql,
+            excludeSpaces: action.data.excludeSpaces,
+            excludePersonalSpaces: action.data.excludePersonalSpaces,
             subscriptionType: action.data.subscriptionType,
+            includeLabels: action.data.includeLabels,
+            excludeLabels: action.data.excludeLabels,
+            contentType: action.data.contentType,
+            ignoreMinorEdits: action.data.ignoreMinorEdits,
+            excludeAuthors: action.data.excludeAuthors,
+            includeAuthors: action.data.includeAuthors,
+            preset: action.data.preset,
         };
     case Constants.ACTION_TYPES.CLOSE_SUBSCRIPTION_MODAL:
         return {};